@@ -0,0 +1,213 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/paginator"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// tuiMarks The markers of the pinned and nested fields, the marked
+// events and the notes. The accessible mode replaces them with words,
+// screen readers skip or misread the symbols.
+var tuiMarks = struct{ pinned, nested, marked, note string }{"★ ", " »", "● ", "✎ "}
+
+// paneNames The names of the panes announced in the accessible mode.
+var paneNames = map[pane]string{
+	queryPane:   "Query",
+	fieldsPane:  "Fields",
+	valuesPane:  "Values",
+	resultsPane: "Results",
+	detailPane:  "Result Detail",
+}
+
+// useAccessibleStyles Switch the styles of the terminal UI to the
+// accessible mode of -accessible: bold, underline and reverse video
+// instead of colors, readable with any color scheme and magnification.
+func useAccessibleStyles() {
+	resultItemStyle = lipgloss.NewStyle().PaddingLeft(2).PaddingRight(2)
+	selectedResultStyle = lipgloss.NewStyle().PaddingLeft(2).PaddingRight(2).Reverse(true).Bold(true)
+	detailViewStyle = lipgloss.NewStyle()
+	msgStyle = lipgloss.NewStyle().Bold(true)
+	timestampStyle = lipgloss.NewStyle()
+	noteStyle = lipgloss.NewStyle().Underline(true)
+	markStyle = lipgloss.NewStyle().Bold(true)
+	titleStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+	activeStyle = lipgloss.NewStyle()
+	inactiveStyle = lipgloss.NewStyle()
+	helpStyle = lipgloss.NewStyle()
+
+	tuiMarks.pinned = locale.Sprintf("pinned: ")
+	tuiMarks.nested = locale.Sprintf(" (nested)")
+	tuiMarks.marked = locale.Sprintf("marked: ")
+	tuiMarks.note = locale.Sprintf("note: ")
+}
+
+// accessibleDelegate The item delegate of the field and value lists in
+// the accessible mode, the selected item in reverse video.
+func accessibleDelegate() list.DefaultDelegate {
+	d := list.NewDefaultDelegate()
+	plain := lipgloss.NewStyle().PaddingLeft(2)
+	selected := plain.Reverse(true).Bold(true)
+	d.Styles.NormalTitle, d.Styles.NormalDesc = plain, plain
+	d.Styles.DimmedTitle, d.Styles.DimmedDesc = plain, plain
+	d.Styles.SelectedTitle, d.Styles.SelectedDesc = selected, selected
+	d.Styles.FilterMatch = lipgloss.NewStyle().Underline(true)
+	return d
+}
+
+// accessibleList Restyle the list for the accessible mode, the page
+// shown as numbers instead of dots.
+func accessibleList(l *list.Model) {
+	l.Styles.Title = titleStyle
+	l.Styles.TitleBar = lipgloss.NewStyle()
+	l.Styles.NoItems = lipgloss.NewStyle()
+	l.Styles.HelpStyle = lipgloss.NewStyle()
+	l.Paginator.Type = paginator.Arabic
+}
+
+// accessibleInput A text input with a steady cursor, nothing on the
+// screen moves on its own in the accessible mode.
+func accessibleInput(ti *textinput.Model) {
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	ti.PromptStyle = lipgloss.NewStyle()
+	ti.PlaceholderStyle = lipgloss.NewStyle()
+}
+
+// activeList The list of the pane, nil for the query and detail panes.
+func (m *model) activeList() *list.Model {
+	if m.showingHistory {
+		return &m.historyList
+	}
+
+	switch m.currentPane {
+	case fieldsPane:
+		return &m.fieldsList
+	case valuesPane:
+		return &m.valuesList
+	case resultsPane:
+		if m.resultsMode == detailModeFormatted {
+			return &m.resultsListFormatted
+		}
+		return &m.resultsListRaw
+	}
+
+	return nil
+}
+
+// announcePane Tell which pane has the focus and what it holds.
+func (m *model) announcePane() {
+	name := locale.Sprintf(paneNames[m.currentPane])
+	if l := m.activeList(); l != nil {
+		m.debugView = locale.Sprintf("%s pane, %d items", name, len(l.VisibleItems()))
+		return
+	}
+	m.debugView = locale.Sprintf("%s pane, enter runs the query", name)
+}
+
+// announceSelection Tell the newly selected item of the list, when the
+// selection moved from the previous index.
+func (m *model) announceSelection(previous int) {
+	l := m.activeList()
+	if l == nil || l.Index() == previous {
+		return
+	}
+
+	item, ok := l.SelectedItem().(interface{ Title() string })
+	if !ok {
+		return
+	}
+	m.debugView = locale.Sprintf("%d of %d: %s", l.Index()+1, len(l.VisibleItems()), item.Title())
+}
+
+// accessibleState The state of the terminal UI in words, instead of the
+// spinner.
+func (m model) accessibleState() string {
+	switch {
+	case m.annotating:
+		return locale.Sprintf("Editing the note of the event, enter saves it, escape cancels")
+	case m.loading:
+		return locale.Sprintf("Loading, please wait, escape cancels")
+	case m.queryState != "":
+		return m.queryState
+	case m.err != nil:
+		return locale.Sprintf("Error: %s", m.err)
+	case len(m.results) > 0:
+		return locale.Sprintf("%d results", len(m.results))
+	}
+
+	return locale.Sprintf("Ready")
+}
+
+// accessibleView The simplified layout of the accessible mode: the lines
+// stacked without borders, with only the pane in focus shown below the
+// query, the state and the last announcement in plain text.
+func (m model) accessibleView() string {
+	if m.width == 0 {
+		return locale.Sprintf("Loading, please wait")
+	}
+
+	if m.showingHelp {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			titleStyle.Render(locale.Sprintf("Help")),
+			m.helpView.View(),
+			"",
+			locale.Sprintf("↑/↓: Scroll • Esc/F1: Close • q: Quit"),
+		)
+	}
+
+	if m.showingHistory {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			m.historyList.View(),
+			"",
+			m.debugView,
+			locale.Sprintf("↑/↓: Select • /: Filter • Enter: Run • Esc/Ctrl+R: Close • q: Quit"),
+		)
+	}
+
+	if m.showingDetail {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			titleStyle.Render(locale.Sprintf(paneNames[detailPane])),
+			m.detailView.View(),
+			"",
+			m.debugView,
+			locale.Sprintf("↑/↓: Scroll • n/p: Next/Previous • y: Copy • Esc: Back to list • q: Quit"),
+		)
+	}
+
+	query := locale.Sprintf("Query: %s", m.queryInput.View())
+	if m.annotating {
+		query = locale.Sprintf("Note: %s", m.noteInput.View())
+	}
+
+	shown := m.currentPane
+	if shown == queryPane {
+		shown = resultsPane
+	}
+	var pane string
+	switch shown {
+	case fieldsPane:
+		pane = m.fieldsList.View()
+	case valuesPane:
+		pane = m.valuesList.View()
+	default:
+		if m.resultsMode == detailModeFormatted {
+			pane = m.resultsListFormatted.View()
+		} else {
+			pane = m.resultsListRaw.View()
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		locale.Sprintf("Focus: %s", locale.Sprintf(paneNames[m.currentPane])),
+		query,
+		"",
+		lipgloss.NewStyle().MaxHeight(m.paneHeight).Render(pane),
+		locale.Sprintf("State: %s", m.accessibleState()),
+		m.debugView,
+		locale.Sprintf("Tab/Shift+Tab: Switch panes • Enter: Execute/Select/View • Backspace: Go up • x/X: Export fields as CSV/JSON • a: Note • A: Annotated only • e: Export events • p/m: Pin field/Select event • Ctrl+S: Save session • Ctrl+R: History • F1: Help • q: Quit"),
+	)
+}
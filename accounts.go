@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// accountField Key of the source account added to the printed events
+// when several accounts are queried.
+const accountField = "_account"
+
+// accountsFlag -account value, comma separated or repeated to query
+// several accounts.
+type accountsFlag struct {
+	value *string
+	set   bool
+}
+
+func (f *accountsFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return *f.value
+}
+
+func (f *accountsFlag) Set(value string) error {
+	// the first use replaces the default, the repeated ones append
+	if f.set && *f.value != "" {
+		value = *f.value + "," + value
+	}
+	*f.value = value
+	f.set = true
+	return nil
+}
+
+func splitList(value string) []string {
+	var ret []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+// accountConfigs One config per queried account. A single token is
+// used for every account, or a comma separated list of tokens in the
+// order of the accounts.
+func (c Config) accountConfigs() ([]Config, error) {
+	accounts := splitList(c.Account)
+	tokens := splitList(c.Token)
+
+	if len(accounts) > 1 && len(tokens) > 1 && len(tokens) != len(accounts) {
+		return nil, fmt.Errorf("got %d tokens for %d accounts, give one token or one per account", len(tokens), len(accounts))
+	}
+
+	configs := make([]Config, len(accounts))
+	for i, account := range accounts {
+		configs[i] = c
+		configs[i].Account = account
+		if len(tokens) > 1 {
+			configs[i].Token = tokens[i]
+		}
+	}
+
+	return configs, nil
+}
+
+// fetchEvents Fetch the events of the query, from every account merged
+// by time when several are given.
+func fetchEvents(ctx context.Context, config Config, query string) (search.Stream, error) {
+	if config.Input != "" {
+		return archiveStream(ctx, config, query)
+	}
+
+	configs, err := config.accountConfigs()
+	if err != nil {
+		return search.Stream{}, err
+	}
+
+	if len(configs) == 1 {
+		c, err := config.newClient()
+		if err != nil {
+			return search.Stream{}, err
+		}
+		return search.NewStream(c.Fetch(ctx, *config.newQuery(query))), nil
+	}
+
+	streams := make([]search.Stream, len(configs))
+	for i, accountConfig := range configs {
+		c, err := accountConfig.newClient()
+		if err != nil {
+			return search.Stream{}, err
+		}
+
+		stream := search.NewStream(c.Fetch(ctx, *accountConfig.newQuery(query)))
+		streams[i] = tagAccount(ctx, stream, accountConfig.Account)
+	}
+
+	return search.NewStream(search.MergeByTime(ctx, false, streams...)), nil
+}
+
+// tagAccount Add the account to the events and to the errors of the
+// stream.
+func tagAccount(ctx context.Context, s search.Stream, account string) search.Stream {
+	tagged := search.Stream{
+		Responses: make(chan search.Response),
+		Errors:    make(chan error),
+	}
+
+	go func() {
+		defer close(tagged.Responses)
+		for res := range s.Responses {
+			for _, event := range res.Events {
+				if m, ok := event.(map[string]any); ok {
+					m[accountField] = account
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case tagged.Responses <- res:
+			}
+		}
+	}()
+
+	go func() {
+		defer close(tagged.Errors)
+		for err := range s.Errors {
+			if err != nil {
+				err = fmt.Errorf("account %s: %w", account, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case tagged.Errors <- err:
+			}
+		}
+	}()
+
+	return tagged
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// fieldReport The field analysis of the events of a query.
+type fieldReport struct {
+	Query  string         `json:"query"`
+	From   string         `json:"from"`
+	Until  string         `json:"until"`
+	Events int            `json:"events"`
+	Fields []fieldSummary `json:"fields"`
+}
+
+// writeText Print a row per field with the number of the events having
+// it, its distinct values and its most frequent values.
+func (r fieldReport) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tCOUNT\tDISTINCT\tTOP VALUES")
+	for _, f := range r.Fields {
+		var top []string
+		for _, v := range f.TopValues {
+			top = append(top, fmt.Sprintf("%s (%s)", v.Value, locale.Int(v.Count)))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Field, locale.Int(f.Count), locale.Int(f.Distinct), strings.Join(top, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s\n", locale.Sprintf("%d events, %d fields", r.Events, len(r.Fields)))
+	return err
+}
+
+func (r fieldReport) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// runAnalyze Print the field analysis of the TUI for the fetched events:
+// every field with its cardinality and its most frequent values, as text
+// or as JSON for the docs and the dashboards.
+func runAnalyze(args []string) {
+	var config Config
+	flags := newFlagSet("loggly analyze", &config)
+	top := flags.Int("top", exportTopValues, "")
+	jsonReport := flags.Bool("json", false, "")
+	flags.Parse(args)
+
+	prepareScanConfig(flags, &config)
+
+	if *top <= 0 {
+		check(usageError(fmt.Errorf("top must be greater than 0")))
+	}
+
+	query, queryErr := resolveQuery(config, flags.Args())
+	check(queryErr)
+	if query == "" {
+		query = "*"
+	}
+	check(lintQuery(config, query))
+
+	analysis := newFieldAnalysis()
+	total := 0
+	scanEvents(config, "analyze", query, func(event any) {
+		total++
+		if m, ok := event.(map[string]any); ok {
+			analysis.Add(m)
+		}
+	})
+
+	report := fieldReport{
+		Query:  query,
+		From:   config.From,
+		Until:  config.To,
+		Events: total,
+		Fields: summarizeFields(analysis.fields, analysis.values, *top),
+	}
+	if *jsonReport {
+		check(report.writeJSON(os.Stdout))
+	} else {
+		check(report.writeText(os.Stdout))
+	}
+	exitIfEmpty(config, int64(total))
+}
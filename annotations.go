@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/notes"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// noteField The field holding the note of an exported event.
+const noteField = "_note"
+
+func openNotes() (*notes.Store, error) {
+	path, err := platform.StateFile("notes.json")
+	if err != nil {
+		return nil, err
+	}
+
+	return notes.Open(path)
+}
+
+// noteOf The note of the event, events without id can not have one.
+func (m *model) noteOf(id string) (notes.Note, bool) {
+	if m.notes == nil || id == "" {
+		return notes.Note{}, false
+	}
+
+	return m.notes.Get(id)
+}
+
+func (m *model) selectedResult() (resultItem, bool) {
+	if m.resultsMode == detailModeFormatted {
+		item, ok := m.resultsListFormatted.SelectedItem().(resultItem)
+		return item, ok
+	}
+
+	item, ok := m.resultsListRaw.SelectedItem().(resultItem)
+	return item, ok
+}
+
+// startNote Open the note prompt for the selected event.
+func (m *model) startNote() tea.Cmd {
+	if m.notes == nil {
+		m.debugView = locale.Sprintf("Notes are not available")
+		return nil
+	}
+
+	item, ok := m.selectedResult()
+	if !ok {
+		return nil
+	}
+	if item.id == "" {
+		m.debugView = locale.Sprintf("The event has no id to attach the note to")
+		return nil
+	}
+
+	m.annotating = true
+	m.noteInput.SetValue(item.note)
+	m.noteInput.CursorEnd()
+	return tea.Batch(m.noteInput.Focus(), textinput.Blink)
+}
+
+// saveNote Store the note of the prompt for the selected event.
+func (m *model) saveNote() {
+	m.annotating = false
+	m.noteInput.Blur()
+
+	item, ok := m.selectedResult()
+	if !ok {
+		return
+	}
+
+	if err := m.notes.Set(item.id, m.noteInput.Value(), time.Now()); err != nil {
+		m.debugView = locale.Sprintf("Saving the note failed: %s", err)
+		return
+	}
+
+	m.updateResultsView()
+	if m.noteInput.Value() == "" {
+		m.debugView = locale.Sprintf("Note removed")
+	} else {
+		m.debugView = locale.Sprintf("Note saved")
+	}
+}
+
+// writeAnnotatedEvents Write the events as NDJSON, annotated events with
+// their note in the _note field.
+func writeAnnotatedEvents(w io.Writer, items []resultItem) error {
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		event := item.data
+		if item.note != "" {
+			event = maps.Clone(item.data)
+			event[noteField] = item.note
+		}
+
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportEvents Write the shown results with their notes to a timestamped
+// file in the working directory.
+func (m *model) exportEvents() {
+	var items []resultItem
+	for _, item := range m.resultsListRaw.Items() {
+		if item, ok := item.(resultItem); ok {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		m.debugView = locale.Sprintf("Nothing to export")
+		return
+	}
+
+	name := filepath.Join(".", fmt.Sprintf("loggly-events-%s.ndjson", time.Now().Format("20060102-150405")))
+	f, err := os.Create(name)
+	if err != nil {
+		m.debugView = locale.Sprintf("Export failed: %s", err)
+		return
+	}
+
+	err = writeAnnotatedEvents(f, items)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		m.debugView = locale.Sprintf("Export failed: %s", err)
+		return
+	}
+	m.debugView = locale.Sprintf("Exported the events to %s", name)
+}
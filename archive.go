@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/archive"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// archiveScheme Prefix of the -input values reading a local archive.
+const archiveScheme = "archive://"
+
+// archiveName The archive name of an -input value.
+func archiveName(input string) (string, error) {
+	name, ok := strings.CutPrefix(input, archiveScheme)
+	if !ok {
+		return "", fmt.Errorf("invalid input %q, use archive://<name>", input)
+	}
+	if err := validateArchiveName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func validateArchiveName(name string) error {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid archive name %q", name)
+	}
+	return nil
+}
+
+// archivesDir Directory of the archives, in the state directory.
+func archivesDir() (string, error) {
+	return platform.StateFile("archives")
+}
+
+func openArchive(name string) (*archive.Archive, error) {
+	dir, err := archivesDir()
+	if err != nil {
+		return nil, err
+	}
+	return archive.Open(filepath.Join(dir, name))
+}
+
+// runArchive Handle the archive subcommands.
+func runArchive(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "sync":
+			runArchiveSync(args[1:])
+			return
+		case "list":
+			runArchiveList()
+			return
+		}
+	}
+
+	check(usageError(fmt.Errorf("usage: loggly archive sync -name <name> [options] [query...] | loggly archive list")))
+}
+
+// runArchiveSync Mirror the events newer than the last sync into the
+// archive. The first sync starts at -from and stores the query, later
+// ones continue where the previous one stopped.
+func runArchiveSync(args []string) {
+	var config Config
+	flags := newFlagSet("loggly archive sync", &config)
+	name := flags.String("name", "", "")
+	indexFields := flags.String("index", "", "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+	check(usageError(validateArchiveName(*name)))
+
+	a, err := openArchive(*name)
+	check(err)
+
+	query := strings.Join(flags.Args(), " ")
+	fields := splitList(*indexFields)
+	if a.Exists() {
+		if query != "" && query != a.Index.Query {
+			check(usageError(fmt.Errorf("archive %s mirrors the query %q, create a new archive for another query", *name, a.Index.Query)))
+		}
+		if *indexFields != "" && strings.Join(fields, ",") != strings.Join(a.Index.Fields, ",") {
+			check(usageError(fmt.Errorf("archive %s indexes %s, the indexed fields can not be changed", *name, strings.Join(a.Index.Fields, ","))))
+		}
+		if a.Index.Cursor > 0 {
+			config.From = time.UnixMilli(a.Index.Cursor).UTC().Format(time.RFC3339Nano)
+		}
+	} else {
+		if query == "" {
+			query = "*"
+		}
+		a.Index.Query = query
+		a.Index.Fields = fields
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+	if len(splitList(config.Account)) > 1 {
+		check(usageError(fmt.Errorf("an archive mirrors a single account")))
+	}
+
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	c, err := config.newClient()
+	check(err)
+
+	check(startAudit(config, "archive sync", a.Index.Query))
+
+	// oldest first, so a sync cut short by the page limits leaves no gap
+	q := config.newQuery(a.Index.Query).Raw(true).Order("asc")
+	resChan, errChan := c.Fetch(ctx, *q)
+
+	var events int
+	var raw []search.Response
+	for resChan != nil {
+		select {
+		case <-ctx.Done():
+			check(ctx.Err())
+		case r, ok := <-resChan:
+			if !ok {
+				resChan = nil
+				continue
+			}
+			raw = append(raw, r)
+			events += r.Len()
+		case err := <-errChan:
+			check(err)
+		}
+	}
+	check(<-errChan)
+	check(finishAudit(int64(events), nil))
+
+	before := a.Count()
+	for _, r := range raw {
+		_, err := a.Append(r.Raw)
+		check(err)
+	}
+	check(a.Save())
+
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Archived %d new events to %s, %d in total", a.Count()-before, *name, a.Count()))
+
+	limit := (config.MaxPages + 1) * int64(config.Size)
+	if config.MaxEvents > 0 {
+		limit = min(limit, config.MaxEvents)
+	}
+	if int64(events) >= limit {
+		config.warnf("The page limits were reached, run the sync again to archive the newer events")
+	}
+}
+
+// runArchiveList Print the archives and what they hold.
+func runArchiveList() {
+	dir, err := archivesDir()
+	check(err)
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	check(err)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tEVENTS\tFROM\tUNTIL\tQUERY")
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		a, err := archive.Open(filepath.Join(dir, entry.Name()))
+		check(err)
+
+		var from, until string
+		if oldest, ok := a.Oldest(); ok {
+			from = locale.Time(oldest)
+			until = locale.Time(time.UnixMilli(a.Index.Cursor))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.Name(), locale.Int(int64(a.Count())), from, until, a.Index.Query)
+	}
+	check(tw.Flush())
+}
+
+// archiveMatch The field values required by the query. Archives are
+// searched offline, so only field:value terms are understood.
+func archiveMatch(query string) (map[string]string, error) {
+	match := make(map[string]string)
+	for _, term := range strings.Fields(query) {
+		if term == "*" || term == "AND" {
+			continue
+		}
+
+		field, value, ok := strings.Cut(term, ":")
+		if !ok || field == "" || value == "" || strings.ContainsAny(term, `()"*/[]`) {
+			return nil, fmt.Errorf("archives understand only field:value terms, filter the events with -where instead of %q", term)
+		}
+		match[field] = value
+	}
+
+	return match, nil
+}
+
+// archiveStream Serve the events of the archive selected by -input like
+// a fetch from the API, in responses of -size events.
+func archiveStream(ctx context.Context, config Config, query string) (search.Stream, error) {
+	name, err := archiveName(config.Input)
+	if err != nil {
+		return search.Stream{}, err
+	}
+
+	a, err := openArchive(name)
+	if err != nil {
+		return search.Stream{}, err
+	}
+	if !a.Exists() {
+		return search.Stream{}, fmt.Errorf("no archive called %s, create it with loggly archive sync -name %s", name, name)
+	}
+
+	match, err := archiveMatch(query)
+	if err != nil {
+		return search.Stream{}, usageError(err)
+	}
+
+	filter := archive.Filter{Match: match}
+	now := time.Now()
+	if t, ok := resolveTime(config.From, now); ok {
+		filter.From = t
+	}
+	if t, ok := resolveTime(config.To, now); ok {
+		filter.Until = t
+	}
+
+	stream := search.Stream{
+		Responses: make(chan search.Response),
+		Errors:    make(chan error),
+	}
+
+	go func() {
+		defer close(stream.Errors)
+
+		var page []any
+		var sent int64
+		send := func() bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case stream.Responses <- search.Response{Total: int64(len(page)), Events: page}:
+				sent += int64(len(page))
+				page = nil
+				return true
+			}
+		}
+
+		err := a.Search(filter, func(event map[string]any) bool {
+			page = append(page, event)
+			if config.MaxEvents > 0 && sent+int64(len(page)) >= config.MaxEvents {
+				send()
+				return false
+			}
+			if len(page) >= config.Size {
+				return send()
+			}
+			return true
+		})
+		if err == nil && len(page) > 0 {
+			send()
+		}
+		close(stream.Responses)
+
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case stream.Errors <- err:
+			}
+		}
+	}()
+
+	return stream, nil
+}
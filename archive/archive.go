@@ -0,0 +1,389 @@
+// Package archive keeps a local copy of events. The events are stored
+// in content addressed NDJSON segments, named by the SHA-256 of their
+// content, and an index records the time range and the values of the
+// selected fields of every segment, so searches read only the segments
+// which can match.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// maxIndexedValues Distinct values of a field recorded per segment.
+// Fields with more values are not indexed in the segment, which then
+// has to be read to find them.
+const maxIndexedValues = 1000
+
+const indexFile = "index.json"
+
+// Segment An NDJSON file of events, ordered by time.
+type Segment struct {
+	// Hash SHA-256 of the content, the name of the file.
+	Hash string `json:"hash"`
+	// From Time of the oldest event in epoch milliseconds.
+	From int64 `json:"from"`
+	// Until Time of the newest event in epoch milliseconds.
+	Until int64 `json:"until"`
+	Count int   `json:"count"`
+	// Fields Distinct values of the indexed fields.
+	Fields map[string][]string `json:"fields,omitempty"`
+}
+
+// Index Description of the archive.
+type Index struct {
+	// Query The query mirrored into the archive.
+	Query string `json:"query"`
+	// Fields The indexed fields.
+	Fields []string `json:"fields,omitempty"`
+	// Cursor Time of the newest archived event in epoch milliseconds.
+	Cursor int64 `json:"cursor"`
+	// CursorIDs IDs of the events archived at Cursor, the next sync
+	// starts at Cursor and skips them.
+	CursorIDs []string  `json:"cursor_ids,omitempty"`
+	Segments  []Segment `json:"segments"`
+}
+
+// Archive Events stored in a directory.
+type Archive struct {
+	Dir   string
+	Index Index
+}
+
+// Open Read the archive in the directory, an empty archive if it does
+// not exist yet.
+func Open(dir string) (*Archive, error) {
+	a := &Archive{Dir: dir}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &a.Index); err != nil {
+		return nil, fmt.Errorf("archive: invalid index %s: %w", filepath.Join(dir, indexFile), err)
+	}
+
+	return a, nil
+}
+
+// Exists Whether anything was archived yet.
+func (a *Archive) Exists() bool {
+	return len(a.Index.Segments) > 0 || a.Index.Query != ""
+}
+
+// Count Number of the archived events.
+func (a *Archive) Count() int {
+	n := 0
+	for _, s := range a.Index.Segments {
+		n += s.Count
+	}
+	return n
+}
+
+// Oldest Time of the oldest archived event.
+func (a *Archive) Oldest() (time.Time, bool) {
+	if len(a.Index.Segments) == 0 {
+		return time.Time{}, false
+	}
+
+	oldest := a.Index.Segments[0].From
+	for _, s := range a.Index.Segments[1:] {
+		oldest = min(oldest, s.From)
+	}
+	return time.UnixMilli(oldest), true
+}
+
+type storedEvent struct {
+	raw   json.RawMessage
+	id    string
+	ts    int64
+	value map[string]any
+}
+
+func decodeEvent(raw json.RawMessage) (storedEvent, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+
+	var m map[string]any
+	if err := d.Decode(&m); err != nil {
+		return storedEvent{}, err
+	}
+
+	ts, ok := search.EventTimestamp(m)
+	if !ok {
+		return storedEvent{}, fmt.Errorf("archive: event without timestamp")
+	}
+
+	id, _ := m["id"].(string)
+	return storedEvent{raw: raw, id: id, ts: ts.UnixMilli(), value: m}, nil
+}
+
+// Append Store the events in a new segment and advance the cursor.
+// Events at the cursor already archived by the previous call are
+// skipped. The index is written by Save.
+func (a *Archive) Append(events []json.RawMessage) (Segment, error) {
+	var stored []storedEvent
+	for _, raw := range events {
+		e, err := decodeEvent(raw)
+		if err != nil {
+			return Segment{}, err
+		}
+
+		if e.ts < a.Index.Cursor || (e.ts == a.Index.Cursor && e.id != "" && slices.Contains(a.Index.CursorIDs, e.id)) {
+			continue
+		}
+		stored = append(stored, e)
+	}
+
+	if len(stored) == 0 {
+		return Segment{}, nil
+	}
+
+	sort.SliceStable(stored, func(i, j int) bool { return stored[i].ts < stored[j].ts })
+
+	var buf bytes.Buffer
+	values := make(map[string]map[string]bool)
+	for _, e := range stored {
+		if err := json.Compact(&buf, e.raw); err != nil {
+			return Segment{}, err
+		}
+		buf.WriteByte('\n')
+
+		for _, field := range a.Index.Fields {
+			v, ok := Lookup(e.value, field)
+			if !ok {
+				continue
+			}
+			if values[field] == nil {
+				values[field] = make(map[string]bool)
+			}
+			values[field][v] = true
+		}
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	seg := Segment{
+		Hash:  hex.EncodeToString(sum[:]),
+		From:  stored[0].ts,
+		Until: stored[len(stored)-1].ts,
+		Count: len(stored),
+	}
+
+	for field, set := range values {
+		if len(set) > maxIndexedValues {
+			continue
+		}
+		if seg.Fields == nil {
+			seg.Fields = make(map[string][]string)
+		}
+		for v := range set {
+			seg.Fields[field] = append(seg.Fields[field], v)
+		}
+		sort.Strings(seg.Fields[field])
+	}
+
+	if err := a.writeSegment(seg.Hash, buf.Bytes()); err != nil {
+		return Segment{}, err
+	}
+
+	// the same content is stored once
+	if !slices.ContainsFunc(a.Index.Segments, func(s Segment) bool { return s.Hash == seg.Hash }) {
+		a.Index.Segments = append(a.Index.Segments, seg)
+	}
+
+	if seg.Until > a.Index.Cursor {
+		a.Index.Cursor = seg.Until
+		a.Index.CursorIDs = nil
+	}
+	for _, e := range stored {
+		if e.ts == a.Index.Cursor && e.id != "" {
+			a.Index.CursorIDs = append(a.Index.CursorIDs, e.id)
+		}
+	}
+
+	return seg, nil
+}
+
+func (a *Archive) segmentPath(hash string) string {
+	return filepath.Join(a.Dir, "segments", hash+".ndjson")
+}
+
+func (a *Archive) writeSegment(hash string, data []byte) error {
+	path := a.segmentPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return platform.WriteFile(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// Save Write the index.
+func (a *Archive) Save() error {
+	data, err := json.MarshalIndent(a.Index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(a.Dir, 0o700); err != nil {
+		return err
+	}
+
+	return platform.WriteFile(filepath.Join(a.Dir, indexFile), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// Filter Selects the events of a search.
+type Filter struct {
+	From  time.Time
+	Until time.Time
+	// Match Required values of fields, compared as strings.
+	Match map[string]string
+}
+
+// mayMatch Whether the segment can hold matching events according to
+// the index.
+func (f Filter) mayMatch(s Segment) bool {
+	if !f.From.IsZero() && s.Until < f.From.UnixMilli() {
+		return false
+	}
+	if !f.Until.IsZero() && s.From > f.Until.UnixMilli() {
+		return false
+	}
+
+	for field, want := range f.Match {
+		if values, ok := s.Fields[field]; ok && !slices.Contains(values, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f Filter) match(e storedEvent) bool {
+	if !f.From.IsZero() && e.ts < f.From.UnixMilli() {
+		return false
+	}
+	if !f.Until.IsZero() && e.ts > f.Until.UnixMilli() {
+		return false
+	}
+
+	for field, want := range f.Match {
+		if v, ok := Lookup(e.value, field); !ok || v != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Search Call fn with the matching events, newest first, until fn
+// returns false.
+func (a *Archive) Search(f Filter, fn func(event map[string]any) bool) error {
+	segments := slices.Clone(a.Index.Segments)
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].Until > segments[j].Until })
+
+	for _, s := range segments {
+		if !f.mayMatch(s) {
+			continue
+		}
+
+		events, err := a.readSegment(s.Hash)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range slices.Backward(events) {
+			if f.match(e) && !fn(e.value) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *Archive) readSegment(hash string) ([]storedEvent, error) {
+	f, err := os.Open(a.segmentPath(hash))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []storedEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		e, err := decodeEvent(bytes.Clone(scanner.Bytes()))
+		if err != nil {
+			return nil, fmt.Errorf("archive: segment %s: %w", hash, err)
+		}
+		events = append(events, e)
+	}
+
+	return events, scanner.Err()
+}
+
+// Lookup The value of a dotted field path of a whole event as a string.
+// Loggly style json.* paths are looked up under the event field as well.
+func Lookup(event map[string]any, path string) (string, bool) {
+	v, ok := lookupPath(event, path)
+	if !ok {
+		v, ok = lookupPath(event, "event."+path)
+	}
+	if !ok {
+		return "", false
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+func lookupPath(v any, path string) (any, bool) {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func event(id string, ts int64, level string) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"id":%q,"timestamp":%d,"event":{"json":{"level":%q}}}`, id, ts, level))
+}
+
+func ids(t *testing.T, a *Archive, f Filter) []string {
+	t.Helper()
+
+	var ret []string
+	err := a.Search(f, func(e map[string]any) bool {
+		ret = append(ret, e["id"].(string))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ret
+}
+
+func TestAppendAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Index.Fields = []string{"json.level"}
+
+	if _, err := a.Append([]json.RawMessage{event("b", 2000, "error"), event("a", 1000, "info")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Append([]json.RawMessage{event("c", 3000, "info")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Count() != 3 || len(a.Index.Segments) != 2 || a.Index.Cursor != 3000 {
+		t.Fatalf("unexpected index %+v", a.Index)
+	}
+
+	if got := fmt.Sprint(ids(t, a, Filter{})); got != "[c b a]" {
+		t.Errorf("expected newest first, got %s", got)
+	}
+
+	if got := fmt.Sprint(ids(t, a, Filter{Match: map[string]string{"json.level": "info"}})); got != "[c a]" {
+		t.Errorf("expected the info events, got %s", got)
+	}
+
+	f := Filter{From: time.UnixMilli(1500), Until: time.UnixMilli(2500)}
+	if got := fmt.Sprint(ids(t, a, f)); got != "[b]" {
+		t.Errorf("expected the events of the time range, got %s", got)
+	}
+}
+
+func TestAppendSkipsArchivedCursorEvents(t *testing.T) {
+	a, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Append([]json.RawMessage{event("a", 1000, "info"), event("b", 2000, "info")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next sync starts at the cursor and returns b again
+	seg, err := a.Append([]json.RawMessage{event("b", 2000, "info"), event("c", 2000, "info"), event("old", 500, "info")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seg.Count != 1 || a.Count() != 3 {
+		t.Errorf("expected only c to be appended, got %+v", a.Index)
+	}
+	if fmt.Sprint(a.Index.CursorIDs) != "[b c]" {
+		t.Errorf("unexpected cursor ids %v", a.Index.CursorIDs)
+	}
+}
+
+func TestSegmentsAreContentAddressed(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seg, err := a.Append([]json.RawMessage{event("a", 1000, "info")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "segments", seg.Hash+".ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(event("a", 1000, "info"))+"\n" {
+		t.Errorf("unexpected segment content %q", data)
+	}
+}
+
+func TestMayMatchUsesTheIndex(t *testing.T) {
+	s := Segment{From: 1000, Until: 2000, Fields: map[string][]string{"json.level": {"error"}}}
+
+	tests := []struct {
+		filter Filter
+		want   bool
+	}{
+		{Filter{}, true},
+		{Filter{Match: map[string]string{"json.level": "error"}}, true},
+		{Filter{Match: map[string]string{"json.level": "info"}}, false},
+		{Filter{Match: map[string]string{"json.host": "web-1"}}, true},
+		{Filter{From: time.UnixMilli(2001)}, false},
+		{Filter{Until: time.UnixMilli(999)}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.filter.mayMatch(s); got != test.want {
+			t.Errorf("%+v: expected %v, got %v", test.filter, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/rules"
+)
+
+// assertResult The outcome of a rule in the report of loggly assert.
+type assertResult struct {
+	Name   string       `json:"name"`
+	Query  string       `json:"query"`
+	From   string       `json:"from"`
+	Until  string       `json:"until"`
+	Expect rules.Expect `json:"expect"`
+	Count  int64        `json:"count"`
+	Passed bool         `json:"passed"`
+	// Failures The unmet expectations.
+	Failures []string `json:"failures,omitempty"`
+	// Error Why the rule could not be checked.
+	Error string `json:"error,omitempty"`
+}
+
+// assertReport The machine readable report of loggly assert.
+type assertReport struct {
+	Passed bool           `json:"passed"`
+	Rules  []assertResult `json:"rules"`
+}
+
+// sumCount The number of events of the query, summed over the
+// accounts.
+func sumCount(ctx context.Context, config Config, query string) (int64, error) {
+	configs, err := config.accountConfigs()
+	if err != nil {
+		return 0, err
+	}
+
+	var sum int64
+	for _, accountConfig := range configs {
+		n, err := fetchCount(ctx, accountConfig, query)
+		if err != nil {
+			return 0, err
+		}
+		sum += n
+	}
+
+	return sum, nil
+}
+
+// missingFields The number of events lacking each of the fields, from
+// the first -size events of the query.
+func missingFields(ctx context.Context, config Config, query string, fields []string) (map[string]int, error) {
+	config.MaxEvents = int64(config.Size)
+	stream, err := fetchEvents(ctx, config, query)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make(map[string]int)
+	resChan := stream.Responses
+	for resChan != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case r, ok := <-resChan:
+			if !ok {
+				resChan = nil
+				continue
+			}
+			for _, event := range r.Events {
+				for _, field := range fields {
+					if _, ok := lookupField(event, field); !ok {
+						missing[field]++
+					}
+				}
+			}
+		case err := <-stream.Errors:
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := <-stream.Errors; err != nil {
+		return nil, err
+	}
+
+	return missing, nil
+}
+
+// checkRule Run the query of the rule and compare the results with the
+// expectations.
+func checkRule(ctx context.Context, config Config, rule rules.Rule) assertResult {
+	if rule.From != "" {
+		config.From = rule.From
+	}
+	if rule.Until != "" {
+		config.To = rule.Until
+	}
+
+	result := assertResult{
+		Name:   rule.Name,
+		Query:  rule.Query,
+		From:   config.From,
+		Until:  config.To,
+		Expect: rule.Expect,
+	}
+
+	fail := func(err error) assertResult {
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := validateTimeRange(config.From, config.To, time.Now()); err != nil {
+		return fail(err)
+	}
+	if err := config.normalizeTimeRange(); err != nil {
+		return fail(err)
+	}
+
+	n, err := auditQuery(config, "assert", rule.Query, func() (int64, error) {
+		return sumCount(ctx, config, rule.Query)
+	})
+	if err != nil {
+		return fail(err)
+	}
+	result.Count = n
+
+	if !rule.Expect.Count.Match(n) {
+		result.Failures = append(result.Failures, fmt.Sprintf("count is %d, expected %s", n, rule.Expect.Count))
+	}
+
+	if len(rule.Expect.Fields) > 0 && n > 0 {
+		missing, err := missingFields(ctx, config, rule.Query, rule.Expect.Fields)
+		if err != nil {
+			return fail(err)
+		}
+
+		for _, field := range rule.Expect.Fields {
+			if missing[field] > 0 {
+				result.Failures = append(result.Failures, fmt.Sprintf("%d events have no %s", missing[field], field))
+			}
+		}
+	}
+
+	result.Passed = len(result.Failures) == 0
+	return result
+}
+
+// runAssert Check the golden queries of a rules file and print a JSON
+// report, exiting with exitAssertFailed when an expectation is not met.
+func runAssert(args []string) {
+	var config Config
+	flags := newFlagSet("loggly assert", &config)
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	if flags.NArg() != 1 {
+		check(usageError(fmt.Errorf("usage: loggly assert [options] <rules.yaml>")))
+	}
+
+	file, err := rules.Load(flags.Arg(0))
+	check(usageError(err))
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	check(usageError(config.Validate()))
+
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	var report assertReport
+	var failed, broken int
+	for _, rule := range file.Rules {
+		result := checkRule(ctx, config, rule)
+		report.Rules = append(report.Rules, result)
+
+		switch {
+		case result.Error != "":
+			broken++
+			fmt.Fprintf(os.Stderr, "ERROR %s: %s\n", result.Name, result.Error)
+		case !result.Passed:
+			failed++
+			for _, failure := range result.Failures {
+				fmt.Fprintf(os.Stderr, "FAIL  %s: %s\n", result.Name, failure)
+			}
+		case !config.Quiet:
+			fmt.Fprintf(os.Stderr, "ok    %s\n", result.Name)
+		}
+	}
+	report.Passed = failed == 0 && broken == 0
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	check(enc.Encode(report))
+
+	if !config.Quiet {
+		fmt.Fprintln(os.Stderr, locale.Sprintf("%d of %d rules passed", len(file.Rules)-failed-broken, len(file.Rules)))
+	}
+
+	if broken > 0 {
+		os.Exit(exitError)
+	}
+	if failed > 0 {
+		os.Exit(exitAssertFailed)
+	}
+}
@@ -0,0 +1,113 @@
+// Package audit records the executed queries in a JSON lines file, for
+// the teams which have to account for the access to their logs.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry An executed query. The token is never recorded.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Host    string    `json:"host,omitempty"`
+	Command string    `json:"command"`
+	Account string    `json:"account"`
+	Query   string    `json:"query"`
+	From    string    `json:"from,omitempty"`
+	Until   string    `json:"until,omitempty"`
+	// Results The number of events the query returned, or counted.
+	Results  int64 `json:"results"`
+	Duration int64 `json:"duration_ms"`
+	// Error Why the query failed, empty if it did not.
+	Error string `json:"error,omitempty"`
+}
+
+// Log An audit log file.
+type Log struct {
+	path string
+}
+
+// Open Open the log, creating the file if it does not exist, so a log
+// which can not be written fails before the query is executed.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return &Log{path: path}, nil
+}
+
+// Record Append the entry as a line. The line is written at once, the
+// entries of the invocations running at the same time do not mix.
+func (l *Log) Record(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Read Read the entries of a log, oldest first.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("audit: invalid entry at line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, scanner.Err()
+}
+
+// ReadFile Read the entries of the log file, none if it does not exist.
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "audit.jsonl")
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []Entry{
+		{Time: now, User: "alice", Command: "search", Account: "acme", Query: "json.level:error", From: "2024-03-01T11:00:00Z", Until: "2024-03-01T12:00:00Z", Results: 42, Duration: 1200},
+		{Time: now.Add(time.Minute), User: "bob", Command: "export", Account: "acme", Query: "*", Error: "401 Unauthorized"},
+	}
+	for _, e := range entries {
+		if err := l.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("expected %d entries, got %d", len(entries), len(got))
+	}
+	for i := range entries {
+		if got[i] != entries[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, entries[i], got[i])
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected the log to be private, got %v", perm)
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	entries, err := ReadFile(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected no entries, got %v, %v", entries, err)
+	}
+}
+
+func TestReadInvalidLine(t *testing.T) {
+	_, err := Read(strings.NewReader("{\"user\":\"alice\"}\n\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected an error at line 3, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/audit"
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+)
+
+// auditLogPath The audit log of the [audit] section of the config file,
+// audit.file or audit.jsonl in the state directory. Empty unless
+// audit.enabled is set.
+func auditLogPath(file configfile.File) (string, error) {
+	value := file["audit.enabled"]
+	if value == "" {
+		return "", nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid audit.enabled %q in the config file, use true or false", value)
+	}
+	if !enabled {
+		return "", nil
+	}
+
+	if path := file["audit.file"]; path != "" {
+		return path, nil
+	}
+	return platform.StateFile("audit.jsonl")
+}
+
+// currentUser The name of the user running the command.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// auditedQuery A query being executed, recorded in the audit log and in
+// the history once it is done.
+type auditedQuery struct {
+	config Config
+	// log The audit log, nil when auditing is off.
+	log   *audit.Log
+	entry audit.Entry
+}
+
+// newAuditedQuery Start the audit of the query, nil when auditing and
+// the history are off. Fails when the audit log can not be written, so
+// no query runs unrecorded.
+func newAuditedQuery(config Config, command, query string) (*auditedQuery, error) {
+	if config.AuditLog == "" && config.HistoryFile == "" {
+		return nil, nil
+	}
+
+	var l *audit.Log
+	if config.AuditLog != "" {
+		var err error
+		if l, err = audit.Open(config.AuditLog); err != nil {
+			return nil, fmt.Errorf("the audit log can not be written: %w", err)
+		}
+	}
+
+	now := time.Now()
+	host, _ := os.Hostname()
+	q := &auditedQuery{config: config, log: l, entry: audit.Entry{
+		Time:    now.UTC(),
+		User:    currentUser(),
+		Host:    host,
+		Command: command,
+		Account: config.Account,
+		Query:   query,
+		From:    config.From,
+		Until:   config.To,
+	}}
+	// the relative times are recorded as the times they meant
+	for _, t := range []*string{&q.entry.From, &q.entry.Until} {
+		if resolved, ok := resolveTime(*t, now); ok {
+			*t = resolved.UTC().Format(time.RFC3339)
+		}
+	}
+
+	return q, nil
+}
+
+// record Record the query with the number of events it returned, or the
+// error it failed with. The history failing only warns.
+func (q *auditedQuery) record(results int64, err error) error {
+	if q == nil {
+		return nil
+	}
+
+	if historyErr := recordHistory(q.config, q.entry.Time, q.entry.Command, q.entry.Query, results, err); historyErr != nil {
+		q.config.warnf("The history could not be updated: %s", historyErr)
+	}
+	if q.log == nil {
+		return nil
+	}
+
+	q.entry.Results = results
+	q.entry.Duration = time.Since(q.entry.Time).Milliseconds()
+	if err != nil {
+		q.entry.Error = err.Error()
+	}
+
+	if err := q.log.Record(q.entry); err != nil {
+		return fmt.Errorf("the audit log can not be written: %w", err)
+	}
+	return nil
+}
+
+// auditQuery Run the query, recording it in the audit log with the
+// number of events run returns.
+func auditQuery(config Config, command, query string, run func() (int64, error)) (int64, error) {
+	q, err := newAuditedQuery(config, command, query)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := run()
+	return n, errors.Join(err, q.record(n, err))
+}
+
+// pendingAudit The query of the command in progress, check records it
+// with the error when the command fails.
+var pendingAudit *auditedQuery
+
+// startAudit Start the audit of the query of the command.
+func startAudit(config Config, command, query string) error {
+	q, err := newAuditedQuery(config, command, query)
+	pendingAudit = q
+	return err
+}
+
+// finishAudit Record the query of the command, once.
+func finishAudit(results int64, err error) error {
+	q := pendingAudit
+	pendingAudit = nil
+	return q.record(results, err)
+}
+
+// runAudit Print the entries of the audit log.
+func runAudit(args []string) {
+	flags := flag.NewFlagSet("loggly audit", flag.ExitOnError)
+	from := flags.String("from", "", "")
+	userName := flags.String("user", "", "")
+	jsonLines := flags.Bool("json", false, "")
+	path := flags.String("file", "", "")
+	flags.Parse(args)
+
+	if *path == "" {
+		fileConfig, err := loadConfigFile()
+		check(usageError(err))
+		*path, err = auditLogPath(fileConfig)
+		check(usageError(err))
+	}
+	if *path == "" {
+		check(usageError(errors.New("the audit log is not enabled, set audit.enabled = true in the config file, or read a log with -file")))
+	}
+
+	var since time.Time
+	if *from != "" {
+		t, ok := resolveTime(*from, time.Now())
+		if !ok {
+			check(usageError(fmt.Errorf("invalid from %q", *from)))
+		}
+		since = t
+	}
+
+	entries, err := audit.ReadFile(*path)
+	check(err)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if !*jsonLines {
+		fmt.Fprintln(tw, "TIME\tUSER\tCOMMAND\tACCOUNT\tFROM\tUNTIL\tRESULTS\tQUERY\tERROR")
+	}
+	for _, e := range entries {
+		if e.Time.Before(since) || (*userName != "" && e.User != *userName) {
+			continue
+		}
+
+		if *jsonLines {
+			check(printJSON(os.Stdout, []any{e}))
+			continue
+		}
+
+		results := locale.Int(e.Results)
+		if e.Error != "" {
+			results = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", locale.Time(e.Time), e.User, e.Command, e.Account, e.From, e.Until, results, e.Query, e.Error)
+	}
+	check(tw.Flush())
+}
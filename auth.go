@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// runAuth Handle the auth subcommands.
+func runAuth(args []string) {
+	if len(args) == 0 || args[0] != "check" {
+		check(usageError(fmt.Errorf("usage: loggly auth check [options]")))
+	}
+
+	var config Config
+	flags := newFlagSet("loggly auth check", &config)
+	flags.Parse(args[1:])
+
+	prepareScanConfig(flags, &config)
+	check(usageError(config.Validate()))
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	configs, err := config.accountConfigs()
+	check(usageError(err))
+
+	for _, accountConfig := range configs {
+		checkAccount(ctx, accountConfig)
+	}
+}
+
+// checkAccount Verify a single account, exit with exitAuthFailed when
+// the token is rejected.
+func checkAccount(ctx context.Context, config Config) {
+	c, err := config.newClient()
+	check(err)
+
+	// The smallest possible search proves both the account and the token.
+	_, err = c.Estimate(ctx, *search.NewQuery("*").From("-1m"))
+
+	var apiErr *search.APIError
+	if errors.As(err, &apiErr) && apiErr.IsAuthError() {
+		fmt.Fprintln(os.Stderr, locale.Sprintf("Authentication failed for account %q: %s", config.Account, apiErr.Status))
+		if apiErr.Hint != "" {
+			fmt.Fprintln(os.Stderr, apiErr.Hint)
+		}
+		os.Exit(exitAuthFailed)
+	}
+	check(err)
+
+	fmt.Println(locale.Sprintf("OK: the token can search account %q", config.Account))
+}
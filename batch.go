@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// batchQuery A query of the batch file, identified by its line number.
+type batchQuery struct {
+	id    string
+	query string
+}
+
+// readBatch Read the queries of a batch file, one per line, skipping
+// the empty lines and the # comments.
+func readBatch(r io.Reader) ([]batchQuery, error) {
+	var queries []batchQuery
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		queries = append(queries, batchQuery{id: strconv.Itoa(line), query: text})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading the batch file: %w", err)
+	}
+
+	return queries, nil
+}
+
+// prefixWriter Prefix every line with the query id. Complete lines are
+// written at once, so the output of parallel queries does not mix
+// within a line.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix []byte
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+
+	i := bytes.LastIndexByte(p.buf, '\n')
+	if i < 0 {
+		return len(data), nil
+	}
+
+	var out []byte
+	for _, line := range bytes.SplitAfter(p.buf[:i+1], []byte("\n")) {
+		if len(line) > 0 {
+			out = append(append(out, p.prefix...), line...)
+		}
+	}
+	p.buf = append(p.buf[:0], p.buf[i+1:]...)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.w.Write(out); err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+// runBatchQuery Print the events, or with count the number of events,
+// of a single batch query to w. Returns the number of matching events.
+func runBatchQuery(ctx context.Context, config Config, query string, count bool, w io.Writer) (int64, error) {
+	if err := lintQuery(config, query); err != nil {
+		return 0, err
+	}
+
+	if count {
+		configs, err := config.accountConfigs()
+		if err != nil {
+			return 0, err
+		}
+
+		var sum int64
+		for _, accountConfig := range configs {
+			n, err := fetchCount(ctx, accountConfig, query)
+			if err != nil {
+				return 0, err
+			}
+			sum += n
+		}
+
+		_, err = fmt.Fprintln(w, sum)
+		return sum, err
+	}
+
+	config.raw = config.rawPassthrough()
+	stream, err := fetchEvents(ctx, config, query)
+	if err != nil {
+		return 0, err
+	}
+
+	filters, err := newEventFilters(config)
+	if err != nil {
+		return 0, err
+	}
+	dedup := newDedupFilter(config)
+
+	formatter := newFormatter(config, query)
+	var printed int64
+	for {
+		select {
+		case <-ctx.Done():
+			return printed, ctx.Err()
+		case r := <-stream.Responses:
+			if r.Raw != nil {
+				if err := printRaw(w, r.Raw); err != nil {
+					return printed, err
+				}
+				printed += int64(len(r.Raw))
+				continue
+			}
+
+			r.Events = dedup.Apply(r.Events)
+			events, ok := decodeRes(config, r)
+			if !ok {
+				continue
+			}
+			events = filters.Apply(events)
+
+			if err := formatter.Format(w, events); err != nil {
+				return printed, err
+			}
+			printed += int64(len(events))
+		case err := <-stream.Errors:
+			return printed, err
+		}
+	}
+}
+
+// runBatch Run every query of a file and print their events prefixed
+// with the line number of the query.
+func runBatch(args []string) {
+	var config Config
+	flags := newFlagSet("loggly batch", &config)
+	parallel := flags.Int("parallel", 1, "")
+	count := flags.Bool("count", false, "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+	colorRules, colorErr := parseColorRules(fileConfig)
+	check(usageError(colorErr))
+	config.ColorRules = colorRules
+
+	if flags.NArg() != 1 {
+		check(usageError(fmt.Errorf("usage: loggly batch [options] <file>")))
+	}
+	if *parallel < 1 {
+		check(usageError(fmt.Errorf("parallel must be at least 1")))
+	}
+
+	var in io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		check(usageError(err))
+		defer f.Close()
+		in = f
+	} else if config.Token == "-" {
+		check(usageError(fmt.Errorf("the standard input can not provide both the token and the queries")))
+	}
+
+	queries, err := readBatch(in)
+	check(err)
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	config.Format = resolveFormat(config, fileConfig)
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+	useTimeLocation(config)
+
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	var (
+		mu     sync.Mutex
+		failed bool
+		empty  bool
+		g      errgroup.Group
+	)
+	g.SetLimit(*parallel)
+	for _, q := range queries {
+		g.Go(func() error {
+			w := &prefixWriter{w: os.Stdout, mu: &mu, prefix: []byte(q.id + "\t")}
+			n, err := auditQuery(config, "batch", q.query, func() (int64, error) {
+				return runBatchQuery(ctx, config, q.query, *count, w)
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locale.Sprintf("Error: line %s: %s", q.id, err))
+				failed = true
+			}
+			empty = empty || n == 0
+
+			return nil
+		})
+	}
+	g.Wait()
+
+	if failed {
+		os.Exit(exitError)
+	}
+	if config.FailEmpty && empty {
+		os.Exit(exitNoResults)
+	}
+}
@@ -0,0 +1,124 @@
+// Package budget shares a request budget between processes on one
+// machine. The budget file holds the times of the recent requests and is
+// locked while a process takes its share, so simultaneous invocations,
+// like a cron job and an interactive session, together stay under the
+// limit.
+package budget
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/platform"
+)
+
+// Budget Allows limit requests per window across the processes using
+// the same file.
+type Budget struct {
+	path   string
+	limit  int
+	window time.Duration
+
+	// now Replaced in tests.
+	now func() time.Time
+}
+
+// New Create a budget of limit requests per window stored in the file.
+func New(path string, limit int, window time.Duration) *Budget {
+	return &Budget{path: path, limit: limit, window: window, now: time.Now}
+}
+
+// Wait Block until the budget allows a request and record it.
+func (b *Budget) Wait(ctx context.Context) error {
+	for {
+		wait, err := b.take()
+		if err != nil {
+			return err
+		}
+		if wait <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// take Record a request if the budget allows it, otherwise return how
+// long to wait before trying again.
+func (b *Budget) take() (time.Duration, error) {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o700); err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(b.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if err := platform.LockFile(f); err != nil {
+		return 0, fmt.Errorf("budget: locking %s: %w", b.path, err)
+	}
+	defer platform.UnlockFile(f)
+
+	now := b.now()
+	times, err := readTimes(f, now.Add(-b.window))
+	if err != nil {
+		return 0, fmt.Errorf("budget: reading %s: %w", b.path, err)
+	}
+
+	if len(times) >= b.limit {
+		// the oldest requests have to leave the window first
+		return times[len(times)-b.limit].Add(b.window).Sub(now), nil
+	}
+
+	times = append(times, now)
+	return 0, writeTimes(f, times)
+}
+
+// readTimes The request times after since, oldest first.
+func readTimes(r io.Reader, since time.Time) ([]time.Time, error) {
+	var times []time.Time
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		ns, err := strconv.ParseInt(strings.TrimSpace(scanner.Text()), 10, 64)
+		if err != nil {
+			// a damaged line only loses one request from the budget
+			continue
+		}
+
+		if t := time.Unix(0, ns); t.After(since) {
+			times = append(times, t)
+		}
+	}
+
+	return times, scanner.Err()
+}
+
+func writeTimes(f *os.File, times []time.Time) error {
+	var sb strings.Builder
+	for _, t := range times {
+		sb.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+		sb.WriteByte('\n')
+	}
+
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt([]byte(sb.String()), 0)
+	return err
+}
@@ -0,0 +1,76 @@
+package budget
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTakeWithinLimit(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := New(filepath.Join(t.TempDir(), "budget"), 2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	for i := range 2 {
+		wait, err := b.take()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != 0 {
+			t.Fatalf("request %d: expected no wait, got %s", i, wait)
+		}
+	}
+
+	now = now.Add(10 * time.Second)
+	wait, err := b.take()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait != 50*time.Second {
+		t.Errorf("expected to wait until the first request leaves the window, got %s", wait)
+	}
+
+	now = now.Add(50 * time.Second)
+	if wait, err := b.take(); err != nil || wait != 0 {
+		t.Errorf("expected the budget to allow a request again, got %s, %v", wait, err)
+	}
+}
+
+func TestSharedBetweenBudgets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budget")
+	now := time.Unix(1000, 0)
+
+	first := New(path, 1, time.Minute)
+	first.now = func() time.Time { return now }
+	second := New(path, 1, time.Minute)
+	second.now = func() time.Time { return now }
+
+	if wait, err := first.take(); err != nil || wait != 0 {
+		t.Fatalf("unexpected %s, %v", wait, err)
+	}
+	if wait, err := second.take(); err != nil || wait != time.Minute {
+		t.Errorf("expected the second budget to see the first request, got %s, %v", wait, err)
+	}
+}
+
+func TestWaitConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budget")
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if err := New(path, 10, time.Minute).Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := New(path, 10, time.Minute).Wait(ctx); err == nil {
+		t.Error("expected the exhausted budget to block until the context is done")
+	}
+}
@@ -2,6 +2,13 @@
 
 set -eu
 
+extension() {
+	if [ "$1" = "windows" ]
+	then
+		echo ".exe"
+	fi
+}
+
 build() {
 	VERSION=$(git describe --tags)
 	BUILD=$(date +%FT%T%z)
@@ -22,7 +29,7 @@ build() {
 			echo "building $os.$arch"
 			if go tool dist list | grep -q "^${os}/${arch}$"
 			then
-				GOOS="$os" GOARCH="$arch" go build -ldflags "-w -s -X main.version=${VERSION} -X main.build=${BUILD}" -o "$BUILD_DIR/$FILE_NAME.$os.$arch"
+				GOOS="$os" GOARCH="$arch" go build -ldflags "-w -s -X main.version=${VERSION} -X main.build=${BUILD}" -o "$BUILD_DIR/$FILE_NAME.$os.$arch$(extension "$os")"
 			fi
 		done
 	done
@@ -34,23 +41,42 @@ remove() {
 	do
 		for arch in $ARCHLIST
 		do
-			if [ -f "$BUILD_DIR/$FILE_NAME.$os.$arch" ]
+			if [ -f "$BUILD_DIR/$FILE_NAME.$os.$arch$(extension "$os")" ]
 			then
 				echo "removing $os.$arch"
-				rm "$BUILD_DIR/$FILE_NAME.$os.$arch"
+				rm "$BUILD_DIR/$FILE_NAME.$os.$arch$(extension "$os")"
 			fi
 		done
 	done
 }
 
+# bench Run the benchmarks, save the results to benchmarks/<version>.txt
+# and compare them with the results of an earlier run when given
+bench() {
+	mkdir -p benchmarks
+	OUT="benchmarks/$(git describe --tags --always --dirty).txt"
+	go test -run '^$' -bench . -benchmem -count 6 ./... | tee "$OUT"
+
+	if [ $# -gt 0 ]
+	then
+		if command -v benchstat > /dev/null
+		then
+			benchstat "$1" "$OUT"
+		else
+			echo "benchstat not found, install it with go install golang.org/x/perf/cmd/benchstat@latest" >&2
+			exit 1
+		fi
+	fi
+}
+
 REMOVE=0
-OSLIST="linux darwin"
+OSLIST="linux darwin windows"
 ARCHLIST="amd64 arm64 arm"
 BUILD_DIR="./build"
 BUILD=0
 
 if [ $# -lt 1 ];then
-	echo "Command required, available commands are \"test\" and \"build\"" >&2
+	echo "Command required, available commands are \"test\", \"bench\" and \"build\"" >&2
 	exit 1
 fi
 
@@ -61,11 +87,15 @@ case "$subcommand" in
 		go test -v -tags test
 		return
 		;;
+	"bench")
+		bench "$@"
+		exit
+		;;
 	"build")
 		BUILD=1;
 		;;
 	*)
-		echo "Invalid command, available commands are \"test\" and \"build\"" >&2
+		echo "Invalid command, available commands are \"test\", \"bench\" and \"build\"" >&2
 		exit 1
 		;;
 esac
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+)
+
+// colorSection The section of the config file holding the color rules.
+const colorSection = "color."
+
+// styleNames Parameters of the style names, the colors can be prefixed
+// with on- for the background.
+var styleNames = map[string]int{
+	"bold":      1,
+	"dim":       2,
+	"italic":    3,
+	"underline": 4,
+	"reverse":   7,
+	"black":     30,
+	"red":       31,
+	"green":     32,
+	"yellow":    33,
+	"blue":      34,
+	"magenta":   35,
+	"cyan":      36,
+	"white":     37,
+	"gray":      90,
+	"grey":      90,
+}
+
+// parseStyle Parse a space separated list of style names, like
+// "red bold" or "white on-red".
+func parseStyle(text string) (style, error) {
+	var s style
+	for _, name := range strings.Fields(strings.ToLower(text)) {
+		background := false
+		if rest, ok := strings.CutPrefix(name, "on-"); ok {
+			name, background = rest, true
+		}
+
+		p, ok := styleNames[name]
+		if !ok || (background && p < 30) {
+			return nil, fmt.Errorf("unknown style %q", name)
+		}
+		if background {
+			p += 10
+		}
+		s = append(s, p)
+	}
+
+	if len(s) == 0 {
+		return nil, fmt.Errorf("missing style")
+	}
+	if len(s) > maxStyleParams {
+		return nil, fmt.Errorf("at most %d styles can be combined", maxStyleParams)
+	}
+
+	return s, nil
+}
+
+// colorRule Styles the lines of the events matching it, either by the
+// value of a field or by a regular expression matching the line.
+type colorRule struct {
+	name  string
+	field string
+	value string
+	re    *regexp.Regexp
+	style style
+}
+
+// parseColorRule Parse a rule like "match: json.level=error -> red bold"
+// or "regex: timeout -> yellow".
+func parseColorRule(name, spec string) (colorRule, error) {
+	rule := colorRule{name: name}
+
+	condition, styleText, ok := strings.Cut(spec, "->")
+	if !ok {
+		return rule, fmt.Errorf("missing -> before the style")
+	}
+
+	s, err := parseStyle(styleText)
+	if err != nil {
+		return rule, err
+	}
+	rule.style = s
+
+	kind, arg, _ := strings.Cut(condition, ":")
+	switch strings.TrimSpace(kind) {
+	case "match":
+		field, value, ok := strings.Cut(arg, "=")
+		if !ok || strings.TrimSpace(field) == "" {
+			return rule, fmt.Errorf("match needs a field=value condition")
+		}
+		rule.field, rule.value = strings.TrimSpace(field), strings.TrimSpace(value)
+	case "regex":
+		re, err := regexp.Compile(strings.TrimSpace(arg))
+		if err != nil {
+			return rule, err
+		}
+		rule.re = re
+	default:
+		return rule, fmt.Errorf("the condition must start with match: or regex:")
+	}
+
+	return rule, nil
+}
+
+// parseColorRules The color rules of the config file, in the order of
+// their names:
+//
+//	[color]
+//	1-errors = match: json.level=error -> red bold
+//	2-timeouts = regex: timeout -> yellow
+func parseColorRules(file configfile.File) ([]colorRule, error) {
+	var rules []colorRule
+	for _, key := range slices.Sorted(maps.Keys(file)) {
+		name, ok := strings.CutPrefix(key, colorSection)
+		if !ok {
+			continue
+		}
+
+		rule, err := parseColorRule(name, file[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid color rule %s in the config file: %w", name, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// matches Whether the rule applies to the event printed as the line.
+func (r colorRule) matches(event any, line string) bool {
+	if r.re != nil {
+		return r.re.MatchString(line)
+	}
+
+	v, ok := lookupField(event, r.field)
+	return ok && v != nil && formatValue(v) == r.value
+}
+
+// lineStyler Picks the style of the printed lines, the style of the
+// first matching color rule or the color of the level of the event, and
+// highlights the matches of -highlight and -highlight-regex.
+type lineStyler struct {
+	rules       []colorRule
+	levelFields []string
+	highlights  *regexp.Regexp
+}
+
+func newLineStyler(config Config, query string) lineStyler {
+	return lineStyler{
+		rules:       config.ColorRules,
+		levelFields: splitList(config.LevelField),
+		highlights:  newHighlights(config, query),
+	}
+}
+
+func (s lineStyler) style(event any, line string) style {
+	for _, rule := range s.rules {
+		if rule.matches(event, line) {
+			return rule.style
+		}
+	}
+
+	return levelStyle(event, s.levelFields)
+}
+
+// highlight The styled line with the matches highlighted.
+func (s lineStyler) highlight(line string) string {
+	return highlightLine(line, s.highlights)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Ajnasz/go-loggly-cli/fieldcache"
+)
+
+// completeCommand The hidden command printing the completions for the
+// shell scripts of loggly completion.
+const completeCommand = "__complete"
+
+// fieldListFlags The flags taking comma separated fields.
+var fieldListFlags = map[string]bool{
+	"fields":          true,
+	"field":           true,
+	"exclude-fields":  true,
+	"columns":         true,
+	"by":              true,
+	"pivot":           true,
+	"sort":            true,
+	"dedup-by":        true,
+	"value-histogram": true,
+	"level-field":     true,
+	"message-field":   true,
+	"merge-fields":    true,
+}
+
+// completionScripts The completion scripts of the shells. They pass the
+// line up to the cursor to loggly __complete. Bash splits the words at
+// the colons too, so the field of a field:value word is trimmed from
+// the completions.
+var completionScripts = map[string]string{
+	"bash": `_loggly() {
+	local line=${COMP_LINE:0:COMP_POINT}
+	local IFS=$'\n'
+	COMPREPLY=($(command loggly __complete "$line" 2>/dev/null))
+	local word=${line##*[[:space:]]}
+	if [[ $word == *:* && $COMP_WORDBREAKS == *:* ]]; then
+		local colon=${word%"${word##*:}"}
+		COMPREPLY=("${COMPREPLY[@]#"$colon"}")
+	fi
+}
+complete -o nospace -o default -F _loggly loggly
+`,
+	"fish": `complete -c loggly -f -a '(command loggly __complete (commandline -cp) 2>/dev/null | string trim -r)'
+`,
+}
+
+func init() {
+	completionScripts["zsh"] = "autoload -U +X bashcompinit && bashcompinit\n" + completionScripts["bash"]
+	// registered here, the completion of the commands reads the map
+	commands[completeCommand] = runComplete
+}
+
+// completeTerm The completions of a query term: the fields starting
+// with it, or the values of its field after the colon. The prefixes
+// like - and ( are kept.
+func completeTerm(word string, cache *fieldcache.Cache) []string {
+	trimmed := strings.TrimLeft(word, "+-(!")
+	prefix := word[:len(word)-len(trimmed)]
+
+	if field, value, ok := strings.Cut(trimmed, ":"); ok {
+		value = strings.TrimPrefix(value, `"`)
+		var ret []string
+		for _, v := range cache.Values(field, value) {
+			if strings.ContainsFunc(v, unicode.IsSpace) || strings.ContainsAny(v, `:()"`) {
+				v = `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+			}
+			ret = append(ret, prefix+field+":"+v+" ")
+		}
+		return ret
+	}
+
+	if trimmed == "" {
+		return nil
+	}
+
+	var ret []string
+	for _, name := range cache.Names(trimmed) {
+		ret = append(ret, prefix+name+":")
+	}
+	return ret
+}
+
+// completeFieldList The completions of the last field of a comma
+// separated list.
+func completeFieldList(word string, cache *fieldcache.Cache) []string {
+	done, last := "", word
+	if i := strings.LastIndex(word, ","); i >= 0 {
+		done, last = word[:i+1], word[i+1:]
+	}
+
+	var ret []string
+	for _, name := range cache.Names(last) {
+		ret = append(ret, done+name)
+	}
+	return ret
+}
+
+// completionAccount The account given in the words, empty when none is.
+func completionAccount(words []string) string {
+	for i, w := range words {
+		if !strings.HasPrefix(w, "-") {
+			continue
+		}
+		w = strings.TrimLeft(w, "-")
+		if v, ok := strings.CutPrefix(w, "account="); ok {
+			return v
+		}
+		if w == "account" && i+1 < len(words) {
+			return words[i+1]
+		}
+	}
+	return ""
+}
+
+// completeWords The completions of the last of the words typed after
+// loggly: the commands, the flags, the fields of the field list flags
+// and the fields and values of the query.
+func completeWords(words []string, cache *fieldcache.Cache) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	cur := words[len(words)-1]
+
+	flags := newFlagSet("loggly", &Config{})
+	if strings.HasPrefix(cur, "-") && !strings.Contains(cur, ":") {
+		var ret []string
+		flags.VisitAll(func(f *flag.Flag) {
+			if name := "-" + f.Name; strings.HasPrefix(name, cur) {
+				ret = append(ret, name+" ")
+			}
+		})
+		return ret
+	}
+
+	if len(words) > 1 {
+		prev := strings.TrimLeft(words[len(words)-2], "-")
+		if fieldListFlags[prev] {
+			return completeFieldList(cur, cache)
+		}
+		// the values of the other flags are not completed
+		if f := flags.Lookup(prev); f != nil && strings.HasPrefix(words[len(words)-2], "-") {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				return nil
+			}
+		}
+	}
+
+	var ret []string
+	if len(words) == 1 {
+		for _, name := range slices.Sorted(maps.Keys(commands)) {
+			if strings.HasPrefix(name, cur) && name != completeCommand {
+				ret = append(ret, name+" ")
+			}
+		}
+	}
+	return append(ret, completeTerm(cur, cache)...)
+}
+
+// runComplete Print the completions of the command line up to the
+// cursor, one per line.
+func runComplete(args []string) {
+	line := strings.Join(args, " ")
+	words := strings.Fields(line)
+	if len(words) > 0 {
+		// the program name
+		words = words[1:]
+	}
+	if line == "" || unicode.IsSpace(rune(line[len(line)-1])) {
+		words = append(words, "")
+	}
+
+	for _, c := range completeWords(words, loadFieldCache(completionAccount(words))) {
+		fmt.Println(c)
+	}
+}
+
+// runCompletion Print the completion script of the shell.
+func runCompletion(args []string) {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		check(usageError(fmt.Errorf("usage: loggly completion bash|zsh|fish")))
+	}
+
+	fmt.Print(completionScripts[args[0]])
+}
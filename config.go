@@ -0,0 +1,649 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Ajnasz/go-loggly-cli/budget"
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+	"github.com/Ajnasz/go-loggly-cli/expr"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/outfile"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+type Config struct {
+	Account   string
+	Token     string
+	TokenFile string
+	// CredentialHelper Programs printing the token of each account, from
+	// the config file.
+	CredentialHelper []string
+	// Pager The program paging the events printed to a terminal.
+	Pager string
+	// AuditLog The file recording the executed queries, from the config
+	// file, empty when auditing is off.
+	AuditLog string
+	// HistoryFile The history of the executed queries, empty when it is
+	// off.
+	HistoryFile  string
+	Size         int
+	From         string
+	To           string
+	AllMsg       bool
+	MaxPages     int64
+	MaxEvents    int64
+	SampleOutput int
+	Concurrency  int
+	Debug        bool
+	Verbose      bool
+	Quiet        bool
+	ErrorFormat  string
+	Output       string
+	Tee          bool
+	RotateSize   string
+	// Accessible The high contrast, still and simplified terminal UI.
+	Accessible   bool
+	Timeout      time.Duration
+	PageTimeout  time.Duration
+	Proxy        string
+	Endpoint     string
+	PrintCurl    bool
+	Strict       bool
+	CurlTokenEnv bool
+	Where        string
+	Grep         string
+	GrepV        string
+	JQ           string
+	FailEmpty    bool
+	Format       string
+	// FileFormat The format of the -o file with -tee, when the Format is
+	// for the terminal.
+	FileFormat       string
+	Columns          string
+	Fields           string
+	ExcludeFields    string
+	Flatten          bool
+	Merge            bool
+	RawText          bool
+	MessageField     string
+	MergeFields      string
+	FlattenSeparator string
+	Template         string
+	Index            string
+	TimeZone         string
+	UTC              bool
+	TimeFormat       string
+	LevelField       string
+	// ColorRules The rules of the color section of the config file.
+	ColorRules []colorRule
+	Highlight  bool
+	PageSize   int
+	NoPager    bool
+	// NoFieldCache Do not record the fields of the events for the
+	// completions.
+	NoFieldCache   bool
+	Stats          bool
+	HighlightRegex string
+	Pretty         bool
+	Color          string
+	Query          string
+	QueryFile      string
+	Input          string
+	SharedBudget   string
+	BudgetRate     int
+	// Auth The authentication scheme of the requests, AuthHeader the
+	// header of the header scheme.
+	Auth       string
+	AuthHeader string
+	// Daemon The socket of the daemon sending the requests, empty sends
+	// them directly.
+	Daemon string
+
+	ValueHistogram string
+	Pivot          string
+	Sort           string
+	Desc           bool
+	Dedup          bool
+	DedupBy        string
+	Buckets        int
+
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+
+	// raw Fetch the events undecoded, see rawPassthrough.
+	raw bool
+	// stats Collects the summary of -stats, nil without it.
+	stats *runStats
+}
+
+// loadConfigFile Read the configuration file, $LOGGLY_CONFIG or the
+// config file in the platform config directory.
+func loadConfigFile() (configfile.File, error) {
+	path := os.Getenv("LOGGLY_CONFIG")
+	if path == "" {
+		var err error
+		if path, err = platform.ConfigFile("config"); err != nil {
+			// no home directory, no configuration
+			return configfile.File{}, nil
+		}
+	}
+
+	return configfile.Load(path)
+}
+
+// warnf Print a warning to the standard error, unless -quiet is set.
+func (c Config) warnf(format string, args ...any) {
+	if c.Quiet {
+		return
+	}
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Warning: %s", locale.Sprintf(format, args...)))
+}
+
+// logger Logger writing to the standard error at the level selected
+// by -verbose or -debug, or nil when logging is off.
+func (c Config) logger() *slog.Logger {
+	var level slog.Level
+	switch {
+	case c.Debug:
+		level = slog.LevelDebug
+	case c.Verbose:
+		level = slog.LevelInfo
+	default:
+		return nil
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// resolveToken Read the token from the token file, from the standard
+// input when the token is "-", or from the credential helper when no
+// token is given, and check its format.
+func (c *Config) resolveToken(stdin io.Reader) error {
+	if c.TokenFile != "" && c.Token != "" {
+		return fmt.Errorf("token and token-file are mutually exclusive")
+	}
+
+	switch {
+	case c.TokenFile != "":
+		data, err := os.ReadFile(c.TokenFile)
+		if err != nil {
+			return fmt.Errorf("reading token file: %w", err)
+		}
+		c.Token = string(data)
+	case c.Token == "-":
+		data, err := io.ReadAll(io.LimitReader(stdin, 64*1024))
+		if err != nil {
+			return fmt.Errorf("reading token from stdin: %w", err)
+		}
+		c.Token = string(data)
+	case c.Token == "" && c.CredentialHelper != nil:
+		token, err := credentialHelperTokens(c.CredentialHelper, c.Account)
+		if err != nil {
+			return err
+		}
+		c.Token = token
+	default:
+		return nil
+	}
+
+	c.Token = strings.TrimSpace(c.Token)
+	if c.Token == "" {
+		return fmt.Errorf("token is empty")
+	}
+	if strings.ContainsFunc(c.Token, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}) {
+		return fmt.Errorf("token contains whitespace or control characters")
+	}
+
+	return nil
+}
+
+func (c Config) newClient() (*search.Client, error) {
+	client := search.New(c.Account, c.Token).
+		SetConcurrency(c.Concurrency).
+		SetPageTimeout(c.PageTimeout).
+		SetWarningHandler(func(warning string) {
+			c.warnf("%s", warning)
+		}).
+		SetLogger(c.logger())
+
+	if c.Daemon != "" {
+		// the daemon sends the requests with its own endpoint, proxy
+		// and TLS settings
+		client.SetEndpoint(daemonURL + "/" + url.PathEscape(c.Account)).
+			SetDialContext(dialDaemon(c.Daemon))
+	} else if c.Endpoint != "" {
+		client.SetEndpoint(c.Endpoint)
+	}
+
+	proxy, err := parseProxy(c.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil && c.Daemon == "" {
+		client.SetProxy(proxy)
+	}
+
+	if c.stats != nil {
+		client.SetObserver(c.stats.observer(c.Account))
+	}
+
+	if c.SharedBudget != "" {
+		client.SetLimiter(budget.New(c.SharedBudget, c.BudgetRate, time.Minute))
+	}
+
+	auth, err := c.authenticator()
+	if err != nil {
+		return nil, err
+	}
+	client.SetAuthenticator(auth)
+
+	if c.PrintCurl {
+		client.SetRequestHook(func(r *http.Request) {
+			fmt.Fprintln(os.Stderr, curlCommand(r, c.CurlTokenEnv, c.AuthHeader))
+		})
+	}
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		client.SetTLSConfig(tlsConfig)
+	}
+
+	return client, nil
+}
+
+// Values of -auth.
+const (
+	authBearer = "bearer"
+	authBasic  = "basic"
+	authHeader = "header"
+)
+
+var authSchemes = []string{authBearer, authBasic, authHeader}
+
+// authenticator The authentication of the requests selected by -auth:
+// the token as a bearer token, as the user:password of basic
+// authentication, or as the value of the -auth-header of a gateway
+// fronting Loggly.
+func (c Config) authenticator() (search.Authenticator, error) {
+	switch c.Auth {
+	case authBasic:
+		user, password, ok := strings.Cut(c.Token, ":")
+		if !ok {
+			return nil, fmt.Errorf("auth basic needs the token as user:password")
+		}
+		return search.BasicAuth{Username: user, Password: password}, nil
+	case authHeader:
+		return search.Headers{c.AuthHeader: {c.Token}}, nil
+	default:
+		return search.BearerToken(c.Token), nil
+	}
+}
+
+// validHeaderName Whether the name can be the name of an HTTP header.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// tlsConfig Build the TLS settings from the options, or return nil
+// when the defaults should be used.
+func (c Config) tlsConfig() (*tls.Config, error) {
+	if c.CACert == "" && c.ClientCert == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CACert != "" {
+		pem, err := os.ReadFile(c.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificate: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", c.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// validateEndpoint Check the -endpoint, a host and path like
+// eu.loggly.com/apiv2 or a full http(s) URL.
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid endpoint %q: scheme must be http or https", endpoint)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("invalid endpoint %q: missing host", endpoint)
+	}
+
+	return nil
+}
+
+func parseProxy(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", proxy)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", proxy)
+	}
+
+	return u, nil
+}
+
+func (c Config) newQuery(query string) *search.Query {
+	return search.NewQuery(query).
+		Size(c.Size).
+		From(c.From).
+		To(c.To).
+		MaxPage(c.MaxPages).
+		MaxEvents(c.MaxEvents).
+		Raw(c.raw)
+}
+
+// rawPassthrough Whether the events can be printed as the API returned
+// them, without decoding and encoding them again. Only the whole events
+// printed as NDJSON qualify, anything filtering, tagging or reformatting
+// them needs the decoded events.
+func (c Config) rawPassthrough() bool {
+	return c.AllMsg &&
+		slices.Contains(rawFormats, c.Format) &&
+		c.Where == "" &&
+		c.Grep == "" &&
+		c.GrepV == "" &&
+		c.JQ == "" &&
+		c.Fields == "" &&
+		c.ExcludeFields == "" &&
+		!c.Flatten &&
+		c.TimeZone == "" &&
+		!c.UTC &&
+		c.TimeFormat == "" &&
+		c.ValueHistogram == "" &&
+		c.Pivot == "" &&
+		c.Sort == "" &&
+		!c.Dedup &&
+		c.DedupBy == "" &&
+		c.SampleOutput == 0 &&
+		!c.Pretty &&
+		!useColor(c.Color, os.Stdout) &&
+		len(splitList(c.Account)) <= 1
+}
+
+// normalizeTimeRange Convert the -from and -to values to the forms the
+// API understands.
+func (c *Config) normalizeTimeRange() error {
+	now := time.Now()
+	from, err := normalizeTime("from", c.From, now)
+	if err != nil {
+		return err
+	}
+
+	to, err := normalizeTime("until", c.To, now)
+	if err != nil {
+		return err
+	}
+
+	c.From, c.To = from, to
+	return nil
+}
+
+func (c Config) Validate() error {
+	// local inputs need no credentials
+	if c.Account == "" && c.Input == "" {
+		return fmt.Errorf("account is required")
+	}
+	if c.Token == "" && c.Input == "" {
+		return fmt.Errorf("token is required")
+	}
+	if c.Input != "" {
+		if _, err := archiveName(c.Input); err != nil {
+			return err
+		}
+	}
+	if _, err := c.accountConfigs(); err != nil {
+		return err
+	}
+	if c.MaxPages <= 0 {
+		return fmt.Errorf("maxPages must be greater than 0")
+	}
+	if c.MaxEvents < 0 {
+		return fmt.Errorf("max-events must not be negative")
+	}
+	if c.PageSize < 0 {
+		return fmt.Errorf("page-size must not be negative")
+	}
+	if c.PageSize > 0 && (c.SampleOutput > 0 || c.ValueHistogram != "" || c.Pivot != "" || c.Sort != "") {
+		return fmt.Errorf("page-size can not be combined with -sample-output, -value-histogram, -pivot or -sort")
+	}
+	if c.Dedup && c.DedupBy != "" {
+		return fmt.Errorf("use either -dedup or -dedup-by, not both")
+	}
+	if c.Desc && c.Sort == "" {
+		return fmt.Errorf("desc requires -sort")
+	}
+	if c.SampleOutput < 0 {
+		return fmt.Errorf("sample-output must not be negative")
+	}
+	if err := validateTimeRange(c.From, c.To, time.Now()); err != nil {
+		return err
+	}
+	if c.Timeout < 0 || c.PageTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if _, err := parseProxy(c.Proxy); err != nil {
+		return err
+	}
+	if err := validateEndpoint(c.Endpoint); err != nil {
+		return err
+	}
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return fmt.Errorf("client-cert and client-key must be set together")
+	}
+	if c.Quiet && (c.Verbose || c.Debug) {
+		return fmt.Errorf("quiet cannot be combined with verbose or debug")
+	}
+	if c.Tee && c.Output == "" {
+		return fmt.Errorf("tee requires an output file set with -o")
+	}
+	if c.RotateSize != "" {
+		if c.Output == "" {
+			return fmt.Errorf("rotate-size requires an output file set with -o")
+		}
+		if (c.Format == formatJSONArray && !c.Tee) || c.FileFormat == formatJSONArray {
+			return fmt.Errorf("rotate-size splits the output between the lines, it can not split a json-array")
+		}
+		if _, err := outfile.ParseSize(c.RotateSize); err != nil {
+			return err
+		}
+	}
+	if c.Format != "" {
+		if err := validateFormat(c.Format); err != nil {
+			return err
+		}
+	}
+	if c.FileFormat != "" {
+		if err := validateFormat(c.FileFormat); err != nil {
+			return fmt.Errorf("format.pipe of the config file: %w", err)
+		}
+		if slices.Contains(teeFileFormats, c.FileFormat) {
+			return fmt.Errorf("the -o file of -tee can not be written in %s, set another format.pipe in the config file", c.FileFormat)
+		}
+	}
+	if c.SharedBudget != "" && c.BudgetRate <= 0 {
+		return fmt.Errorf("shared-budget-rate must be greater than 0")
+	}
+	if !slices.Contains(authSchemes, c.Auth) {
+		return fmt.Errorf("invalid auth %q, use one of %s", c.Auth, strings.Join(authSchemes, ", "))
+	}
+	if c.Auth == authHeader && !validHeaderName(c.AuthHeader) {
+		return fmt.Errorf("auth header requires the name of the header with -auth-header")
+	}
+	if c.AuthHeader != "" && c.Auth != authHeader {
+		return fmt.Errorf("auth-header requires -auth header")
+	}
+	if !slices.Contains(colorModes, c.Color) {
+		return fmt.Errorf("invalid color %q, use one of %s", c.Color, strings.Join(colorModes, ", "))
+	}
+	if c.HighlightRegex != "" {
+		if _, err := regexp.Compile(c.HighlightRegex); err != nil {
+			return fmt.Errorf("invalid highlight-regex: %w", err)
+		}
+	}
+	if c.Pretty && c.Format != formatNDJSON {
+		return fmt.Errorf("pretty requires -format ndjson")
+	}
+	if c.Columns != "" && !slices.Contains(columnFormats, c.Format) {
+		return fmt.Errorf("columns requires -format csv, tsv, logfmt or parquet")
+	}
+	if c.Merge && c.AllMsg {
+		return fmt.Errorf("merge can not be combined with -all, which prints the whole envelope")
+	}
+	if c.Merge {
+		for _, field := range splitList(c.MergeFields) {
+			if !slices.Contains(envelopeFieldNames, field) {
+				return fmt.Errorf("invalid merge field %q, use %s", field, strings.Join(envelopeFieldNames, ", "))
+			}
+		}
+	}
+	if len(splitList(c.MessageField)) == 0 {
+		return fmt.Errorf("message-field can not be empty")
+	}
+	if c.Flatten && c.FlattenSeparator == "" {
+		return fmt.Errorf("flatten-separator can not be empty")
+	}
+	if c.UTC && c.TimeZone != "" {
+		return fmt.Errorf("utc and tz can not be combined")
+	}
+	if _, err := c.timeLocation(); err != nil {
+		return err
+	}
+	if c.TimeFormat != "" {
+		if err := validateTimeFormat(c.TimeFormat); err != nil {
+			return err
+		}
+	}
+	if c.Index != "" && c.Format != formatESBulk {
+		return fmt.Errorf("index requires -format es-bulk")
+	}
+	if c.Format == formatESBulk {
+		if err := validateIndexName(c.Index); err != nil {
+			return err
+		}
+	}
+	if c.Template != "" && c.Format != formatTemplate {
+		return fmt.Errorf("template requires -format template")
+	}
+	if c.Format == formatTemplate {
+		if c.Template == "" {
+			return fmt.Errorf("format template requires -template")
+		}
+		if _, err := newTemplate(c.Template); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+	if c.Pivot != "" && len(splitList(c.Pivot)) != 2 {
+		return fmt.Errorf("pivot needs two comma separated fields, the rows and the columns")
+	}
+	if c.Pivot != "" && c.ValueHistogram != "" {
+		return fmt.Errorf("pivot can not be combined with -value-histogram")
+	}
+	if c.Format == formatJSONArray && (c.ValueHistogram != "" || c.Pivot != "" || c.PageSize > 0) {
+		return fmt.Errorf("json-array prints the events as a whole array, it can not be combined with -value-histogram, -pivot or -page-size")
+	}
+	if c.Format == formatParquet {
+		if c.Output == "" || c.Tee {
+			return fmt.Errorf("parquet is a binary format, write it to a file with -o, without -tee")
+		}
+		if c.RotateSize != "" || c.ValueHistogram != "" || c.Pivot != "" || c.PageSize > 0 {
+			return fmt.Errorf("parquet writes the events into a single file, it can not be combined with -rotate-size, -value-histogram, -pivot or -page-size")
+		}
+	}
+	if c.ValueHistogram != "" && c.Buckets <= 0 {
+		return fmt.Errorf("buckets must be greater than 0")
+	}
+	for name, re := range map[string]string{"grep": c.Grep, "grep-v": c.GrepV} {
+		if _, err := regexp.Compile(re); err != nil {
+			return fmt.Errorf("invalid %s expression: %w", name, err)
+		}
+	}
+	if c.Where != "" {
+		if _, err := expr.Parse(c.Where); err != nil {
+			return fmt.Errorf("invalid where expression: %w", err)
+		}
+	}
+	if c.JQ != "" {
+		if _, err := compileJQ(c.JQ); err != nil {
+			return fmt.Errorf("invalid jq expression: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package configfile
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `
+# defaults
+format.pipe = ndjson
+
+[format]
+tty = "table"
+; comment
+[search]
+query = 'json.level:error AND "a b"'
+`
+
+	file, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := File{
+		"format.pipe":  "ndjson",
+		"format.tty":   "table",
+		"search.query": `json.level:error AND "a b"`,
+	}
+
+	if len(file) != len(want) {
+		t.Fatalf("expected %v, got %v", want, file)
+	}
+	for k, v := range want {
+		if file[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, file[k])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{"novalue", "[open", " = x", `k = "\q"`} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	file, err := Load(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(file) != 0 {
+		t.Errorf("expected an empty configuration, got %v, %v", file, err)
+	}
+}
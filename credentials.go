@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+)
+
+// credentialHelper The programs printing the tokens of the comma
+// separated accounts, the credential_helper.<account> or else the
+// credential_helper of the config file, one per account. Nil when no
+// account has a helper.
+func credentialHelper(file configfile.File, account string) []string {
+	accounts := splitList(account)
+	if len(accounts) == 0 {
+		accounts = []string{account}
+	}
+
+	var found bool
+	helpers := make([]string, len(accounts))
+	for i, account := range accounts {
+		helpers[i] = file["credential_helper"]
+		if helper := file["credential_helper."+account]; helper != "" {
+			helpers[i] = helper
+		}
+		found = found || helpers[i] != ""
+	}
+	if !found {
+		return nil
+	}
+	return helpers
+}
+
+// credentialHelperTokens Run the helper of each account, in the order of
+// the comma separated accounts, and return their tokens comma separated.
+func credentialHelperTokens(helpers []string, account string) (string, error) {
+	accounts := splitList(account)
+	if len(accounts) == 0 {
+		accounts = []string{account}
+	}
+
+	tokens := make([]string, len(accounts))
+	for i, account := range accounts {
+		if helpers[i] == "" {
+			return "", fmt.Errorf("no credential helper for the account %s", account)
+		}
+		token, err := runCredentialHelper(helpers[i], account)
+		if err != nil {
+			return "", err
+		}
+		if tokens[i] = strings.TrimSpace(token); tokens[i] == "" {
+			return "", fmt.Errorf("the credential helper of the account %s printed no token", account)
+		}
+	}
+
+	return strings.Join(tokens, ","), nil
+}
+
+// runCredentialHelper Run the helper with the get argument and the
+// account in $LOGGLY_ACCOUNT, and return the token it prints. The
+// helper may prompt on the terminal, its standard error is passed
+// through.
+func runCredentialHelper(helper, account string) (string, error) {
+	args := strings.Fields(helper)
+	if len(args) == 0 {
+		return "", fmt.Errorf("credential helper is empty")
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(args[0], append(args[1:], "get")...)
+	cmd.Env = append(os.Environ(), "LOGGLY_ACCOUNT="+account)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("credential helper %s: %w", args[0], err)
+	}
+
+	return stdout.String(), nil
+}
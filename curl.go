@@ -0,0 +1,48 @@
+package main
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// curlCommand Format the request as a copy-pasteable curl command. When
+// tokenEnv is true the token is replaced by the $LOGGLY_TOKEN variable,
+// in the Authorization header or in the authHeader of -auth header.
+func curlCommand(r *http.Request, tokenEnv bool, authHeader string) string {
+	parts := []string{"curl", "-sS"}
+
+	if r.Method != http.MethodGet {
+		parts = append(parts, "-X", r.Method)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(r.Header)) {
+		for _, value := range r.Header[name] {
+			switch {
+			case !tokenEnv:
+			case strings.EqualFold(name, "Authorization") && strings.HasPrefix(value, "Basic "):
+				// the token is the user:password of curl
+				parts = append(parts, "-u", `"$LOGGLY_TOKEN"`)
+				continue
+			case strings.EqualFold(name, "Authorization"):
+				scheme, _, _ := strings.Cut(value, " ")
+				parts = append(parts, "-H", `"`+name+": "+scheme+` $LOGGLY_TOKEN"`)
+				continue
+			case authHeader != "" && strings.EqualFold(name, authHeader):
+				parts = append(parts, "-H", `"`+name+`: $LOGGLY_TOKEN"`)
+				continue
+			}
+			parts = append(parts, "-H", shellQuote(name+": "+value))
+		}
+	}
+
+	parts = append(parts, shellQuote(r.URL.String()))
+
+	return strings.Join(parts, " ")
+}
+
+// shellQuote Quote the string for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
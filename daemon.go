@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/budget"
+	"github.com/Ajnasz/go-loggly-cli/daemon"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// daemonURL The base of the requests sent to the daemon, the host is
+// ignored as the connections go to the socket.
+const daemonURL = "http://loggly-daemon"
+
+// dialDaemon Connect to the daemon listening on the socket.
+func dialDaemon(socket string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "unix", socket)
+		if err != nil {
+			return nil, fmt.Errorf("the daemon is not running on %s, start it with loggly daemon: %w", socket, err)
+		}
+		return conn, nil
+	}
+}
+
+// listenDaemon Listen on the socket, replacing the socket left behind by
+// a daemon which is not running anymore.
+func listenDaemon(socket string) (net.Listener, error) {
+	if info, err := os.Stat(socket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and it is not a socket", socket)
+		}
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already running on %s", socket)
+		}
+		if err := os.Remove(socket); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := platform.EnsureDir(socket); err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	// the responses belong to the user running the daemon
+	if err := os.Chmod(socket, 0o600); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// printDaemonStatus Print the counters of the daemon running on the
+// socket.
+func printDaemonStatus(socket string) error {
+	client := &http.Client{Transport: &http.Transport{DialContext: dialDaemon(socket)}}
+	res, err := client.Get(daemonURL + "/status")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var stats daemon.Stats
+	if err := json.NewDecoder(res.Body).Decode(&stats); err != nil {
+		return err
+	}
+
+	fmt.Println(locale.Sprintf("%d requests, %d from the cache, %d shared, %d sent to Loggly, %d responses cached",
+		stats.Requests, stats.Hits, stats.Shared, stats.Upstream, stats.Entries))
+	return nil
+}
+
+// runDaemon Serve the Loggly API to the invocations using -daemon, or
+// print the counters of the running daemon with -status.
+func runDaemon(args []string) {
+	var config Config
+	flags := newFlagSet("loggly daemon", &config)
+	socket := flags.String("socket", "", "")
+	ttl := flags.Duration("cache-ttl", time.Minute, "")
+	cacheSize := flags.Int("cache-size", 256, "")
+	rate := flags.Int("rate", 0, "")
+	status := flags.Bool("status", false, "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+
+	if *socket == "" {
+		var err error
+		*socket, err = platform.StateFile("daemon.sock")
+		check(err)
+	}
+
+	if *status {
+		check(printDaemonStatus(*socket))
+		return
+	}
+
+	check(usageError(validateEndpoint(config.Endpoint)))
+	if *ttl < 0 || *cacheSize < 0 || *rate < 0 {
+		check(usageError(errors.New("cache-ttl, cache-size and rate can not be negative")))
+	}
+	if *rate > 0 && config.SharedBudget != "" {
+		check(usageError(errors.New("use either -rate or -shared-budget")))
+	}
+	if config.SharedBudget != "" && config.BudgetRate <= 0 {
+		check(usageError(errors.New("shared-budget-rate must be greater than 0")))
+	}
+
+	// the daemon talks to Loggly itself
+	config.Daemon = ""
+	c, err := config.newClient()
+	check(err)
+
+	var limiter daemon.Limiter
+	switch {
+	case config.SharedBudget != "":
+		limiter = budget.New(config.SharedBudget, config.BudgetRate, time.Minute)
+	case *rate > 0:
+		limiter = daemon.NewRate(*rate, time.Minute)
+	}
+
+	logger := config.logger()
+	server := daemon.New(daemon.Options{
+		Upstream: func(account string) string {
+			client := search.New(account, "")
+			if config.Endpoint != "" {
+				client.SetEndpoint(config.Endpoint)
+			}
+			return client.URL()
+		},
+		Client:     &http.Client{Transport: c.Transport()},
+		Limiter:    limiter,
+		TTL:        *ttl,
+		MaxEntries: *cacheSize,
+		Logger:     logger,
+	})
+
+	l, err := listenDaemon(*socket)
+	check(err)
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	srv := &http.Server{Handler: server}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelShutdown()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Serving the Loggly API on %s, use it with -daemon %s or LOGGLY_DAEMON", *socket, *socket))
+	if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		check(err)
+	}
+}
@@ -0,0 +1,391 @@
+// Package daemon serves the Loggly API to the local invocations of
+// loggly over a unix socket. The invocations share the connections of
+// the daemon, the responses it cached and its request rate, so repeated
+// interactive queries are answered faster and heavy users stay under the
+// rate limit together.
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter Delays the requests sent to the API.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// Options The settings of a Server.
+type Options struct {
+	// Upstream The base URL of the API of the account, like
+	// https://acme.loggly.com/apiv2.
+	Upstream func(account string) string
+	// Client Sends the requests to the API, keeping its connections
+	// open between the invocations.
+	Client *http.Client
+	// Limiter Waited for before every request sent to the API, nil
+	// sends them right away.
+	Limiter Limiter
+	// TTL How long a response is served from the cache, zero disables
+	// the cache.
+	TTL time.Duration
+	// MaxEntries The number of cached responses, the ones expiring first
+	// are evicted.
+	MaxEntries int
+	Logger     *slog.Logger
+}
+
+// Stats The counters of a Server, served at /status.
+type Stats struct {
+	Requests int64 `json:"requests"`
+	// Hits The requests answered from the cache.
+	Hits int64 `json:"hits"`
+	// Shared The requests which waited for the same request of another
+	// invocation instead of sending their own.
+	Shared int64 `json:"shared"`
+	// Upstream The requests sent to the API.
+	Upstream int64 `json:"upstream"`
+	Entries  int   `json:"entries"`
+}
+
+// response A response of the API.
+type response struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// call A request sent to the API, the same requests arriving meanwhile
+// wait for its response.
+type call struct {
+	done chan struct{}
+	res  *response
+	err  error
+}
+
+// Server Forwards the requests of the invocations to the API.
+type Server struct {
+	opts Options
+
+	mu       sync.Mutex
+	cache    map[string]*response
+	inflight map[string]*call
+	stats    Stats
+
+	// now Replaced in tests.
+	now func() time.Time
+}
+
+// New Create a server forwarding the requests to the API of
+// opts.Upstream.
+func New(opts Options) *Server {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.DiscardHandler)
+	}
+
+	return &Server{
+		opts:     opts,
+		cache:    make(map[string]*response),
+		inflight: make(map[string]*call),
+		now:      time.Now,
+	}
+}
+
+// Stats The counters of the server.
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Entries = len(s.cache)
+	return stats
+}
+
+// ServeHTTP Answer GET /<account>/<path of the API> from the cache, or
+// by forwarding it to the API of the account, and GET /status with the
+// Stats.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET requests are forwarded", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path == "/status" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Stats())
+		return
+	}
+
+	account, path, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !ok || !validAccount(account) {
+		http.Error(w, "the path must start with the account", http.StatusNotFound)
+		return
+	}
+	target := s.opts.Upstream(account) + "/" + path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	s.mu.Lock()
+	s.stats.Requests++
+	s.mu.Unlock()
+
+	// the responses are private to the credentials they were fetched
+	// with, whichever headers hold them
+	header := forwardedHeader(r.Header)
+	h := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		fmt.Fprintf(h, "%s: %q\n", name, header[name])
+	}
+	key := hex.EncodeToString(h.Sum(nil)) + " " + target
+
+	res, err := s.get(r.Context(), key, header, target, s.cacheable(r, path))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	for name, values := range res.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(res.status)
+	w.Write(res.body)
+}
+
+// validAccount Whether the account is a valid subdomain, nothing else
+// gets into the URL of the API.
+func validAccount(account string) bool {
+	if account == "" {
+		return false
+	}
+	for _, r := range account {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheable Whether the response of the request can be cached. The
+// searches of a relative time range, like the last hour, find different
+// events every time, the pages of a search do not change.
+func (s *Server) cacheable(r *http.Request, path string) bool {
+	if s.opts.TTL <= 0 || r.Header.Get("Cache-Control") == "no-cache" {
+		return false
+	}
+	if path != "search" {
+		return true
+	}
+
+	q := r.URL.Query()
+	return !isRelative(q.Get("from")) && !isRelative(q.Get("until"))
+}
+
+// isRelative Whether the time of a search is relative to the time it is
+// executed at, the default of a missing one included.
+func isRelative(t string) bool {
+	return t == "" || t == "now" || strings.HasPrefix(t, "-") || strings.HasPrefix(t, "+")
+}
+
+// hopHeaders The headers of the connection to the daemon, not sent to
+// the API. The compression is negotiated by the client of the daemon.
+var hopHeaders = []string{
+	"Accept-Encoding",
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// forwardedHeader The headers of the request sent to the API, the
+// credentials among them.
+func forwardedHeader(header http.Header) http.Header {
+	h := header.Clone()
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+	return h
+}
+
+// get The response of the request, from the cache if cacheable, or
+// shared with the same request already sent to the API.
+func (s *Server) get(ctx context.Context, key string, header http.Header, target string, cacheable bool) (*response, error) {
+	for {
+		s.mu.Lock()
+		if res, ok := s.cache[key]; ok && cacheable && s.now().Before(res.expires) {
+			s.stats.Hits++
+			s.mu.Unlock()
+			s.opts.Logger.Debug("cache hit", "url", target)
+			return res, nil
+		}
+
+		if c, ok := s.inflight[key]; ok {
+			s.stats.Shared++
+			s.mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-c.done:
+			}
+			// the invocation which sent the request went away, send it again
+			if errors.Is(c.err, context.Canceled) && ctx.Err() == nil {
+				continue
+			}
+			return c.res, c.err
+		}
+
+		c := &call{done: make(chan struct{})}
+		s.inflight[key] = c
+		s.stats.Upstream++
+		s.mu.Unlock()
+
+		c.res, c.err = s.fetch(ctx, header, target)
+
+		s.mu.Lock()
+		delete(s.inflight, key)
+		if c.err == nil && cacheable && c.res.status == http.StatusOK {
+			s.store(key, c.res)
+		}
+		s.mu.Unlock()
+		close(c.done)
+
+		return c.res, c.err
+	}
+}
+
+// fetch Send the request to the API.
+func (s *Server) fetch(ctx context.Context, header http.Header, target string) (*response, error) {
+	if s.opts.Limiter != nil {
+		if err := s.opts.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+
+	start := time.Now()
+	res, err := s.opts.Client.Do(req)
+	if err != nil {
+		s.opts.Logger.Info("request failed", "url", redact(target), "error", err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	s.opts.Logger.Info("request done", "url", redact(target), "status", res.StatusCode, "duration", time.Since(start))
+
+	h := make(http.Header)
+	for _, name := range []string{"Content-Type", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"} {
+		if v := res.Header.Values(name); len(v) > 0 {
+			h[name] = v
+		}
+	}
+
+	return &response{status: res.StatusCode, header: h, body: body}, nil
+}
+
+// store Cache the response, evicting the expired ones, and the ones
+// expiring first when the cache is full.
+func (s *Server) store(key string, res *response) {
+	now := s.now()
+	res.expires = now.Add(s.opts.TTL)
+
+	for k, r := range s.cache {
+		if !now.Before(r.expires) {
+			delete(s.cache, k)
+		}
+	}
+	for s.opts.MaxEntries > 0 && len(s.cache) >= s.opts.MaxEntries {
+		var oldest string
+		for k, r := range s.cache {
+			if oldest == "" || r.expires.Before(s.cache[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(s.cache, oldest)
+	}
+
+	s.cache[key] = res
+}
+
+// redact The URL without its query, which may hold sensitive search
+// terms.
+func redact(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	u.RawQuery = ""
+	return u.String()
+}
+
+// Rate A Limiter allowing n requests per period, evenly spaced.
+type Rate struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRate Allow n requests per period.
+func NewRate(n int, period time.Duration) *Rate {
+	return &Rate{interval: period / time.Duration(max(n, 1))}
+}
+
+// Wait Block until the next request is allowed.
+func (r *Rate) Wait(ctx context.Context) error {
+	r.mu.Lock()
+	now := time.Now()
+	at := r.next
+	if at.Before(now) {
+		at = now
+	}
+	r.next = at.Add(r.interval)
+	r.mu.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
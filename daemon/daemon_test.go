@@ -0,0 +1,198 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestServer A daemon in front of an API answering with the number
+// of requests it got, blocked until release is closed if it is not nil.
+func newTestServer(t *testing.T, ttl time.Duration, release chan struct{}) (*httptest.Server, *Server, *atomic.Int64) {
+	var calls atomic.Int64
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if release != nil {
+			<-release
+		}
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "failed", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "%s%s %d", r.Header.Get("Authorization"), r.Header.Get("X-Gateway-Token"), n)
+	}))
+	t.Cleanup(api.Close)
+
+	s := New(Options{
+		Upstream: func(account string) string { return api.URL + "/" + account },
+		TTL:      ttl,
+	})
+	d := httptest.NewServer(s)
+	t.Cleanup(d.Close)
+
+	return d, s, &calls
+}
+
+func get(t *testing.T, url, token string) (int, string) {
+	t.Helper()
+	return getWith(t, url, "Authorization", "Bearer "+token)
+}
+
+// getWith Get the URL with the credentials in the header.
+func getWith(t *testing.T, url, header, value string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(header, value)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, string(body)
+}
+
+func TestCache(t *testing.T) {
+	d, s, calls := newTestServer(t, time.Minute, nil)
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+
+	events := d.URL + "/acme/events?rsid=1&page=0"
+	if _, body := get(t, events, "a"); body != "Bearer a 1" {
+		t.Fatalf("unexpected response %q", body)
+	}
+	if _, body := get(t, events, "a"); body != "Bearer a 1" {
+		t.Errorf("expected the cached response, got %q", body)
+	}
+	if _, body := get(t, events, "b"); body != "Bearer b 2" {
+		t.Errorf("expected the response of another token to be fetched, got %q", body)
+	}
+
+	now = now.Add(time.Minute)
+	if _, body := get(t, events, "a"); body != "Bearer a 3" {
+		t.Errorf("expected the expired response to be fetched again, got %q", body)
+	}
+
+	if stats := s.Stats(); stats.Requests != 4 || stats.Hits != 1 || stats.Upstream != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 requests to the API, got %d", calls.Load())
+	}
+}
+
+func TestCacheGatewayHeader(t *testing.T) {
+	d, _, _ := newTestServer(t, time.Minute, nil)
+
+	events := d.URL + "/acme/events?rsid=1"
+	if _, body := getWith(t, events, "X-Gateway-Token", "a"); body != "a 1" {
+		t.Fatalf("expected the header to be forwarded, got %q", body)
+	}
+	if _, body := getWith(t, events, "X-Gateway-Token", "b"); body != "b 2" {
+		t.Errorf("expected the response of another header to be fetched, got %q", body)
+	}
+	if _, body := getWith(t, events, "X-Gateway-Token", "a"); body != "a 1" {
+		t.Errorf("expected the cached response, got %q", body)
+	}
+}
+
+func TestCacheRelativeSearch(t *testing.T) {
+	d, _, calls := newTestServer(t, time.Minute, nil)
+
+	tests := []struct {
+		query  string
+		cached bool
+	}{
+		{"q=*&from=-1h&until=now", false},
+		{"q=*", false},
+		{"q=*&from=2024-03-01T00:00:00Z&until=2024-03-02T00:00:00Z", true},
+		{"q=*&from=2024-03-01T00:00:00Z", false},
+		{"q=*&fail=1&from=2024-03-01T00:00:00Z&until=2024-03-02T00:00:00Z", false},
+	}
+	for _, test := range tests {
+		get(t, d.URL+"/acme/search?"+test.query, "a")
+		before := calls.Load()
+		get(t, d.URL+"/acme/search?"+test.query, "a")
+		if cached := calls.Load() == before; cached != test.cached {
+			t.Errorf("%s: expected cached %v, got %v", test.query, test.cached, cached)
+		}
+	}
+}
+
+func TestNoCache(t *testing.T) {
+	d, _, calls := newTestServer(t, 0, nil)
+
+	get(t, d.URL+"/acme/events?rsid=1", "a")
+	get(t, d.URL+"/acme/events?rsid=1", "a")
+	if calls.Load() != 2 {
+		t.Errorf("expected every request to be sent without a cache, got %d", calls.Load())
+	}
+}
+
+func TestShareInflight(t *testing.T) {
+	release := make(chan struct{})
+	d, s, calls := newTestServer(t, 0, release)
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 3)
+	for i := range bodies {
+		wg.Go(func() {
+			_, bodies[i] = get(t, d.URL+"/acme/events?rsid=1", "a")
+		})
+	}
+
+	for s.Stats().Requests < int64(len(bodies)) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for i, body := range bodies {
+		if body != "Bearer a 1" {
+			t.Errorf("request %d: expected the shared response, got %q", i, body)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single request to the API, got %d", calls.Load())
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	d, _, _ := newTestServer(t, time.Minute, nil)
+
+	if status, _ := get(t, d.URL+"/acme/events?fail=1", "a"); status != http.StatusInternalServerError {
+		t.Errorf("expected the status of the API, got %d", status)
+	}
+	if status, _ := get(t, d.URL+"/events", "a"); status != http.StatusNotFound {
+		t.Errorf("expected a path without an account to be rejected, got %d", status)
+	}
+	if status, _ := get(t, d.URL+"/evil.com%2F/events", "a"); status != http.StatusNotFound {
+		t.Errorf("expected an invalid account to be rejected, got %d", status)
+	}
+}
+
+func TestEvict(t *testing.T) {
+	d, s, _ := newTestServer(t, time.Minute, nil)
+	s.opts.MaxEntries = 2
+
+	for i := range 3 {
+		get(t, fmt.Sprintf("%s/acme/events?rsid=%d", d.URL, i), "a")
+	}
+	if entries := s.Stats().Entries; entries != 2 {
+		t.Errorf("expected the cache to be limited to 2 entries, got %d", entries)
+	}
+}
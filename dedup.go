@@ -0,0 +1,59 @@
+package main
+
+// dedupFilter drops the events seen before, by the id Loggly gives the
+// events with -dedup or by the value of the -dedup-by field, so the
+// events repeated across the pages are printed once.
+type dedupFilter struct {
+	field string
+	seen  map[string]bool
+}
+
+// newDedupFilter Returns nil when neither -dedup nor -dedup-by is set.
+func newDedupFilter(config Config) *dedupFilter {
+	switch {
+	case config.DedupBy != "":
+		return &dedupFilter{field: config.DedupBy, seen: make(map[string]bool)}
+	case config.Dedup:
+		return &dedupFilter{field: "id", seen: make(map[string]bool)}
+	default:
+		return nil
+	}
+}
+
+// key The value identifying the Loggly event, looked up in the event and
+// in its JSON message. False when the event has no such value.
+func (f *dedupFilter) key(event any) (string, bool) {
+	v, ok := lookupField(event, f.field)
+	if !ok {
+		if message, found := lookupPath(event, "event.json"); found {
+			v, ok = lookupField(message, f.field)
+		}
+	}
+	if !ok || v == nil {
+		return "", false
+	}
+
+	return formatValue(v), true
+}
+
+// Apply Return the events not seen before, applied to the Loggly events
+// before their messages are decoded. The events without the field are
+// kept.
+func (f *dedupFilter) Apply(events []any) []any {
+	if f == nil {
+		return events
+	}
+
+	var ret []any
+	for _, event := range events {
+		if key, ok := f.key(event); ok {
+			if f.seen[key] {
+				continue
+			}
+			f.seen[key] = true
+		}
+		ret = append(ret, event)
+	}
+
+	return ret
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+)
+
+// configurableDefaults Flags whose defaults can be set in the [defaults]
+// section of the config file or in the environment.
+var configurableDefaults = []struct {
+	flag string
+	env  string
+}{
+	{"size", "LOGGLY_SIZE"},
+	{"maxPages", "LOGGLY_MAX_PAGES"},
+	{"concurrency", "LOGGLY_CONCURRENCY"},
+	{"from", "LOGGLY_FROM"},
+	{"to", "LOGGLY_TO"},
+	{"page-size", "LOGGLY_PAGE_SIZE"},
+	{"accessible", "LOGGLY_ACCESSIBLE"},
+	{"daemon", "LOGGLY_DAEMON"},
+	{"auth", "LOGGLY_AUTH"},
+	{"auth-header", "LOGGLY_AUTH_HEADER"},
+}
+
+// Where the effective value of a flag comes from.
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceConfig  = "config"
+	sourceBuiltin = "default"
+)
+
+// applyDefaults Set the flags not given on the command line from the
+// environment, or else from the config file. Returns the source of the
+// value of every configurable flag.
+func applyDefaults(flags *flag.FlagSet, file configfile.File, getenv func(string) string) (map[string]string, error) {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	// -until is the alias of -to
+	given["to"] = given["to"] || given["until"]
+
+	sources := make(map[string]string, len(configurableDefaults))
+	for _, d := range configurableDefaults {
+		if given[d.flag] {
+			sources[d.flag] = sourceFlag
+			continue
+		}
+
+		value, source := getenv(d.env), sourceEnv
+		if value == "" {
+			value, source = file["defaults."+d.flag], sourceConfig
+		}
+		if value == "" {
+			sources[d.flag] = sourceBuiltin
+			continue
+		}
+
+		if err := flags.Set(d.flag, value); err != nil {
+			if source == sourceEnv {
+				return nil, fmt.Errorf("invalid $%s %q: %w", d.env, value, err)
+			}
+			return nil, fmt.Errorf("invalid defaults.%s %q in the config file: %w", d.flag, value, err)
+		}
+		sources[d.flag] = source
+	}
+
+	return sources, nil
+}
+
+// printDefaults Print the effective value and the source of every
+// configurable flag.
+func printDefaults(w io.Writer, flags *flag.FlagSet, sources map[string]string) {
+	for _, d := range configurableDefaults {
+		f := flags.Lookup(d.flag)
+		source := sources[d.flag]
+		if source == sourceEnv {
+			source += " $" + d.env
+		}
+		fmt.Fprintf(w, "%-12s %-10s (%s)\n", d.flag, f.Value.String(), source)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/mockserver"
+)
+
+// runDemo Run the CLI against the in-process mock server, so every
+// feature can be tried without Loggly credentials.
+func runDemo(args []string) {
+	srv, err := mockserver.New(time.Now())
+	check(err)
+	check(srv.Start())
+	defer srv.Close()
+
+	run(args, runOptions{override: func(c *Config) {
+		c.Account = "demo"
+		c.Token = "demo"
+		c.Endpoint = srv.URL
+	}})
+}
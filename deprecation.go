@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+)
+
+// Deprecated invocations, still working but pointing to their
+// replacements.
+var deprecations = map[string]string{
+	"bare-query": "searching without a subcommand is deprecated, use `loggly search [options] [query...]`",
+	"tui-flag":   "-tui is deprecated, use `loggly tui [options] [query...]`",
+	"to-flag":    "-to is deprecated, use -until",
+}
+
+// warnDeprecated Print the warning of the deprecation once per user.
+// Setting LOGGLY_NO_DEPRECATION_WARNINGS silences them.
+func warnDeprecated(config Config, id string) {
+	if os.Getenv("LOGGLY_NO_DEPRECATION_WARNINGS") != "" {
+		return
+	}
+
+	file, err := platform.StateFile("deprecations")
+	if err != nil {
+		// without a state directory warn every time
+		config.warnf("%s", locale.Sprintf(deprecations[id]))
+		return
+	}
+
+	if deprecationShown(file, id) {
+		return
+	}
+
+	config.warnf("%s", locale.Sprintf(deprecations[id]))
+	if config.Quiet {
+		return
+	}
+
+	if err := platform.EnsureDir(file); err != nil {
+		return
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(id + "\n")
+}
+
+// deprecationShown Whether the warning was already printed, the state
+// file lists the ids of the printed warnings.
+func deprecationShown(file, id string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// distinctValues The values of a field with the number of the events
+// having them. The elements of the arrays are values of their own, like
+// the tags.
+type distinctValues struct {
+	field   string
+	counts  map[string]int
+	missing int
+	// onNew Called with the values not seen before, as they are found.
+	onNew func(value string)
+}
+
+func newDistinctValues(field string, onNew func(value string)) *distinctValues {
+	return &distinctValues{field: field, counts: make(map[string]int), onNew: onNew}
+}
+
+func (d *distinctValues) Add(event any) {
+	v, ok := lookupField(event, d.field)
+	if !ok || v == nil {
+		d.missing++
+		return
+	}
+
+	values := []any{v}
+	if array, ok := v.([]any); ok {
+		values = array
+	}
+	for _, value := range values {
+		s := formatValue(value)
+		if d.counts[s] == 0 && d.onNew != nil {
+			d.onNew(s)
+		}
+		d.counts[s]++
+	}
+}
+
+// printValueCounts Print a tab separated line per value with its count,
+// the most frequent first.
+func printValueCounts(w io.Writer, counts map[string]int) error {
+	for _, v := range topValues(counts, 0) {
+		if _, err := fmt.Fprintf(w, "%s\t%d\n", v.Value, v.Count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runDistinct Print the unique values of the -field across the fetched
+// pages, a line per value as soon as it is first seen, or with -counts
+// at the end with their counts.
+func runDistinct(args []string) {
+	var config Config
+	flags := newFlagSet("loggly distinct", &config)
+	field := flags.String("field", "", "")
+	counts := flags.Bool("counts", false, "")
+	flags.Parse(args)
+
+	prepareScanConfig(flags, &config)
+
+	if *field == "" {
+		check(usageError(fmt.Errorf("usage: loggly distinct -field <field> [-counts] [options] [query...]")))
+	}
+
+	query, queryErr := resolveQuery(config, flags.Args())
+	check(queryErr)
+	if query == "" {
+		query = "*"
+	}
+	check(lintQuery(config, query))
+
+	var onNew func(string)
+	if !*counts {
+		onNew = func(value string) {
+			_, err := fmt.Println(value)
+			check(err)
+		}
+	}
+
+	values := newDistinctValues(*field, onNew)
+	scanEvents(config, "distinct", query, values.Add)
+
+	if values.missing > 0 {
+		config.warnf("%d events without %s", values.missing, *field)
+	}
+	if *counts {
+		check(printValueCounts(os.Stdout, values.counts))
+	}
+	exitIfEmpty(config, int64(len(values.counts)))
+}
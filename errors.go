@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// Exit codes of the command.
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitAuthFailed  = 3
+	exitRateLimited = 4
+	// exitNoResults nothing matched and -fail-empty is set
+	exitNoResults = 5
+	// exitAssertFailed an expectation of loggly assert was not met
+	exitAssertFailed = 6
+)
+
+// errorFormat How check prints the errors, "text" or "json".
+var errorFormat = "text"
+
+func setErrorFormat(format string) error {
+	switch format {
+	case "text", "json":
+		errorFormat = format
+		return nil
+	default:
+		return usageError(fmt.Errorf("invalid error-format %q, use text or json", format))
+	}
+}
+
+// UsageError The command line was invalid.
+type UsageError struct {
+	err error
+}
+
+func (e *UsageError) Error() string { return e.err.Error() }
+func (e *UsageError) Unwrap() error { return e.err }
+
+// usageError Mark err as caused by invalid command line usage.
+func usageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &UsageError{err: err}
+}
+
+// errorReport Machine readable description of a failure.
+type errorReport struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Status  int    `json:"status,omitempty"`
+	// Transient the failure is likely temporary, worth retrying later
+	Transient bool `json:"transient,omitempty"`
+}
+
+func newErrorReport(err error) errorReport {
+	report := errorReport{Code: "error", Message: err.Error()}
+
+	var apiErr *search.APIError
+	var usageErr *UsageError
+	var syntaxErr *json.SyntaxError
+
+	switch {
+	case errors.As(err, &apiErr):
+		report.Status = apiErr.StatusCode
+		report.Transient = apiErr.Transient()
+		switch {
+		case apiErr.IsAuthError():
+			report.Code = "auth_failed"
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			report.Code = "rate_limited"
+		case apiErr.Transient():
+			report.Code = "unavailable"
+		default:
+			report.Code = "http_error"
+		}
+	case errors.As(err, &usageErr):
+		report.Code = "usage_error"
+	case errors.As(err, &syntaxErr):
+		report.Code = "parse_error"
+	case errors.Is(err, context.DeadlineExceeded):
+		report.Code = "timeout"
+	case errors.Is(err, context.Canceled):
+		report.Code = "canceled"
+	}
+
+	return report
+}
+
+// exitCode Exit code describing the error.
+func exitCode(err error) int {
+	switch newErrorReport(err).Code {
+	case "usage_error":
+		return exitUsage
+	case "auth_failed":
+		return exitAuthFailed
+	case "rate_limited":
+		return exitRateLimited
+	default:
+		return exitError
+	}
+}
+
+// check Print the error in the selected format and exit.
+func check(err error) {
+	if err == nil {
+		return
+	}
+
+	// the query failed, or the command was interrupted while running it
+	finishAudit(0, err)
+	if runningPager != nil {
+		runningPager.Close()
+	}
+
+	if errorFormat == "json" {
+		data, _ := json.Marshal(newErrorReport(err))
+		fmt.Fprintln(os.Stderr, string(data))
+	} else {
+		fmt.Fprintln(os.Stderr, locale.Sprintf("Error: %s", err))
+	}
+
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+)
+
+// prepareScanConfig Apply the defaults, resolve the token and check the
+// options of the commands scanning the events of a query.
+func prepareScanConfig(flags *flag.FlagSet, config *Config) {
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+}
+
+// scanEvents Fetch the events of the query and pass the decoded ones
+// kept by -grep, -grep-v and -where, rewritten by -jq, to scan: the
+// summarizing commands aggregate them on the client. Warns when the page
+// limits cut the scanning short.
+func scanEvents(config Config, command, query string, scan func(event any)) {
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	filters, filtersErr := newEventFilters(config)
+	check(filtersErr)
+
+	limit := newEventLimit(&config)
+	// the fetching stops once -max-events of the matching events are
+	// scanned, and fails with context.Canceled then
+	fetchCtx, stopFetching := context.WithCancel(ctx)
+	defer stopFetching()
+	checkFetch := func(err error) {
+		if !limit.Reached() || !errors.Is(err, context.Canceled) {
+			check(err)
+		}
+	}
+
+	check(startAudit(config, command, query))
+	stream, err := fetchEvents(fetchCtx, config, query)
+	check(err)
+
+	var fetched int64
+	resChan := stream.Responses
+	for resChan != nil {
+		select {
+		case <-ctx.Done():
+			check(ctx.Err())
+		case r, ok := <-resChan:
+			if !ok {
+				resChan = nil
+				continue
+			}
+			if limit.Reached() {
+				continue
+			}
+			fetched += int64(r.Len())
+			events, ok := decodeRes(config, r)
+			if !ok {
+				continue
+			}
+			for _, event := range limit.Apply(filters.Match(events)) {
+				scan(event)
+			}
+			if limit.Reached() {
+				stopFetching()
+			}
+		case err := <-stream.Errors:
+			checkFetch(err)
+		}
+	}
+	checkFetch(<-stream.Errors)
+	check(finishAudit(fetched, nil))
+
+	switch {
+	case limit.Reached():
+		config.warnf("The page limits were reached, only the first %d events are counted, raise -maxPages or -size", limit.kept)
+	case fetched >= (config.MaxPages+1)*int64(config.Size):
+		config.warnf("The page limits were reached, only the first %d events are counted, raise -maxPages or -size", fetched)
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// exportManifest Describes an export, so the next export can continue
+// where it stopped.
+type exportManifest struct {
+	Query      string    `json:"query"`
+	ExportedAt time.Time `json:"exported_at"`
+	Count      int       `json:"count"`
+	// Watermark Time of the newest exported event in epoch milliseconds,
+	// of any export since the first one.
+	Watermark int64 `json:"watermark"`
+	// WatermarkIDs IDs of the exported events at Watermark, the next
+	// export starts at Watermark and skips them.
+	WatermarkIDs []string `json:"watermark_ids,omitempty"`
+	// From, Until, Size and MaxEvents The search of the last export, to
+	// fetch its pages again with -verify.
+	From      string `json:"from,omitempty"`
+	Until     string `json:"until,omitempty"`
+	Size      int    `json:"size,omitempty"`
+	MaxEvents int64  `json:"max_events,omitempty"`
+	// Pages The checksums of the pages of the last export.
+	Pages []pageChecksum `json:"pages,omitempty"`
+}
+
+// pageChecksum The number of events and the checksum of a fetched page.
+type pageChecksum struct {
+	Page   int    `json:"page"`
+	Count  int    `json:"count"`
+	SHA256 string `json:"sha256"`
+}
+
+// checksumPage The checksum of the events of a page as the API returned
+// them.
+func checksumPage(page int, events []json.RawMessage) pageChecksum {
+	h := sha256.New()
+	for _, event := range events {
+		h.Write(event)
+		h.Write([]byte{'\n'})
+	}
+
+	return pageChecksum{Page: page, Count: len(events), SHA256: hex.EncodeToString(h.Sum(nil))}
+}
+
+func readManifest(path string) (exportManifest, error) {
+	var m exportManifest
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// writeManifest Write the manifest through a temporary file, so an
+// interrupted write leaves the previous manifest intact.
+func writeManifest(path string, m exportManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return platform.WriteFile(path, func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// eventKey The id and the time in epoch milliseconds of a whole event.
+func eventKey(raw json.RawMessage) (string, int64, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+
+	var m map[string]any
+	if err := d.Decode(&m); err != nil {
+		return "", 0, err
+	}
+
+	ts, ok := search.EventTimestamp(m)
+	if !ok {
+		return "", 0, fmt.Errorf("event without timestamp")
+	}
+
+	id, _ := m["id"].(string)
+	return id, ts.UnixMilli(), nil
+}
+
+// verifyExport Fetch a random subset of the pages of the export again
+// and compare them with their checksums in the manifest. Returns the
+// number of differing pages.
+func verifyExport(ctx context.Context, config Config, m exportManifest, n int) (int, error) {
+	c, err := config.newClient()
+	if err != nil {
+		return 0, err
+	}
+
+	q := search.NewQuery(m.Query).
+		Size(m.Size).
+		From(m.From).
+		To(m.Until).
+		MaxEvents(m.MaxEvents).
+		Raw(true).
+		Order("asc")
+
+	var differ int
+	for _, i := range rand.Perm(len(m.Pages))[:min(n, len(m.Pages))] {
+		want := m.Pages[i]
+
+		res, err := c.FetchPage(ctx, *q, want.Page)
+		if err != nil {
+			return differ, fmt.Errorf("page %d: %w", want.Page, err)
+		}
+
+		got := checksumPage(want.Page, res.Raw)
+		switch {
+		case got.Count != want.Count:
+			differ++
+			fmt.Fprintln(os.Stderr, locale.Sprintf("page %d: DIFFERS, %d events instead of %d", want.Page, got.Count, want.Count))
+		case got.SHA256 != want.SHA256:
+			differ++
+			fmt.Fprintln(os.Stderr, locale.Sprintf("page %d: DIFFERS, checksum %s instead of %s", want.Page, got.SHA256, want.SHA256))
+		default:
+			fmt.Fprintln(os.Stderr, locale.Sprintf("page %d: ok", want.Page))
+		}
+	}
+
+	return differ, nil
+}
+
+// runExport Print the whole events of the query oldest first. With
+// -diff-against only the events missing from the previous export are
+// printed, and -manifest records what was exported for the next run.
+// -verify checks the pages recorded in a manifest instead.
+func runExport(args []string) {
+	if len(args) > 0 && args[0] == "sqlite" {
+		runExportSQLite(args[1:])
+		return
+	}
+
+	var config Config
+	flags := newFlagSet("loggly export", &config)
+	manifestPath := flags.String("manifest", "", "")
+	diffAgainst := flags.String("diff-against", "", "")
+	verify := flags.String("verify", "", "")
+	verifyPages := flags.Int("verify-pages", 3, "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	query := strings.Join(flags.Args(), " ")
+
+	if *verify != "" {
+		if *manifestPath != "" || *diffAgainst != "" || query != "" {
+			check(usageError(fmt.Errorf("verify checks the export of the manifest, it takes no query, -manifest or -diff-against")))
+		}
+		if *verifyPages < 1 {
+			check(usageError(fmt.Errorf("verify-pages must be at least 1")))
+		}
+
+		m, err := readManifest(*verify)
+		check(usageError(err))
+		if len(m.Pages) == 0 {
+			check(usageError(fmt.Errorf("manifest %s has no page checksums, export again with -manifest", *verify)))
+		}
+
+		ctx, cancel := contextWithInterrupt(context.Background())
+		defer cancel()
+
+		config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+		check(config.resolveToken(os.Stdin))
+		check(usageError(config.Validate()))
+
+		differ, err := verifyExport(ctx, config, m, *verifyPages)
+		check(err)
+		if differ > 0 {
+			check(fmt.Errorf("%d of the verified pages differ from the export", differ))
+		}
+		return
+	}
+
+	var previous exportManifest
+	if *diffAgainst != "" {
+		var err error
+		previous, err = readManifest(*diffAgainst)
+		if errors.Is(err, fs.ErrNotExist) {
+			check(usageError(fmt.Errorf("manifest %s does not exist, run the first export without -diff-against", *diffAgainst)))
+		}
+		check(usageError(err))
+
+		if query == "" {
+			query = previous.Query
+		}
+		if query != previous.Query {
+			check(usageError(fmt.Errorf("the previous export was of the query %q, not %q", previous.Query, query)))
+		}
+		if previous.Watermark > 0 {
+			config.From = time.UnixMilli(previous.Watermark).UTC().Format(time.RFC3339Nano)
+		}
+	}
+	if query == "" {
+		query = "*"
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+	if len(splitList(config.Account)) > 1 {
+		check(usageError(fmt.Errorf("an export reads a single account")))
+	}
+
+	// an absolute time range, so -verify fetches the same pages again
+	now := time.Now()
+	for _, t := range []*string{&config.From, &config.To} {
+		if resolved, ok := resolveTime(*t, now); ok {
+			*t = resolved.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano)
+		}
+	}
+
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	if config.Stats {
+		config.stats = newRunStats()
+	}
+	c, err := config.newClient()
+	check(err)
+
+	out, err := newOutput(config, query)
+	check(err)
+
+	check(startAudit(config, "export", query))
+
+	// oldest first, so an export cut short by the page limits leaves no gap
+	q := config.newQuery(query).Raw(true).Order("asc")
+	resChan, errChan := c.Fetch(ctx, *q)
+
+	next := exportManifest{
+		Query:        query,
+		Watermark:    previous.Watermark,
+		WatermarkIDs: slices.Clone(previous.WatermarkIDs),
+		From:         config.From,
+		Until:        config.To,
+		Size:         config.Size,
+		MaxEvents:    config.MaxEvents,
+	}
+
+	var fetched int
+	for resChan != nil {
+		select {
+		case <-ctx.Done():
+			check(ctx.Err())
+		case r, ok := <-resChan:
+			if !ok {
+				resChan = nil
+				continue
+			}
+			// the pages arrive in order
+			next.Pages = append(next.Pages, checksumPage(len(next.Pages), r.Raw))
+			fetched += r.Len()
+
+			var events []json.RawMessage
+			for _, raw := range r.Raw {
+				id, ts, err := eventKey(raw)
+				check(err)
+
+				if ts < previous.Watermark || (ts == previous.Watermark && id != "" && slices.Contains(previous.WatermarkIDs, id)) {
+					continue
+				}
+				events = append(events, raw)
+
+				if ts > next.Watermark {
+					next.Watermark = ts
+					next.WatermarkIDs = nil
+				}
+				if ts == next.Watermark && id != "" {
+					next.WatermarkIDs = append(next.WatermarkIDs, id)
+				}
+			}
+
+			check(out.WriteRaw(events))
+			next.Count += len(events)
+		case err := <-errChan:
+			check(err)
+		}
+	}
+	check(<-errChan)
+	check(out.Close())
+	check(finishAudit(int64(fetched), nil))
+
+	if *manifestPath != "" {
+		next.ExportedAt = time.Now().UTC()
+		check(writeManifest(*manifestPath, next))
+	}
+
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Exported %d events", next.Count))
+	if config.stats != nil {
+		check(config.stats.Print(os.Stderr))
+	}
+
+	limit := (config.MaxPages + 1) * int64(config.Size)
+	if config.MaxEvents > 0 {
+		limit = min(limit, config.MaxEvents)
+	}
+	if int64(fetched) >= limit {
+		config.warnf("The page limits were reached, export again with -diff-against to get the newer events")
+	}
+}
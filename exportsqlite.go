@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// quoteIdent Quote a table or column name for SQLite, the field paths
+// have dots in them.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqliteValue The value of a field as SQLite stores it. The numbers stay
+// numbers, objects and arrays are stored as JSON text.
+func sqliteValue(v any) any {
+	switch v := v.(type) {
+	case nil, string, bool:
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// sqliteExport Writes events into a table of a SQLite database, a row
+// per event with its id, its time in epoch milliseconds, a column per
+// selected field and the whole event as JSON.
+type sqliteExport struct {
+	db      *sql.DB
+	table   string
+	columns []string
+}
+
+// openSQLiteExport Open the database and create the table, or add the
+// columns missing from the table of an earlier export.
+func openSQLiteExport(path, table string, columns []string) (*sqliteExport, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &sqliteExport{db: db, table: table, columns: columns}
+	if err := e.createTable(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return e, nil
+}
+
+func (e *sqliteExport) createTable() error {
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id TEXT PRIMARY KEY, timestamp INTEGER, event TEXT NOT NULL)", quoteIdent(e.table))
+	if _, err := e.db.Exec(create); err != nil {
+		return err
+	}
+
+	rows, err := e.db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info(%s)", quoteIdent(e.table)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// no type, the values keep the type they have in the events
+	for _, column := range e.columns {
+		if existing[column] {
+			continue
+		}
+		if _, err := e.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", quoteIdent(e.table), quoteIdent(column))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Insert Insert the events of a page in a transaction. The events
+// already in the table are skipped by their id, so exporting an
+// overlapping time range again adds only the new ones. Returns the
+// number of inserted events.
+func (e *sqliteExport) Insert(events []json.RawMessage) (int64, error) {
+	names := []string{"id", "timestamp", "event"}
+	for _, column := range e.columns {
+		names = append(names, quoteIdent(column))
+	}
+	insert := fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)",
+		quoteIdent(e.table), strings.Join(names, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(insert)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var inserted int64
+	var compact bytes.Buffer
+	for _, raw := range events {
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		var event map[string]any
+		if err := d.Decode(&event); err != nil {
+			return 0, err
+		}
+
+		compact.Reset()
+		if err := json.Compact(&compact, raw); err != nil {
+			return 0, err
+		}
+
+		var id, timestamp any
+		if s, ok := event["id"].(string); ok && s != "" {
+			id = s
+		}
+		if t, ok := search.EventTimestamp(event); ok {
+			timestamp = t.UnixMilli()
+		}
+
+		values := []any{id, timestamp, compact.String()}
+		for _, column := range e.columns {
+			v, _ := lookupField(event, column)
+			values = append(values, sqliteValue(v))
+		}
+
+		res, err := stmt.Exec(values...)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		inserted += n
+	}
+
+	return inserted, tx.Commit()
+}
+
+func (e *sqliteExport) Close() error {
+	return e.db.Close()
+}
+
+// runExportSQLite Write the whole events of the query into a table of a
+// SQLite database, -columns selects the fields stored in columns of
+// their own.
+func runExportSQLite(args []string) {
+	var config Config
+	flags := newFlagSet("loggly export sqlite", &config)
+	dbPath := flags.String("db", "", "")
+	table := flags.String("table", "events", "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	if *dbPath == "" {
+		check(usageError(fmt.Errorf("usage: loggly export sqlite -db <file> [options] [query...]")))
+	}
+	if *table == "" {
+		check(usageError(fmt.Errorf("table can not be empty")))
+	}
+
+	// the columns of the table, not of a printed format
+	columns := splitList(config.Columns)
+	config.Columns = ""
+
+	query := strings.Join(flags.Args(), " ")
+	if query == "" {
+		query = "*"
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+	if len(splitList(config.Account)) > 1 {
+		check(usageError(fmt.Errorf("an export reads a single account")))
+	}
+
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	db, err := openSQLiteExport(*dbPath, *table, columns)
+	check(err)
+	defer db.Close()
+
+	if config.Stats {
+		config.stats = newRunStats()
+	}
+	c, err := config.newClient()
+	check(err)
+
+	check(startAudit(config, "export sqlite", query))
+
+	q := config.newQuery(query).Raw(true).Order("asc")
+	resChan, errChan := c.Fetch(ctx, *q)
+
+	var fetched, inserted int64
+	for resChan != nil {
+		select {
+		case <-ctx.Done():
+			check(ctx.Err())
+		case r, ok := <-resChan:
+			if !ok {
+				resChan = nil
+				continue
+			}
+			fetched += int64(r.Len())
+
+			n, err := db.Insert(r.Raw)
+			check(err)
+			inserted += n
+		case err := <-errChan:
+			check(err)
+		}
+	}
+	check(<-errChan)
+	check(finishAudit(fetched, nil))
+	check(db.Close())
+
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Exported %d events to %s, %d of them new", fetched, *dbPath, inserted))
+	if config.stats != nil {
+		check(config.stats.Print(os.Stderr))
+	}
+}
@@ -0,0 +1,250 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+)
+
+type node interface {
+	eval(lookup Lookup) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) eval(Lookup) (any, error) {
+	return n.value, nil
+}
+
+type fieldNode struct {
+	path string
+}
+
+func (n fieldNode) eval(lookup Lookup) (any, error) {
+	v, ok := lookup(n.path)
+	if !ok {
+		return nil, nil
+	}
+	return normalize(v), nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n unaryNode) eval(lookup Lookup) (any, error) {
+	v, err := n.operand.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "!":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expr: cannot negate %s", typeName(v))
+		}
+		return !b, nil
+	default:
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expr: cannot negate %s", typeName(v))
+		}
+		return -f, nil
+	}
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+	// compiled pattern of =~ and !~ with a literal right side
+	re *regexp.Regexp
+}
+
+func newBinary(op string, left, right node) (node, error) {
+	n := binaryNode{op: op, left: left, right: right}
+
+	if op == "=~" || op == "!~" {
+		if lit, ok := right.(literalNode); ok {
+			pattern, ok := lit.value.(string)
+			if !ok {
+				return nil, fmt.Errorf("right side of %s must be a string", op)
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+			}
+			n.re = re
+		}
+	}
+
+	return n, nil
+}
+
+func (n binaryNode) eval(lookup Lookup) (any, error) {
+	left, err := n.left.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	// short circuit
+	switch n.op {
+	case "&&", "||":
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expr: left side of %s is %s, not a boolean", n.op, typeName(left))
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := n.right.eval(lookup)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expr: right side of %s is %s, not a boolean", n.op, typeName(right))
+		}
+		return r, nil
+	}
+
+	right, err := n.right.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "=~", "!~":
+		return n.match(left, right)
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	default:
+		return arithmetic(n.op, left, right)
+	}
+}
+
+func (n binaryNode) match(left, right any) (any, error) {
+	if left == nil {
+		return n.op == "!~", nil
+	}
+
+	s, ok := left.(string)
+	if !ok {
+		s = fmt.Sprint(left)
+	}
+
+	re := n.re
+	if re == nil {
+		pattern, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("expr: right side of %s is %s, not a string", n.op, typeName(right))
+		}
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("expr: invalid regular expression %q: %w", pattern, err)
+		}
+	}
+
+	return re.MatchString(s) == (n.op == "=~"), nil
+}
+
+func equal(left, right any) bool {
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case bool, float64, string:
+		return left == right
+	default:
+		return fmt.Sprint(l) == fmt.Sprint(right)
+	}
+}
+
+// compare Order numbers or strings. Comparing with a missing field is
+// false, comparing different types is an error.
+func compare(op string, left, right any) (any, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var c int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expr: cannot compare number with %s", typeName(right))
+		}
+		c = cmpOrdered(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("expr: cannot compare string with %s", typeName(right))
+		}
+		c = cmpOrdered(l, r)
+	default:
+		return nil, fmt.Errorf("expr: cannot compare %s", typeName(left))
+	}
+
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func cmpOrdered[T float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func arithmetic(op string, left, right any) (any, error) {
+	if op == "+" {
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("expr: cannot apply %s to %s and %s", op, typeName(left), typeName(right))
+	}
+
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("expr: division by zero")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("expr: division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
@@ -0,0 +1,108 @@
+// Package expr evaluates small typed boolean expressions over events,
+// like `json.status >= 500 && json.duration > 1000`.
+//
+// Supported are field paths, numbers, 'single' or "double" quoted
+// strings, true, false and null literals, the arithmetic operators
+// + - * / %, the comparisons == != < <= > >=, the regular expression
+// matches =~ and !~, and the logical operators && || and !.
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Lookup Resolve a field path of the evaluated event.
+type Lookup func(path string) (any, bool)
+
+// Expr A parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse Compile the expression.
+func Parse(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("expr: %w", err)
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parse(0)
+	if err != nil {
+		return nil, fmt.Errorf("expr: %w", err)
+	}
+
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("expr: unexpected %q at position %d", t.text, t.pos)
+	}
+
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval Evaluate the expression. The result is nil, a bool, a float64
+// or a string.
+func (e *Expr) Eval(lookup Lookup) (any, error) {
+	return e.root.eval(lookup)
+}
+
+// Match Evaluate the expression which must result in a bool.
+func (e *Expr) Match(lookup Lookup) (bool, error) {
+	v, err := e.Eval(lookup)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expr: %q is %s, not a boolean", e.src, typeName(v))
+	}
+
+	return b, nil
+}
+
+// normalize Convert the looked up values to the types of the language.
+func normalize(v any) any {
+	switch n := v.(type) {
+	case nil, bool, string, float64:
+		return v
+	case fmt.Stringer:
+		// json.Number and alike
+		if f, err := strconv.ParseFloat(n.String(), 64); err == nil {
+			return f
+		}
+		return n.String()
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	default:
+		return v
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
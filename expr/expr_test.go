@@ -0,0 +1,82 @@
+package expr
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	event := map[string]any{
+		"json.status":   json.Number("503"),
+		"json.duration": 1500,
+		"json.path":     "/v2/users",
+		"json.ok":       false,
+	}
+	lookup := func(path string) (any, bool) {
+		v, ok := event[path]
+		return v, ok
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"json.status >= 500 && json.duration > 1000", true},
+		{"json.status >= 500 && json.duration > 2000", false},
+		{"json.status == 503", true},
+		{"json.status != 503 || json.path == '/v2/users'", true},
+		{"!(json.status < 500)", true},
+		{"json.duration / 1000 == 1.5", true},
+		{"json.duration % 1000 == 500", true},
+		{"-json.status < 0", true},
+		{`json.path =~ "^/v2/"`, true},
+		{`json.path !~ "^/v2/"`, false},
+		{"json.missing == null", true},
+		{"json.missing > 1", false},
+		{"json.ok == false", true},
+		{"1 + 2 * 3 == 7", true},
+		{"'a' + 'b' == \"ab\"", true},
+		{"json.path > 'a' && json.path < 'z'", false},
+	}
+
+	for _, test := range tests {
+		e, err := Parse(test.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", test.expr, err)
+		}
+
+		got, err := e.Match(lookup)
+		if err != nil {
+			t.Fatalf("Match(%q) error: %v", test.expr, err)
+		}
+
+		if got != test.want {
+			t.Errorf("Match(%q) = %v, want %v", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestMatchTypeErrors(t *testing.T) {
+	lookup := func(path string) (any, bool) {
+		return "text", true
+	}
+
+	for _, src := range []string{"a > 1", "a && true", "a - 1", "a", "!a", "1 / 0"} {
+		e, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", src, err)
+		}
+
+		if _, err := e.Match(lookup); err == nil {
+			t.Errorf("Match(%q) expected a type error", src)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{"", "a >", "(a == 1", "a == 1)", "'open", "a # 1", "a =~ '('", "a =~ 1", "> 1"} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) expected an error", src)
+		}
+	}
+}
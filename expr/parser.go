@@ -0,0 +1,109 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "=~": 3, "!~": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+const unaryPrecedence = 7
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parse Parse an expression whose binary operators bind tighter than
+// minPrec.
+func (p *parser) parse(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokOp || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parse(prec)
+		if err != nil {
+			return nil, err
+		}
+
+		left, err = newBinary(t.text, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokOp:
+		if t.text != "!" && t.text != "-" {
+			return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+		}
+		operand, err := p.parse(unaryPrecedence)
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: t.text, operand: operand}, nil
+	case tokLParen:
+		inner, err := p.parse(0)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("missing ) at position %d", closing.pos)
+		}
+		return inner, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		return literalNode{value: f}, nil
+	case tokString:
+		return literalNode{value: t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null":
+			return literalNode{value: nil}, nil
+		}
+		return fieldNode{path: t.text}, nil
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// fieldAnalysis Counts the fields of the events, the nested ones joined
+// by dots, and the values of the scalar ones. It backs the field list of
+// the TUI, loggly top and loggly analyze.
+type fieldAnalysis struct {
+	// fields The number of the events having the field.
+	fields map[string]int
+	// values The number of the events per value of the scalar fields.
+	values map[string]map[string]int
+}
+
+func newFieldAnalysis() *fieldAnalysis {
+	return &fieldAnalysis{
+		fields: make(map[string]int),
+		values: make(map[string]map[string]int),
+	}
+}
+
+// Add Count the fields of an event.
+func (a *fieldAnalysis) Add(event map[string]any) {
+	a.add(event, nil)
+}
+
+func (a *fieldAnalysis) add(obj map[string]any, path []string) {
+	for key, value := range obj {
+		fullPath := append(path, key)
+		pathStr := strings.Join(fullPath, ".")
+		a.fields[pathStr]++
+
+		switch v := value.(type) {
+		case map[string]any:
+			a.add(v, fullPath)
+		default:
+			valueStr := fmt.Sprintf("%v", v)
+			if a.values[pathStr] == nil {
+				a.values[pathStr] = make(map[string]int)
+			}
+			a.values[pathStr][valueStr]++
+		}
+	}
+}
+
+type valueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// topValues The n most frequent values, the equally frequent ones by
+// value. Every value when n is not positive.
+func topValues(values map[string]int, n int) []valueCount {
+	counts := make([]valueCount, 0, len(values))
+	for value, count := range values {
+		counts = append(counts, valueCount{Value: value, Count: count})
+	}
+	slices.SortFunc(counts, func(a, b valueCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+
+	if n > 0 {
+		counts = counts[:min(len(counts), n)]
+	}
+	return counts
+}
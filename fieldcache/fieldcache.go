@@ -0,0 +1,319 @@
+// Package fieldcache keeps the field names and the most frequent values
+// seen in the fetched events of an account, the source of the shell
+// completion, of the autocompletion of the TUI and of the did you mean
+// suggestions of the unknown fields.
+package fieldcache
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/platform"
+)
+
+// Version The version of the written files. Files of other versions
+// are dropped, a cache is rebuilt by the next runs.
+const Version = 1
+
+// Limits of the cache.
+const (
+	// MaxFields The number of the most frequent fields kept.
+	MaxFields = 2000
+	// MaxValues The number of the most frequent values kept per field.
+	MaxValues = 20
+	// MaxValueLen Longer values are not kept, they are rarely typed.
+	MaxValueLen = 100
+	// MaxDepth Deeper fields are not walked.
+	MaxDepth = 8
+	// Expiry The fields not seen for longer are dropped.
+	Expiry = 30 * 24 * time.Hour
+)
+
+// Field The occurrences of a field and of its values.
+type Field struct {
+	Count  int            `json:"count"`
+	Seen   time.Time      `json:"seen"`
+	Values map[string]int `json:"values,omitempty"`
+}
+
+// Cache The fields by their dotted paths, like json.level.
+type Cache struct {
+	Version int               `json:"version"`
+	Fields  map[string]*Field `json:"fields"`
+}
+
+// New Create an empty cache.
+func New() *Cache {
+	return &Cache{Version: Version, Fields: make(map[string]*Field)}
+}
+
+// Observe Count the fields of a Loggly event and their scalar values.
+// The paths start at the keys of the event, so the fields of the parsed
+// JSON messages are json.*, like in the queries.
+func (c *Cache) Observe(event map[string]any, now time.Time) {
+	c.walk("", event, now, 0)
+}
+
+func (c *Cache) walk(prefix string, m map[string]any, now time.Time, depth int) {
+	if depth >= MaxDepth {
+		return
+	}
+
+	for k, v := range m {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		if child, ok := v.(map[string]any); ok {
+			c.walk(path, child, now, depth+1)
+			continue
+		}
+
+		f := c.field(path)
+		f.Count++
+		f.Seen = now
+		if value, ok := scalar(v); ok && value != "" && len(value) <= MaxValueLen {
+			if f.Values == nil {
+				f.Values = make(map[string]int)
+			}
+			f.Values[value]++
+		}
+	}
+}
+
+func (c *Cache) field(path string) *Field {
+	f, ok := c.Fields[path]
+	if !ok {
+		f = &Field{}
+		c.Fields[path] = f
+	}
+	return f
+}
+
+// scalar The value as typed in a query, false for arrays and nulls.
+func scalar(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return fmt.Sprint(v), true
+	case bool:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
+
+// Merge Add the counts of the other cache.
+func (c *Cache) Merge(other *Cache) {
+	for path, o := range other.Fields {
+		f := c.field(path)
+		f.Count += o.Count
+		if o.Seen.After(f.Seen) {
+			f.Seen = o.Seen
+		}
+		for value, n := range o.Values {
+			if f.Values == nil {
+				f.Values = make(map[string]int)
+			}
+			f.Values[value] += n
+		}
+	}
+}
+
+// Trim Drop the fields not seen since Expiry, and keep the MaxFields
+// most frequent fields with their MaxValues most frequent values.
+func (c *Cache) Trim(now time.Time) {
+	for path, f := range c.Fields {
+		if now.Sub(f.Seen) > Expiry {
+			delete(c.Fields, path)
+			continue
+		}
+		if len(f.Values) > MaxValues {
+			keep := ranked(f.Values)[:MaxValues]
+			f.Values = make(map[string]int, MaxValues)
+			for _, value := range keep {
+				f.Values[value.name] = value.count
+			}
+		}
+	}
+
+	if len(c.Fields) > MaxFields {
+		counts := make(map[string]int, len(c.Fields))
+		for path, f := range c.Fields {
+			counts[path] = f.Count
+		}
+		for _, dropped := range ranked(counts)[MaxFields:] {
+			delete(c.Fields, dropped.name)
+		}
+	}
+}
+
+type rank struct {
+	name  string
+	count int
+}
+
+// ranked The names from the most to the least frequent, the equal ones
+// by name.
+func ranked(counts map[string]int) []rank {
+	ret := make([]rank, 0, len(counts))
+	for name, count := range counts {
+		ret = append(ret, rank{name, count})
+	}
+	slices.SortFunc(ret, func(a, b rank) int {
+		return cmp.Or(cmp.Compare(b.count, a.count), cmp.Compare(a.name, b.name))
+	})
+	return ret
+}
+
+func names(ranks []rank) []string {
+	ret := make([]string, len(ranks))
+	for i, r := range ranks {
+		ret[i] = r.name
+	}
+	return ret
+}
+
+// Has Whether the field was seen.
+func (c *Cache) Has(path string) bool {
+	_, ok := c.Fields[path]
+	return ok
+}
+
+// Names The fields starting with the prefix, the most frequent first.
+func (c *Cache) Names(prefix string) []string {
+	counts := make(map[string]int)
+	for path, f := range c.Fields {
+		if strings.HasPrefix(path, prefix) {
+			counts[path] = f.Count
+		}
+	}
+	return names(ranked(counts))
+}
+
+// Values The values of the field starting with the prefix, the most
+// frequent first.
+func (c *Cache) Values(path, prefix string) []string {
+	f, ok := c.Fields[path]
+	if !ok {
+		return nil
+	}
+
+	counts := maps.Clone(f.Values)
+	maps.DeleteFunc(counts, func(value string, _ int) bool {
+		return !strings.HasPrefix(value, prefix)
+	})
+	return names(ranked(counts))
+}
+
+// Suggest The known field closest to the unknown one, when it is likely
+// a typo of it: at most one edit away for short names, two for the
+// longer ones.
+func (c *Cache) Suggest(path string) (string, bool) {
+	limit := 1
+	if len(path) > 8 {
+		limit = 2
+	}
+
+	best, bestDistance, bestCount := "", limit+1, 0
+	for known, f := range c.Fields {
+		d := distance(path, known)
+		if d < bestDistance || d == bestDistance && (f.Count > bestCount || f.Count == bestCount && known < best) {
+			best, bestDistance, bestCount = known, d, f.Count
+		}
+	}
+
+	return best, best != "" && best != path
+}
+
+// distance The Levenshtein distance of the strings.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// Read Decode a cache, an empty one when it was written by another
+// version.
+func Read(r io.Reader) (*Cache, error) {
+	c := New()
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, fmt.Errorf("fieldcache: invalid file: %w", err)
+	}
+	if c.Version != Version || c.Fields == nil {
+		return New(), nil
+	}
+
+	return c, nil
+}
+
+// Load Read the cache file, an empty cache when it does not exist yet.
+func Load(path string) (*Cache, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Save Write the cache file through a temporary file, the concurrent
+// runs read either the previous or the new cache.
+func (c *Cache) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	c.Version = Version
+	return platform.WriteFile(path, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(c)
+	})
+}
+
+// Update Add the observed fields to the cache file.
+func Update(path string, observed *Cache, now time.Time) error {
+	c, err := Load(path)
+	if err != nil {
+		// a broken cache is rebuilt
+		c = New()
+	}
+
+	c.Merge(observed)
+	c.Trim(now)
+	return c.Save(path)
+}
@@ -0,0 +1,157 @@
+package fieldcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 10, 17, 10, 15, 0, 0, time.UTC)
+
+func event(t testing.TB, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestObserve(t *testing.T) {
+	c := New()
+	c.Observe(event(t, `{"json":{"level":"error","http":{"status":500},"tags":["a"]}}`), now)
+	c.Observe(event(t, `{"json":{"level":"info","http":{"status":200}}}`), now)
+	c.Observe(event(t, `{"json":{"level":"error","http":{"status":null}}}`), now)
+
+	if got, want := c.Names("json."), []string{"json.http.status", "json.level", "json.tags"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, want := c.Values("json.level", ""), []string{"error", "info"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, want := c.Values("json.http.status", "5"), []string{"500"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := c.Values("json.tags", ""); len(got) != 0 {
+		t.Errorf("expected no values of an array, got %v", got)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	c := New()
+	for i := range MaxValues + 5 {
+		c.Observe(map[string]any{"json": map[string]any{"n": fmt.Sprint(i)}}, now)
+	}
+	c.Observe(map[string]any{"json": map[string]any{"n": "3"}}, now)
+	c.Observe(map[string]any{"json": map[string]any{"old": "x"}}, now.Add(-Expiry-time.Hour))
+
+	c.Trim(now)
+
+	if c.Has("json.old") {
+		t.Error("expected the expired field to be dropped")
+	}
+	got := c.Values("json.n", "")
+	if len(got) != MaxValues || got[0] != "3" {
+		t.Errorf("expected %d values starting with the most frequent, got %v", MaxValues, got)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	c := New()
+	c.Observe(event(t, `{"json":{"level":"error","hostname":"api-1","responseTime":12}}`), now)
+
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"json.levl", "json.level", true},
+		{"json.hostnme", "json.hostname", true},
+		{"json.responsetime", "json.responseTime", true},
+		{"json.level", "", false},
+		{"json.service", "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := c.Suggest(test.path)
+		if ok != test.ok || ok && got != test.want {
+			t.Errorf("%s: expected %q %v, got %q %v", test.path, test.want, test.ok, got, ok)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fields", "acme.json")
+
+	for _, level := range []string{"error", "warn", "error"} {
+		observed := New()
+		observed.Observe(map[string]any{"json": map[string]any{"level": level}}, now)
+		if err := Update(path, observed, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := c.Fields["json.level"]; f == nil || f.Count != 3 || f.Values["error"] != 2 {
+		t.Errorf("expected 3 occurrences of json.level, 2 of them error, got %+v", f)
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+	c, err := Read(strings.NewReader(`{"version":2,"fields":{"json.level":{"count":1}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Fields) != 0 {
+		t.Errorf("expected the cache of another version to be dropped, got %v", c.Fields)
+	}
+
+	if _, err := Read(strings.NewReader(`{"version":1`)); err == nil {
+		t.Error("expected an error of the invalid file")
+	}
+}
+
+// benchEvents A page of 1000 typical events with a hundred distinct
+// users.
+func benchEvents(t testing.TB) []map[string]any {
+	events := make([]map[string]any, 1000)
+	for i := range events {
+		events[i] = event(t, fmt.Sprintf(`{"json":{"level":"info","message":"request completed","duration":%d,"http":{"method":"GET","path":"/v1/orders","status":200},"user":{"id":"u%03d"}}}`, i, i%100))
+	}
+	return events
+}
+
+// TestObserveAllocs The allocation budget of the field analysis, which
+// runs on every fetched event.
+func TestObserveAllocs(t *testing.T) {
+	events := benchEvents(t)
+	c := New()
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, e := range events {
+			c.Observe(e, now)
+		}
+	})
+	if perEvent := allocs / float64(len(events)); perEvent > 20 {
+		t.Errorf("expected at most 20 allocations per event, got %.1f", perEvent)
+	}
+}
+
+// BenchmarkObserve Memory of the field analysis of a page of events.
+func BenchmarkObserve(b *testing.B) {
+	events := benchEvents(b)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		c := New()
+		for _, e := range events {
+			c.Observe(e, now)
+		}
+	}
+}
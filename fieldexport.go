@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportTopValues Number of the most frequent values exported per field.
+const exportTopValues = 5
+
+// fieldSummary One row of the exported field analysis.
+type fieldSummary struct {
+	Field     string       `json:"field"`
+	Count     int          `json:"count"`
+	Distinct  int          `json:"distinct"`
+	TopValues []valueCount `json:"top_values"`
+}
+
+// summarizeFields Turn the field analysis of the TUI into rows sorted by
+// the field name, with the top most frequent values of every field.
+func summarizeFields(allFields map[string]int, fieldValues map[string]map[string]int, top int) []fieldSummary {
+	summaries := make([]fieldSummary, 0, len(fieldValues))
+	for field, values := range fieldValues {
+		summaries = append(summaries, fieldSummary{
+			Field:     field,
+			Count:     allFields[field],
+			Distinct:  len(values),
+			TopValues: topValues(values, top),
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Field < summaries[j].Field
+	})
+
+	return summaries
+}
+
+func writeFieldsCSV(w io.Writer, summaries []fieldSummary) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"field", "count", "distinct", "top_values"}); err != nil {
+		return err
+	}
+
+	for _, s := range summaries {
+		var top []string
+		for _, v := range s.TopValues {
+			top = append(top, fmt.Sprintf("%s (%d)", v.Value, v.Count))
+		}
+
+		err := cw.Write([]string{
+			s.Field,
+			strconv.Itoa(s.Count),
+			strconv.Itoa(s.Distinct),
+			strings.Join(top, "; "),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeFieldsJSON(w io.Writer, summaries []fieldSummary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(summaries)
+}
+
+// exportFields Write the field analysis to a timestamped file in the
+// working directory, format is "csv" or "json". Returns the file name.
+func exportFields(format string, summaries []fieldSummary, now time.Time) (string, error) {
+	name := filepath.Join(".", fmt.Sprintf("loggly-fields-%s.%s", now.Format("20060102-150405"), format))
+
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+
+	if format == "json" {
+		err = writeFieldsJSON(f, summaries)
+	} else {
+		err = writeFieldsCSV(f, summaries)
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return name, err
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/fieldcache"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/Ajnasz/go-loggly-cli/querylint"
+)
+
+// fieldCacheDir The directory of the field caches, a file per account.
+const fieldCacheDir = "fields"
+
+func fieldCachePath(account string) (string, error) {
+	return platform.CacheFile(fieldCacheDir + "/" + url.PathEscape(account) + ".json")
+}
+
+// fieldCacheOn Whether the fields of the account are cached: the events
+// of a single account searched in Loggly.
+func fieldCacheOn(config Config) bool {
+	return !config.NoFieldCache && config.Input == "" && len(splitList(config.Account)) == 1
+}
+
+// loadFieldCache The field cache of the account, or of every account
+// when none is given. Missing and broken caches are empty.
+func loadFieldCache(account string) *fieldcache.Cache {
+	if account != "" {
+		path, err := fieldCachePath(account)
+		if err != nil {
+			return fieldcache.New()
+		}
+		c, err := fieldcache.Load(path)
+		if err != nil {
+			return fieldcache.New()
+		}
+		return c
+	}
+
+	merged := fieldcache.New()
+	dir, err := platform.CacheFile(fieldCacheDir)
+	if err != nil {
+		return merged
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if c, err := fieldcache.Load(filepath.Join(dir, entry.Name())); err == nil {
+			merged.Merge(c)
+		}
+	}
+	return merged
+}
+
+// fieldRecorder Collects the fields of the fetched events into the
+// field cache of the account, nil when the cache is off.
+type fieldRecorder struct {
+	path     string
+	observed *fieldcache.Cache
+}
+
+func newFieldRecorder(config Config) *fieldRecorder {
+	if !fieldCacheOn(config) {
+		return nil
+	}
+	path, err := fieldCachePath(config.Account)
+	if err != nil {
+		return nil
+	}
+	return &fieldRecorder{path: path, observed: fieldcache.New()}
+}
+
+// Add Observe the Loggly events, before their messages are decoded.
+func (r *fieldRecorder) Add(events []any) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, event := range events {
+		if m, ok := lookupPath(event, "event"); ok {
+			if m, ok := m.(map[string]any); ok {
+				r.observed.Observe(m, now)
+			}
+		}
+	}
+}
+
+// AddRaw Observe the undecoded events of a raw query. Only their event
+// objects are decoded for it, the events are printed as they came.
+func (r *fieldRecorder) AddRaw(events []json.RawMessage) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, raw := range events {
+		var e struct {
+			Event map[string]any `json:"event"`
+		}
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		if err := d.Decode(&e); err == nil && e.Event != nil {
+			r.observed.Observe(e.Event, now)
+		}
+	}
+}
+
+// save Add the observed fields to the cache file.
+func (r *fieldRecorder) save() error {
+	if r == nil || len(r.observed.Fields) == 0 {
+		return nil
+	}
+
+	err := fieldcache.Update(r.path, r.observed, time.Now())
+	r.observed = fieldcache.New()
+	return err
+}
+
+// Save Add the observed fields to the cache file. The cache is a
+// convenience, the failures only warn.
+func (r *fieldRecorder) Save(config Config) {
+	if err := r.save(); err != nil {
+		config.warnf("The field cache could not be updated: %s", err)
+	}
+}
+
+// fieldHints The did you mean suggestions of the fields of the query
+// which were never seen, but are close to a seen one. Only the dotted
+// fields are checked, tag:, logtype: and the like are not in the events.
+func fieldHints(cache *fieldcache.Cache, query string) []string {
+	var hints []string
+	seen := make(map[string]bool)
+	for _, t := range querylint.Tokenize(query) {
+		if !strings.Contains(t.Field, ".") || seen[t.Field] || cache.Has(t.Field) {
+			continue
+		}
+		seen[t.Field] = true
+
+		if suggestion, ok := cache.Suggest(t.Field); ok {
+			hints = append(hints, locale.Sprintf("Unknown field %s, did you mean %s?", t.Field, suggestion))
+		}
+	}
+
+	return hints
+}
+
+// warnUnknownFields Print the did you mean suggestions of the fields of
+// the query.
+func warnUnknownFields(config Config, query string) {
+	if !fieldCacheOn(config) {
+		return
+	}
+
+	for _, hint := range fieldHints(loadFieldCache(config.Account), query) {
+		config.warnf("%s", hint)
+	}
+}
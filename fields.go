@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// lookupField Resolve a dotted field path of a printed event. Loggly
+// style json.* paths work on the parsed messages, where the json prefix
+// is implicit, and on the whole events printed with -all as well.
+func lookupField(event any, path string) (any, bool) {
+	// the keys of the events printed with -flatten are whole paths
+	if m, ok := event.(map[string]any); ok {
+		if v, ok := m[path]; ok {
+			return v, true
+		}
+	}
+
+	if v, ok := lookupPath(event, path); ok {
+		return v, true
+	}
+
+	rest, ok := strings.CutPrefix(path, "json.")
+	if !ok {
+		return nil, false
+	}
+
+	if v, ok := lookupPath(event, rest); ok {
+		return v, true
+	}
+
+	return lookupPath(event, "event."+path)
+}
+
+func lookupPath(v any, path string) (any, bool) {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// numericValue Value of the field as a number, numeric strings included.
+func numericValue(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
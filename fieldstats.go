@@ -0,0 +1,137 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/numstats"
+)
+
+// fieldStatsGroup The values of the field in the events with the same
+// value of the -by field.
+type fieldStatsGroup struct {
+	by      string
+	summary numstats.Summary
+}
+
+// fieldStats Collects the numeric values of the field, per value of the
+// by field when it is given.
+type fieldStats struct {
+	field   string
+	by      string
+	values  map[string][]float64
+	skipped int
+}
+
+func newFieldStats(field, by string) *fieldStats {
+	return &fieldStats{field: field, by: by, values: make(map[string][]float64)}
+}
+
+func (s *fieldStats) Add(event any) {
+	v, ok := lookupField(event, s.field)
+	if !ok {
+		s.skipped++
+		return
+	}
+	f, ok := numericValue(v)
+	if !ok {
+		s.skipped++
+		return
+	}
+
+	group := ""
+	if s.by != "" {
+		group = pivotValue(event, s.by)
+	}
+	s.values[group] = append(s.values[group], f)
+}
+
+// Groups The statistics of the groups, the largest group first.
+func (s *fieldStats) Groups() []fieldStatsGroup {
+	groups := make([]fieldStatsGroup, 0, len(s.values))
+	for _, by := range slices.Sorted(maps.Keys(s.values)) {
+		groups = append(groups, fieldStatsGroup{by: by, summary: numstats.Summarize(s.values[by])})
+	}
+	slices.SortStableFunc(groups, func(a, b fieldStatsGroup) int {
+		return cmp.Compare(b.summary.Count, a.summary.Count)
+	})
+	return groups
+}
+
+// formatStat Print the statistic rounded to 6 significant digits, like
+// the value histogram.
+func formatStat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// Print Print a row of statistics per group.
+func (s *fieldStats) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	columns := []string{"COUNT", "MIN", "MAX", "MEAN", "MEDIAN", "P95", "P99"}
+	if s.by != "" {
+		columns = append([]string{s.by}, columns...)
+	}
+	fmt.Fprintf(tw, "%s\t\n", strings.Join(columns, "\t"))
+
+	for _, g := range s.Groups() {
+		row := []string{
+			locale.Int(g.summary.Count),
+			formatStat(g.summary.Min),
+			formatStat(g.summary.Max),
+			formatStat(g.summary.Mean),
+			formatStat(g.summary.Median),
+			formatStat(g.summary.P95),
+			formatStat(g.summary.P99),
+		}
+		if s.by != "" {
+			row = append([]string{g.by}, row...)
+		}
+		fmt.Fprintf(tw, "%s\t\n", strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// runFieldStats Print the min, max, mean, median, p95 and p99 of the
+// numeric -field of the fetched events, per value of the -by field when
+// given, computed on the client.
+func runFieldStats(args []string) {
+	var config Config
+	flags := newFlagSet("loggly stats", &config)
+	field := flags.String("field", "", "")
+	by := flags.String("by", "", "")
+	flags.Parse(args)
+
+	prepareScanConfig(flags, &config)
+
+	if *field == "" {
+		check(usageError(fmt.Errorf("usage: loggly stats -field <field> [-by <field>] [options] [query...]")))
+	}
+
+	query, queryErr := resolveQuery(config, flags.Args())
+	check(queryErr)
+	if query == "" {
+		query = "*"
+	}
+	check(lintQuery(config, query))
+
+	stats := newFieldStats(*field, *by)
+	scanEvents(config, "stats", query, stats.Add)
+
+	if stats.skipped > 0 {
+		config.warnf("%d events without a numeric %s", stats.skipped, *field)
+	}
+	if len(stats.values) == 0 {
+		check(fmt.Errorf("no numeric values of %s", *field))
+	}
+
+	check(stats.Print(os.Stdout))
+}
@@ -0,0 +1,88 @@
+package main
+
+// eventFilters The filters and the rewrites of the options, applied to
+// the decoded events in order: -grep and -grep-v, -where, -jq, the field
+// projection, the time zone and -flatten.
+type eventFilters struct {
+	grep       *grepFilter
+	where      *whereFilter
+	jq         *jqFilter
+	projection *fieldProjection
+	times      *timeRewriter
+	flat       *flattener
+}
+
+func newEventFilters(config Config) (*eventFilters, error) {
+	f := &eventFilters{
+		projection: newFieldProjection(config),
+		flat:       newFlattener(config),
+	}
+
+	var err error
+	if f.grep, err = newGrepFilter(config); err != nil {
+		return nil, err
+	}
+	if f.where, err = newWhereFilter(config); err != nil {
+		return nil, err
+	}
+	if f.jq, err = newJQFilter(config); err != nil {
+		return nil, err
+	}
+	if f.times, err = newTimeRewriter(config); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Match The events kept by -grep, -grep-v and -where, rewritten by -jq:
+// the ones the summarizing commands count.
+func (f *eventFilters) Match(events []any) []any {
+	return f.jq.Apply(f.where.Apply(f.grep.Apply(events)))
+}
+
+// Apply The matching events shaped for the output.
+func (f *eventFilters) Apply(events []any) []any {
+	return f.flat.Apply(f.times.Apply(f.projection.Apply(f.Match(events))))
+}
+
+// clientFiltered Whether -grep, -grep-v, -where, -jq or the dedup drop
+// some of the events returned by Loggly.
+func (c Config) clientFiltered() bool {
+	return c.Grep != "" || c.GrepV != "" || c.Where != "" || c.JQ != "" || c.Dedup || c.DedupBy != ""
+}
+
+// eventLimit Keeps the first -max-events of the matching events. The
+// client side filters drop events after Loggly returned them, so with
+// them the events are counted here instead of by the search, which
+// would stop before that many matched.
+type eventLimit struct {
+	max  int64
+	kept int64
+}
+
+// newEventLimit The limit of the -max-events, the search of the config
+// is left unlimited when the client side filters count the events.
+func newEventLimit(config *Config) *eventLimit {
+	l := &eventLimit{max: config.MaxEvents}
+	if config.clientFiltered() {
+		config.MaxEvents = 0
+	}
+	return l
+}
+
+// Apply The events up to the limit.
+func (l *eventLimit) Apply(events []any) []any {
+	if l.max == 0 {
+		return events
+	}
+
+	events = events[:min(int64(len(events)), l.max-l.kept)]
+	l.kept += int64(len(events))
+	return events
+}
+
+// Reached Whether the limit is reached, no more events are needed.
+func (l *eventLimit) Reached() bool {
+	return l.max > 0 && l.kept >= l.max
+}
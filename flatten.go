@@ -0,0 +1,54 @@
+package main
+
+// flattener converts the nested objects of the events printed with
+// -flatten into single level objects keyed by the joined paths.
+type flattener struct {
+	separator string
+}
+
+// newFlattener Returns nil without -flatten.
+func newFlattener(config Config) *flattener {
+	if !config.Flatten {
+		return nil
+	}
+
+	return &flattener{separator: config.FlattenSeparator}
+}
+
+// Apply Flatten the events. Arrays are kept as values, events which are
+// not objects are left as they are.
+func (f *flattener) Apply(events []any) []any {
+	if f == nil {
+		return events
+	}
+
+	ret := make([]any, len(events))
+	for i, event := range events {
+		m, ok := event.(map[string]any)
+		if !ok {
+			ret[i] = event
+			continue
+		}
+
+		flat := make(map[string]any)
+		f.flatten(flat, "", m)
+		ret[i] = flat
+	}
+
+	return ret
+}
+
+func (f *flattener) flatten(flat map[string]any, prefix string, m map[string]any) {
+	for k, v := range m {
+		if prefix != "" {
+			k = prefix + f.separator + k
+		}
+
+		// empty objects are kept, so the field does not disappear
+		if child, ok := v.(map[string]any); ok && len(child) > 0 {
+			f.flatten(flat, k, child)
+			continue
+		}
+		flat[k] = v
+	}
+}
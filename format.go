@@ -0,0 +1,760 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/prettyjson"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// Output formats of the events.
+const (
+	formatNDJSON    = "ndjson"
+	formatJSONArray = "json-array"
+	formatTable     = "table"
+	formatCSV       = "csv"
+	formatTSV       = "tsv"
+	formatLogfmt    = "logfmt"
+	formatTemplate  = "template"
+	formatParquet   = "parquet"
+	formatESBulk    = "es-bulk"
+	formatOTLP      = "otlp"
+)
+
+var formats = []string{formatNDJSON, formatJSONArray, formatTable, formatCSV, formatTSV, formatLogfmt, formatTemplate, formatParquet, formatESBulk, formatOTLP}
+
+// rawFormats Formats printing the undecoded events of a raw query.
+var rawFormats = []string{formatNDJSON, formatJSONArray, formatESBulk}
+
+// columnFormats Formats printing the fields selected with -columns.
+var columnFormats = []string{formatCSV, formatTSV, formatLogfmt, formatParquet}
+
+// headerFormats Formats starting with a line of column names, repeated
+// in every part of -rotate-size.
+var headerFormats = []string{formatTable, formatCSV, formatTSV}
+
+// resolveFormat The -format if set, otherwise the format.tty or
+// format.pipe of the configuration file, depending on whether the
+// events are printed to a terminal.
+func resolveFormat(config Config, file configfile.File) string {
+	if config.Format != "" {
+		return config.Format
+	}
+
+	key := "format.pipe"
+	if (config.Output == "" || config.Tee) && isTerminal(os.Stdout) {
+		key = "format.tty"
+	}
+
+	if format := file[key]; format != "" {
+		return format
+	}
+
+	return formatNDJSON
+}
+
+// resolveFileFormat The format of the -o file next to -tee, when the
+// -format is for the terminal: the format.pipe of the configuration file,
+// otherwise NDJSON.
+func resolveFileFormat(config Config, file configfile.File) string {
+	if !config.Tee {
+		return ""
+	}
+	if format := file["format.pipe"]; format != "" {
+		return format
+	}
+
+	return formatNDJSON
+}
+
+// teeFileFormats Formats needing options of their own, which the file of
+// -tee can not be written in.
+var teeFileFormats = []string{formatTemplate, formatESBulk, formatParquet}
+
+func validateFormat(format string) error {
+	if !slices.Contains(formats, format) {
+		return fmt.Errorf("invalid format %q, use one of %s", format, strings.Join(formats, ", "))
+	}
+	return nil
+}
+
+// eventFormatter writes the events in an output format.
+type eventFormatter interface {
+	Format(w io.Writer, events []any) error
+}
+
+// rawFormatter An eventFormatter which prints the undecoded events of a
+// raw query in its own way instead of as NDJSON.
+type rawFormatter interface {
+	FormatRaw(w io.Writer, events []json.RawMessage) error
+}
+
+// finishingFormatter An eventFormatter which has to write an ending
+// once every event is written.
+type finishingFormatter interface {
+	Finish(w io.Writer) error
+}
+
+// newFormatter The formatter of the -format, the query is needed for
+// the -highlight of its terms.
+func newFormatter(config Config, query string) eventFormatter {
+	switch config.Format {
+	case formatTable:
+		return &tableFormatter{headers: make(map[io.Writer]bool), color: config.Color, styler: newLineStyler(config, query)}
+	case formatCSV:
+		return newColumnFormatter(config, writeCSVRow, true)
+	case formatTSV:
+		return newColumnFormatter(config, writeTSVRow, true)
+	case formatLogfmt:
+		f := newColumnFormatter(config, writeLogfmtRow, false)
+		f.styled, f.color, f.styler = true, config.Color, newLineStyler(config, query)
+		return f
+	case formatJSONArray:
+		return &jsonArrayFormatter{opened: make(map[io.Writer]bool)}
+	case formatESBulk:
+		return esBulkFormatter{index: config.Index}
+	case formatOTLP:
+		return otlpFormatter{levelFields: splitList(config.LevelField)}
+	case formatParquet:
+		return &parquetFormatter{columns: splitList(config.Columns), events: make(map[io.Writer][]any)}
+	case formatTemplate:
+		// the template is checked by Validate
+		return templateFormatter{tmpl: template.Must(newTemplate(config.Template)), color: config.Color, styler: newLineStyler(config, query)}
+	default:
+		return ndjsonFormatter{pretty: config.Pretty, color: config.Color, styler: newLineStyler(config, query)}
+	}
+}
+
+// Values of -color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+var colorModes = []string{colorAuto, colorAlways, colorNever}
+
+// useColor Whether to color the output written to w. In auto mode only
+// terminals get colors, unless $NO_COLOR is set.
+func useColor(mode string, w io.Writer) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorAuto, "":
+		f, ok := w.(*os.File)
+		if p, paged := w.(*terminalPager); paged {
+			f, ok = p.out, true
+		}
+		if p, paged := w.(*externalPager); paged {
+			f, ok = p.out, true
+		}
+		return ok && isTerminal(f) && os.Getenv("NO_COLOR") == ""
+	default:
+		return false
+	}
+}
+
+// ndjsonFormatter prints the events as JSON lines. On terminals the
+// events are highlighted, the events matching a color rule and the
+// error, warning and debug events are printed in their style instead.
+type ndjsonFormatter struct {
+	pretty bool
+	color  string
+	styler lineStyler
+}
+
+func (f ndjsonFormatter) Format(w io.Writer, events []any) error {
+	opts := prettyjson.Options{Color: useColor(f.color, w)}
+	if f.pretty {
+		opts.Indent = "  "
+	}
+
+	if opts == (prettyjson.Options{}) {
+		return printJSON(w, events)
+	}
+
+	for _, event := range events {
+		if text, ok := event.(textMessage); ok {
+			if _, err := fmt.Fprintln(w, string(text)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// the rules match the line without the highlighting
+		plain := opts
+		plain.Color = false
+		data, err := prettyjson.Marshal(event, plain)
+		if err != nil {
+			return err
+		}
+
+		line := string(data)
+		if opts.Color {
+			s := f.styler.style(event, line)
+			if s == nil {
+				if data, err = prettyjson.Marshal(event, opts); err != nil {
+					return err
+				}
+			}
+			line = f.styler.highlight(colorLine(string(data), s))
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tableFormatter prints the time, level and message of the events in
+// aligned columns followed by the rest of the fields. On terminals the
+// rows are printed in the style of the matching color rule or of the
+// level of the event.
+type tableFormatter struct {
+	headers map[io.Writer]bool
+	color   string
+	styler  lineStyler
+}
+
+func (f *tableFormatter) Format(w io.Writer, events []any) error {
+	colored := useColor(f.color, w)
+
+	// the highlighting would break the alignment of the columns, the
+	// rows are highlighted once aligned
+	out := w
+	var aligned bytes.Buffer
+	highlighted := colored && f.styler.highlights != nil
+	if highlighted {
+		out = &aligned
+	}
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	// every row starts with a fixed width style, the empty one included,
+	// so the escape sequences widen the first column equally
+	printRow := func(s style, row string) {
+		if colored {
+			row = s.fixedWidth() + row + styleReset
+		}
+		fmt.Fprintln(tw, row)
+	}
+
+	header := !f.headers[w]
+	if header {
+		f.headers[w] = true
+		printRow(nil, "TIME\tLEVEL\tMESSAGE\tFIELDS")
+	}
+
+	for _, event := range events {
+		ts, level, msg, rest := summarizeEvent(event)
+		row := fmt.Sprintf("%s\t%s\t%s\t%s", ts, level, msg, formatFields(rest))
+
+		var s style
+		if colored {
+			s = f.styler.style(event, row)
+		}
+		printRow(s, row)
+	}
+
+	if err := tw.Flush(); err != nil || !highlighted {
+		return err
+	}
+
+	for i, row := range strings.SplitAfter(aligned.String(), "\n") {
+		if i > 0 || !header {
+			row = f.styler.highlight(row)
+		}
+		if _, err := io.WriteString(w, row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// templateFuncs Functions available in the -template besides the
+// builtin ones.
+var templateFuncs = template.FuncMap{
+	// default The value, or d if the field is missing or null:
+	// {{.json.user | default "-"}}
+	"default": func(d string, v any) any {
+		if v == nil {
+			return d
+		}
+		return v
+	},
+	// json The value as compact JSON.
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func newTemplate(text string) (*template.Template, error) {
+	return template.New("event").Funcs(templateFuncs).Parse(text)
+}
+
+// templateFormatter prints every event as a line rendered by a Go
+// text/template, the event is the dot of the template. On terminals the
+// lines are printed in the style of the matching color rule or of the
+// level of the event.
+type templateFormatter struct {
+	tmpl   *template.Template
+	color  string
+	styler lineStyler
+}
+
+func (f templateFormatter) Format(w io.Writer, events []any) error {
+	colored := useColor(f.color, w)
+
+	var sb strings.Builder
+	for _, event := range events {
+		sb.Reset()
+		if err := f.tmpl.Execute(&sb, event); err != nil {
+			return err
+		}
+
+		line := sb.String()
+		if colored {
+			line = f.styler.highlight(colorLine(line, f.styler.style(event, line)))
+		}
+
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jsonArrayFormatter prints the events as the elements of a single JSON
+// array, an element per line, for the consumers which can not read JSON
+// lines. The array is opened by the first events written to a writer
+// and closed by Finish.
+type jsonArrayFormatter struct {
+	opened map[io.Writer]bool
+}
+
+// element Write the separator before the next element of the array.
+func (f *jsonArrayFormatter) element(w io.Writer, data []byte) error {
+	sep := ",\n"
+	if !f.opened[w] {
+		sep = "[\n"
+		f.opened[w] = true
+	}
+
+	_, err := w.Write(append([]byte(sep), data...))
+	return err
+}
+
+func (f *jsonArrayFormatter) Format(w io.Writer, events []any) error {
+	for _, event := range events {
+		// the messages which are not objects become strings
+		if text, ok := event.(textMessage); ok {
+			event = string(text)
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if err := f.element(w, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FormatRaw Write the undecoded events as elements, compacted like
+// printRaw does.
+func (f *jsonArrayFormatter) FormatRaw(w io.Writer, events []json.RawMessage) error {
+	var buf bytes.Buffer
+	for _, event := range events {
+		buf.Reset()
+		if err := json.Compact(&buf, event); err != nil {
+			return err
+		}
+
+		if err := f.element(w, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Finish Close the array, an empty one when no event was written.
+func (f *jsonArrayFormatter) Finish(w io.Writer) error {
+	end := "\n]\n"
+	if !f.opened[w] {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(w, end)
+	return err
+}
+
+// esBulkFormatter prints the events as the body of an Elasticsearch
+// _bulk request, an index action followed by the event on the next
+// line. The Loggly id of the whole events is the id of the documents,
+// so loading the same events again does not duplicate them.
+type esBulkFormatter struct {
+	index string
+}
+
+// write Write the action and the document at once, so -rotate-size
+// never splits them.
+func (f esBulkFormatter) write(w io.Writer, id string, source []byte) error {
+	meta := map[string]string{"_index": f.index}
+	if id != "" {
+		meta["_id"] = id
+	}
+
+	action, err := json.Marshal(map[string]any{"index": meta})
+	if err != nil {
+		return err
+	}
+
+	line := append(append(action, '\n'), source...)
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+func (f esBulkFormatter) Format(w io.Writer, events []any) error {
+	for _, event := range events {
+		// the documents are objects, the messages which are not JSON
+		// are kept as their raw field
+		if text, ok := event.(textMessage); ok {
+			event = map[string]any{"raw": string(text)}
+		} else if _, ok := event.(map[string]any); !ok {
+			event = map[string]any{"raw": event}
+		}
+
+		var id string
+		if v, ok := lookupField(event, "id"); ok {
+			id, _ = v.(string)
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if err := f.write(w, id, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FormatRaw Write the undecoded whole events as documents, compacted.
+func (f esBulkFormatter) FormatRaw(w io.Writer, events []json.RawMessage) error {
+	var buf bytes.Buffer
+	for _, event := range events {
+		var envelope struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(event, &envelope); err != nil {
+			return err
+		}
+
+		buf.Reset()
+		if err := json.Compact(&buf, event); err != nil {
+			return err
+		}
+
+		if err := f.write(w, envelope.ID, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateIndexName Check the -index against the rules of Elasticsearch,
+// so the bulk request does not fail on every action.
+func validateIndexName(index string) error {
+	switch {
+	case index == "":
+		return fmt.Errorf("format es-bulk requires -index")
+	case index == "." || index == "..":
+		return fmt.Errorf("invalid index %q", index)
+	case len(index) > 255:
+		return fmt.Errorf("invalid index %q: longer than 255 bytes", index)
+	case strings.ToLower(index) != index:
+		return fmt.Errorf("invalid index %q: it must be lowercase", index)
+	case strings.ContainsAny(index, "\\/*?\"<>| ,#:"):
+		return fmt.Errorf("invalid index %q: it can not contain \\ / * ? \" < > | space , # :", index)
+	case strings.ContainsAny(index[:1], "-_+"):
+		return fmt.Errorf("invalid index %q: it can not start with -, _ or +", index)
+	}
+
+	return nil
+}
+
+// rowWriter Writes a row of values, nil for the missing fields. The
+// header row is written with the column names as values.
+type rowWriter func(w io.Writer, columns []string, values []any) error
+
+// columnFormatter prints the fields of the events selected with
+// -columns as rows. Without selected columns the formats with a header
+// use the fields of the first printed event, the others the fields of
+// every event.
+type columnFormatter struct {
+	columns  []string
+	header   bool
+	headers  map[io.Writer]bool
+	writeRow rowWriter
+	// styled Style the rows on terminals, set for logfmt only, the
+	// others are machine readable.
+	styled bool
+	color  string
+	styler lineStyler
+}
+
+func newColumnFormatter(config Config, writeRow rowWriter, header bool) *columnFormatter {
+	return &columnFormatter{
+		columns:  splitList(config.Columns),
+		header:   header,
+		headers:  make(map[io.Writer]bool),
+		writeRow: writeRow,
+	}
+}
+
+func (f *columnFormatter) Format(w io.Writer, events []any) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if len(f.columns) == 0 && f.header {
+		f.columns = flattenedKeys(events[0])
+	}
+
+	if f.header && !f.headers[w] {
+		f.headers[w] = true
+		names := make([]any, len(f.columns))
+		for i, column := range f.columns {
+			names[i] = column
+		}
+		if err := f.writeRow(w, f.columns, names); err != nil {
+			return err
+		}
+	}
+
+	colored := f.styled && useColor(f.color, w)
+
+	for _, event := range events {
+		columns := f.columns
+		if len(columns) == 0 {
+			columns = flattenedKeys(event)
+		}
+
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			if v, ok := lookupField(event, column); ok {
+				values[i] = v
+			}
+		}
+
+		if !colored {
+			if err := f.writeRow(w, columns, values); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var row bytes.Buffer
+		if err := f.writeRow(&row, columns, values); err != nil {
+			return err
+		}
+		line := strings.TrimSuffix(row.String(), "\n")
+		line = f.styler.highlight(colorLine(line, f.styler.style(event, line)))
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCSVRow Write an RFC 4180 CSV record.
+func writeCSVRow(w io.Writer, columns []string, values []any) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(rowStrings(values)); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// tsvEscaper Escapes the characters separating the fields and the rows.
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+// writeTSVRow Write tab separated values, escaping the tabs and the new
+// lines of the values with backslashes.
+func writeTSVRow(w io.Writer, columns []string, values []any) error {
+	row := rowStrings(values)
+	for i, v := range row {
+		row[i] = tsvEscaper.Replace(v)
+	}
+	_, err := fmt.Fprintln(w, strings.Join(row, "\t"))
+	return err
+}
+
+// writeLogfmtRow Write key=value pairs, quoting the values when needed.
+// Missing fields are left out.
+func writeLogfmtRow(w io.Writer, columns []string, values []any) error {
+	var pairs []string
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+
+		value := formatValue(v)
+		if value == "" || strings.ContainsAny(value, " =\"\t\n\r\\") {
+			value = strconv.Quote(value)
+		}
+		pairs = append(pairs, columns[i]+"="+value)
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(pairs, " "))
+	return err
+}
+
+// rowStrings The values as strings, the missing ones and null as empty
+// strings.
+func rowStrings(values []any) []string {
+	row := make([]string, len(values))
+	for i, v := range values {
+		if v != nil {
+			row[i] = formatValue(v)
+		}
+	}
+	return row
+}
+
+// flattenedKeys The sorted dotted paths of the scalar and array values
+// of the event.
+func flattenedKeys(event any) []string {
+	var keys []string
+
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		m, ok := v.(map[string]any)
+		if !ok || (len(m) == 0 && prefix != "") {
+			keys = append(keys, prefix)
+			return
+		}
+		for k, child := range m {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			walk(k, child)
+		}
+	}
+	walk("", event)
+
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	timeKeys    = []string{"timestamp", "@timestamp", "time"}
+	levelKeys   = []string{"level", "severity"}
+	messageKeys = []string{"message", "msg", "logmsg"}
+)
+
+// summarizeEvent Pick the time, the level and the message of a parsed
+// message or of a whole event printed with -all.
+func summarizeEvent(event any) (ts, level, msg string, rest map[string]any) {
+	m, ok := event.(map[string]any)
+	if !ok {
+		return "", "", fmt.Sprint(event), nil
+	}
+
+	rest = make(map[string]any, len(m))
+	for k, v := range m {
+		rest[k] = v
+	}
+
+	take := func(keys []string) any {
+		for _, k := range keys {
+			if v, ok := rest[k]; ok {
+				delete(rest, k)
+				return v
+			}
+		}
+		return nil
+	}
+
+	if t, ok := search.EventTimestamp(m); ok {
+		delete(rest, "timestamp")
+		ts = locale.Time(t)
+	} else if v := take(timeKeys); v != nil {
+		ts = fmt.Sprint(v)
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			ts = locale.Time(t)
+		}
+	}
+
+	if v := take(levelKeys); v != nil {
+		level = fmt.Sprint(v)
+	}
+
+	if v := take(messageKeys); v != nil {
+		msg = fmt.Sprint(v)
+	}
+
+	return ts, level, msg, rest
+}
+
+// formatFields Print the fields as sorted key=value pairs, nested values
+// as compact JSON.
+func formatFields(fields map[string]any) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, k+"="+formatValue(fields[k]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// formatValue Print strings as they are, numbers without an exponent,
+// nested values as compact JSON.
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestFormatNumbers(t *testing.T) {
+	events := []any{map[string]any{
+		"duration": float64(1234567),
+		"ts":       float64(1700000000123),
+		"ratio":    0.25,
+		"id":       json.Number("9007199254740993"),
+	}}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{formatCSV, "duration,id,ratio,ts\n1234567,9007199254740993,0.25,1700000000123\n"},
+		{formatTSV, "duration\tid\tratio\tts\n1234567\t9007199254740993\t0.25\t1700000000123\n"},
+		{formatLogfmt, "duration=1234567 id=9007199254740993 ratio=0.25 ts=1700000000123\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := newFormatter(Config{Format: tt.format}, "").Format(&buf, events); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.format, tt.want, got)
+		}
+	}
+}
+
+func TestFormatFieldsNumbers(t *testing.T) {
+	fields := map[string]any{"duration": float64(1234567), "status": float64(200), "id": float64(1700000000123)}
+
+	want := "duration=1234567 id=1700000000123 status=200"
+	if got := formatFields(fields); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTemplateMessageNumbers(t *testing.T) {
+	events, err := parseLogMSG([]any{map[string]any{"logmsg": `{"timestamp":1700000000123,"ratio":0.5}`}}, []string{"logmsg"}, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	f := newFormatter(Config{Format: formatTemplate, Template: "{{.timestamp}} {{.ratio}}"}, "")
+	if err := f.Format(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1700000000123 0.5\n"; buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+
+	if _, err := decodeMessage(`{"a":1} trailing`); err == nil {
+		t.Error("expected an error for the data after the object")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// grepFilter keeps the events whose JSON matches the -grep expression
+// and does not match the -grep-v one.
+type grepFilter struct {
+	match  *regexp.Regexp
+	reject *regexp.Regexp
+}
+
+// newGrepFilter Compile the -grep and -grep-v expressions, returns nil
+// when neither is set.
+func newGrepFilter(config Config) (*grepFilter, error) {
+	if config.Grep == "" && config.GrepV == "" {
+		return nil, nil
+	}
+
+	f := &grepFilter{}
+	var err error
+	if config.Grep != "" {
+		if f.match, err = regexp.Compile(config.Grep); err != nil {
+			return nil, err
+		}
+	}
+	if config.GrepV != "" {
+		if f.reject, err = regexp.Compile(config.GrepV); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Apply Return the matching events. The expressions match the compact
+// JSON of the events, or the text of the messages kept with -raw.
+func (f *grepFilter) Apply(events []any) []any {
+	if f == nil {
+		return events
+	}
+
+	var ret []any
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, event := range events {
+		var line []byte
+		if text, ok := event.(textMessage); ok {
+			line = []byte(text)
+		} else {
+			buf.Reset()
+			if err := enc.Encode(event); err != nil {
+				continue
+			}
+			line = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		}
+
+		if f.match != nil && !f.match.Match(line) {
+			continue
+		}
+		if f.reject != nil && f.reject.Match(line) {
+			continue
+		}
+		ret = append(ret, event)
+	}
+
+	return ret
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/snapshot"
+)
+
+// printGroupCounts Print the number of the events per distinct values
+// of the fields, counted over the fetched events, the largest group
+// first, with its share of the counted events.
+func printGroupCounts(w io.Writer, groups []snapshot.Group, fields []string) error {
+	total := 0
+	for _, g := range groups {
+		total += g.Count
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCOUNT\tSHARE\n", strings.Join(fields, "\t"))
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%s\t%.1f%%\n", strings.Join(g.Values, "\t"), locale.Int(g.Count), 100*float64(g.Count)/float64(total))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,289 @@
+// Package help holds the help topics and the examples of the CLI, the
+// single source of the query syntax part of the usage, of loggly help,
+// loggly examples and of the help of the TUI.
+package help
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Section A part of a topic: a title, a short explanation and the
+// examples of the syntax.
+type Section struct {
+	Title    string
+	Text     string
+	Examples []string
+}
+
+// Topic A help topic, printed by loggly help <name>.
+type Topic struct {
+	Name    string
+	Title   string
+	Summary string
+	// Usage The sections are part of the usage too.
+	Usage    bool
+	Sections []Section
+}
+
+// Example A command line doing a task, printed by loggly examples.
+type Example struct {
+	Title   string
+	Command string
+	Tags    []string
+}
+
+// Topics The help topics.
+var Topics = []Topic{
+	{
+		Name:    "query-syntax",
+		Title:   "Query syntax",
+		Summary: "the operators, fields, grouping and regexps of the search queries",
+		Usage:   true,
+		Sections: []Section{
+			{
+				Title: "Operators",
+				Text:  "Terms are joined with AND unless OR is given. NOT, - and + exclude and require terms. Square brackets match numeric ranges, * leaves one end open.",
+				Examples: []string{
+					`"foo bar" AND baz`,
+					`foo AND bar NOT baz`,
+					`+foo +bar -baz`,
+					`foo OR bar`,
+					`json.responseTime[50 TO 100]`,
+					`json.duration[1000 TO *]`,
+				},
+			},
+			{
+				Title: "Fields",
+				Text:  "field:value matches a field of the parsed JSON events, quote values with spaces, * matches any characters.",
+				Examples: []string{
+					`json.level:error`,
+					`json.type:"upload failed"`,
+					`json.hostname:"api-*"`,
+				},
+			},
+			{
+				Title: "Grouping",
+				Text:  "Parentheses group the terms.",
+				Examples: []string{
+					`foo AND (bar OR baz)`,
+				},
+			},
+			{
+				Title: "Regexps",
+				Text:  "Terms between slashes are regular expressions.",
+				Examples: []string{
+					`/Black(Berry)?/`,
+				},
+			},
+		},
+	},
+	{
+		Name:    "time-ranges",
+		Title:   "Time ranges",
+		Summary: "the values of -from and -until",
+		Sections: []Section{
+			{
+				Title: "Relative",
+				Text:  "now, or an offset before now in seconds, minutes, hours, days or weeks.",
+				Examples: []string{
+					`-from -30m`,
+					`-from -24h -until -1h`,
+					`-from -7d`,
+					`-from -2w`,
+				},
+			},
+			{
+				Title: "Absolute",
+				Text:  "An ISO 8601 timestamp, the ones without a zone are in the local time zone, or a unix epoch in seconds or milliseconds.",
+				Examples: []string{
+					`-from 2024-01-02T15:04:05Z`,
+					`-from "2024-01-02 15:04" -until 2024-01-02T16:00`,
+					`-from 2024-01-02`,
+					`-from 1704207845`,
+				},
+			},
+			{
+				Title: "Natural language",
+				Text:  "N units ago, last unit, a day with an optional time, or the most recent weekday before today. A day without a time means its start.",
+				Examples: []string{
+					`-from "2 days ago"`,
+					`-from "last hour"`,
+					`-from "yesterday 14:00" -until "yesterday 15:30"`,
+					`-from "last monday at 9am"`,
+					`-from "friday 17:30"`,
+				},
+			},
+		},
+	},
+}
+
+// Examples The examples of loggly examples.
+var Examples = []Example{
+	{"Errors of the last hour", `loggly search -from -1h json.level:error`, []string{"search", "time"}},
+	{"Count the matching events", `loggly search -count -from -24h 'json.http.status:[500 TO *]'`, []string{"search", "count"}},
+	{"Count the errors per service", `loggly search -count -group-by json.service -from -1h json.level:error`, []string{"search", "count", "group"}},
+	{"Explore the events interactively", `loggly tui -from -2h json.service:billing`, []string{"tui"}},
+	{"Print a table of some fields", `loggly search -format table -columns json.level,json.message json.service:api`, []string{"search", "format"}},
+	{"Keep the fields of the messages only", `loggly search -fields json.user,json.http.status json.level:error`, []string{"search", "fields"}},
+	{"Filter the events locally", `loggly search -where 'json.duration > 1000' json.service:api`, []string{"search", "filter"}},
+	{"Export everything of yesterday", `loggly export -from yesterday -until today -o yesterday.ndjson.gz '*'`, []string{"export", "time"}},
+	{"Load the events into SQLite", `loggly export sqlite -db events.db -columns json.level,json.service -from -1d '*'`, []string{"export", "sqlite"}},
+	{"Compare the errors before and after a fix", `loggly snapshot save -by json.message -from -1h before-fix json.level:error`, []string{"snapshot", "diff"}},
+	{"Gate a deploy on golden queries", `loggly assert -from -1h rules.yaml`, []string{"assert", "ci"}},
+	{"Follow the new errors", `loggly tail -interval 10s json.level:error`, []string{"tail", "follow"}},
+	{"Latency percentiles per endpoint", `loggly stats -field json.responseTime -by json.http.path -from -1h`, []string{"stats", "latency", "percentile"}},
+	{"The hosts logging the most errors", `loggly top -field json.hostname -n 20 json.level:error`, []string{"top", "frequent", "values"}},
+	{"Report the fields of the events as JSON", `loggly analyze -json -from -1h json.level:error`, []string{"analyze", "fields", "report"}},
+	{"The users hitting errors, one per line", `loggly distinct -field json.userId -from -1h json.level:error`, []string{"distinct", "unique", "values"}},
+	{"Run a saved search by name", `loggly saved run payment-errors -count`, []string{"saved", "search", "share"}},
+	{"Run a query of the history again", `loggly rerun 128`, []string{"history", "rerun", "recall"}},
+	{"Count the errors per 5 minutes", `loggly histogram -interval 5m -from -6h json.level:error`, []string{"histogram", "spike", "count"}},
+	{"Watch the error rates during a deploy", `loggly monitor -interval 30s -q json.level:error json.level:warn`, []string{"monitor"}},
+	{"Complete the commands, fields and values in bash", `source <(loggly completion bash)`, []string{"completion", "shell"}},
+	{"Try the CLI without an account", `loggly demo -tui`, []string{"demo", "tui"}},
+}
+
+// Lookup The topic of the name.
+func Lookup(name string) (Topic, bool) {
+	i := slices.IndexFunc(Topics, func(t Topic) bool { return t.Name == name })
+	if i < 0 {
+		return Topic{}, false
+	}
+	return Topics[i], true
+}
+
+// contains Whether the text contains the term, ignoring the case.
+func contains(text, term string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(term))
+}
+
+// Matches Whether the topic mentions the term anywhere.
+func (t Topic) Matches(term string) bool {
+	if contains(t.Name, term) || contains(t.Title, term) || contains(t.Summary, term) {
+		return true
+	}
+	for _, s := range t.Sections {
+		if contains(s.Title, term) || contains(s.Text, term) || slices.ContainsFunc(s.Examples, func(e string) bool { return contains(e, term) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches Whether the example mentions the term in its title, command or
+// tags.
+func (e Example) Matches(term string) bool {
+	return contains(e.Title, term) || contains(e.Command, term) || slices.ContainsFunc(e.Tags, func(tag string) bool { return contains(tag, term) })
+}
+
+// Search The topics and the examples mentioning the term.
+func Search(term string) ([]Topic, []Example) {
+	var topics []Topic
+	for _, t := range Topics {
+		if t.Matches(term) {
+			topics = append(topics, t)
+		}
+	}
+
+	var examples []Example
+	for _, e := range Examples {
+		if e.Matches(term) {
+			examples = append(examples, e)
+		}
+	}
+
+	return topics, examples
+}
+
+// Usage The sections of the topics shown in the usage, their titles and
+// examples indented like the rest of the usage.
+func Usage() string {
+	var b strings.Builder
+	for _, t := range Topics {
+		if !t.Usage {
+			continue
+		}
+		for _, s := range t.Sections {
+			fmt.Fprintf(&b, "\n  %s:\n\n", s.Title)
+			for _, e := range s.Examples {
+				fmt.Fprintf(&b, "    %s\n", e)
+			}
+		}
+	}
+	return b.String()
+}
+
+// Style Decorates the titles of the rendered help, for example in bold
+// on terminals.
+type Style func(string) string
+
+// plain Leaves the titles as they are.
+func plain(s string) string { return s }
+
+// Render Print the topic, the title of each section with its text and
+// examples.
+func (t Topic) Render(w io.Writer, title Style) error {
+	if title == nil {
+		title = plain
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n%s.\n", title(t.Title), capitalize(t.Summary))
+	for _, s := range t.Sections {
+		fmt.Fprintf(&b, "\n  %s\n\n", title(s.Title))
+		if s.Text != "" {
+			fmt.Fprintf(&b, "  %s\n\n", s.Text)
+		}
+		for _, e := range s.Examples {
+			fmt.Fprintf(&b, "    %s\n", e)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RenderExamples Print the examples, the title of each followed by its
+// command.
+func RenderExamples(w io.Writer, examples []Example, title Style) error {
+	if title == nil {
+		title = plain
+	}
+
+	var b strings.Builder
+	for i, e := range examples {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s\n  %s\n", title(e.Title), e.Command)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RenderIndex Print the names and the summaries of the topics.
+func RenderIndex(w io.Writer, topics []Topic) error {
+	width := 0
+	for _, t := range topics {
+		width = max(width, len(t.Name))
+	}
+
+	var b strings.Builder
+	for _, t := range topics {
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, t.Name, t.Summary)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,104 @@
+package help
+
+import (
+	"strings"
+	"testing"
+)
+
+const usageSyntax = `
+  Operators:
+
+    "foo bar" AND baz
+    foo AND bar NOT baz
+    +foo +bar -baz
+    foo OR bar
+    json.responseTime[50 TO 100]
+    json.duration[1000 TO *]
+
+  Fields:
+
+    json.level:error
+    json.type:"upload failed"
+    json.hostname:"api-*"
+
+  Grouping:
+
+    foo AND (bar OR baz)
+
+  Regexps:
+
+    /Black(Berry)?/
+`
+
+func TestUsage(t *testing.T) {
+	if got := Usage(); got != usageSyntax {
+		t.Errorf("expected %q, got %q", usageSyntax, got)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"query-syntax", "time-ranges"} {
+		if topic, ok := Lookup(name); !ok || topic.Name != name {
+			t.Errorf("expected the topic %s, got %v %v", name, topic.Name, ok)
+		}
+	}
+	if _, ok := Lookup("queries"); ok {
+		t.Error("expected no topic named queries")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		term     string
+		topics   []string
+		examples int
+	}{
+		{"REGULAR expressions", []string{"query-syntax"}, 0},
+		{"yesterday", []string{"time-ranges"}, 1},
+		{"sqlite", nil, 1},
+		{"nothing like this", nil, 0},
+	}
+
+	for _, test := range tests {
+		topics, examples := Search(test.term)
+		var names []string
+		for _, t := range topics {
+			names = append(names, t.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(test.topics, ",") || len(examples) != test.examples {
+			t.Errorf("%s: expected %v and %d examples, got %v and %d", test.term, test.topics, test.examples, names, len(examples))
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	topic := Topic{
+		Title:   "Grouping",
+		Summary: "parentheses",
+		Sections: []Section{
+			{Title: "Nested", Text: "Groups can be nested.", Examples: []string{"(a OR (b AND c))"}},
+		},
+	}
+
+	var b strings.Builder
+	if err := topic.Render(&b, func(s string) string { return "*" + s + "*" }); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "*Grouping*\n\nParentheses.\n\n  *Nested*\n\n  Groups can be nested.\n\n    (a OR (b AND c))\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestRenderExamples(t *testing.T) {
+	var b strings.Builder
+	if err := RenderExamples(&b, Examples[:2], nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Errors of the last hour\n  loggly search -from -1h json.level:error\n\nCount the matching events\n  " + Examples[1].Command + "\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
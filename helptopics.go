@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Ajnasz/go-loggly-cli/help"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// helpTitleStyle The titles of the help, bold.
+var helpTitleStyle = style{1}
+
+// helpTitle The style of the help titles on the standard output, none
+// when it is not a terminal or NO_COLOR is set.
+func helpTitle() help.Style {
+	if !useColor(colorAuto, os.Stdout) {
+		return nil
+	}
+	return func(s string) string { return colorLine(s, helpTitleStyle) }
+}
+
+// runHelp Print a help topic, the topics mentioning the words, or the
+// list of the topics.
+func runHelp(args []string) {
+	if len(args) == 0 {
+		fmt.Println(locale.Sprintf("Help topics:"))
+		fmt.Println()
+		check(help.RenderIndex(os.Stdout, help.Topics))
+		fmt.Println()
+		fmt.Println("loggly help <topic> prints a topic, loggly help <words> searches the topics and the")
+		fmt.Println("examples, loggly examples [words] prints the examples, loggly -h the options.")
+		return
+	}
+
+	if topic, ok := help.Lookup(args[0]); ok && len(args) == 1 {
+		check(topic.Render(os.Stdout, helpTitle()))
+		return
+	}
+
+	term := strings.Join(args, " ")
+	topics, examples := help.Search(term)
+	if len(topics) == 0 && len(examples) == 0 {
+		check(usageError(fmt.Errorf("no help topic or example mentions %q, see loggly help", term)))
+	}
+
+	if len(topics) == 1 && len(examples) == 0 {
+		check(topics[0].Render(os.Stdout, helpTitle()))
+		return
+	}
+	if len(topics) > 0 {
+		fmt.Println(locale.Sprintf("Help topics:"))
+		fmt.Println()
+		check(help.RenderIndex(os.Stdout, topics))
+	}
+	if len(examples) > 0 {
+		if len(topics) > 0 {
+			fmt.Println()
+		}
+		check(help.RenderExamples(os.Stdout, examples, helpTitle()))
+	}
+}
+
+// runExamples Print the examples, the ones mentioning the words when
+// some are given.
+func runExamples(args []string) {
+	examples := help.Examples
+	if len(args) > 0 {
+		term := strings.Join(args, " ")
+		_, examples = help.Search(term)
+		if len(examples) == 0 {
+			check(usageError(fmt.Errorf("no example mentions %q, see loggly examples", term)))
+		}
+	}
+
+	check(help.RenderExamples(os.Stdout, examples, helpTitle()))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Ajnasz/go-loggly-cli/querylint"
+)
+
+// highlightStyle The style of the highlighted matches, reversed to stand
+// out from the style of the line whichever it is.
+var highlightStyle = style{7}
+
+// escapeSequence An ANSI select graphic rendition sequence, the
+// highlighting skips them.
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// wildcardReplacer Translates the wildcards of a quoted query term to
+// regular expressions matching within a word.
+var wildcardReplacer = strings.NewReplacer(`\*`, `[^\s"]*`, `\?`, `[^\s"]`)
+
+// queryTermPatterns The regular expressions of the free text, the phrases,
+// the field values and the regular expressions of the query. The
+// negated terms and the ranges are left out, their values are not what
+// made the events match.
+func queryTermPatterns(query string) []string {
+	var patterns []string
+
+	// depth of the skipped ranges and negated groups
+	skip := 0
+	negateNext := false
+	for _, t := range querylint.Tokenize(query) {
+		if t.Kind == querylint.Paren {
+			switch {
+			case skip > 0 && strings.Contains("([{", t.Value):
+				skip++
+			case skip > 0:
+				skip--
+			case t.Value == "[" || t.Value == "{" || (t.Value == "(" && negateNext):
+				skip++
+			}
+			negateNext = false
+			continue
+		}
+
+		if t.Kind == querylint.Operator {
+			negateNext = t.Value == "NOT"
+			continue
+		}
+
+		negated := t.Negated || negateNext
+		negateNext = false
+		if skip > 0 || negated || t.Value == "" || t.Value == "*" {
+			continue
+		}
+
+		switch t.Kind {
+		case querylint.Regexp:
+			if _, err := regexp.Compile(t.Value); err == nil {
+				patterns = append(patterns, t.Value)
+			}
+		case querylint.Phrase:
+			patterns = append(patterns, regexp.QuoteMeta(t.Value))
+		default:
+			patterns = append(patterns, wildcardReplacer.Replace(regexp.QuoteMeta(t.Value)))
+		}
+	}
+
+	return patterns
+}
+
+// newHighlights The expression matching the highlighted substrings, the
+// terms of the query with -highlight and the -highlight-regex, nil when
+// nothing is highlighted. The query terms match case insensitively, like
+// the search does.
+func newHighlights(config Config, query string) *regexp.Regexp {
+	var alternatives []string
+	if config.Highlight {
+		if terms := queryTermPatterns(query); len(terms) > 0 {
+			alternatives = append(alternatives, "(?i:"+strings.Join(terms, "|")+")")
+		}
+	}
+	if config.HighlightRegex != "" {
+		alternatives = append(alternatives, "(?:"+config.HighlightRegex+")")
+	}
+
+	if len(alternatives) == 0 {
+		return nil
+	}
+
+	// the -highlight-regex is checked by Validate and the terms are quoted
+	return regexp.MustCompile(strings.Join(alternatives, "|"))
+}
+
+// highlightLine Wrap the matches of the expression in the highlight
+// style. The escape sequences of the line are kept as they are, after a
+// match the style in effect before it is restored.
+func highlightLine(line string, re *regexp.Regexp) string {
+	if re == nil {
+		return line
+	}
+
+	var sb strings.Builder
+	current := ""
+	highlight := func(text string) {
+		sb.WriteString(re.ReplaceAllStringFunc(text, func(m string) string {
+			if m == "" {
+				return m
+			}
+			return highlightStyle.String() + m + styleReset + current
+		}))
+	}
+
+	last := 0
+	for _, loc := range escapeSequence.FindAllStringIndex(line, -1) {
+		highlight(line[last:loc[0]])
+		current = line[loc[0]:loc[1]]
+		sb.WriteString(current)
+		last = loc[1]
+	}
+	highlight(line[last:])
+
+	return sb.String()
+}
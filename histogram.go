@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/sparkline"
+)
+
+// histogramMaxBuckets The most buckets counted, a request each.
+const histogramMaxBuckets = 500
+
+// timeBucket The number of the events between Start and End.
+type timeBucket struct {
+	Start time.Time
+	End   time.Time
+	Count int64
+}
+
+// timeBuckets Split the range into buckets of the interval. The buckets
+// start at the multiples of the interval, the first and the last one
+// are cut to the range.
+func timeBuckets(from, until time.Time, interval time.Duration) []timeBucket {
+	var buckets []timeBucket
+	for start := from.Truncate(interval); start.Before(until); start = start.Add(interval) {
+		buckets = append(buckets, timeBucket{
+			Start: maxTime(start, from),
+			End:   minTime(start.Add(interval), until),
+		})
+	}
+	return buckets
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// countBuckets Count the events of the query in every bucket, -concurrency
+// buckets at once.
+func countBuckets(ctx context.Context, config Config, query string, buckets []timeBucket) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(config.Concurrency, 1))
+	for i := range buckets {
+		g.Go(func() error {
+			bucketConfig := config
+			bucketConfig.From = buckets[i].Start.UTC().Format(time.RFC3339Nano)
+			bucketConfig.To = buckets[i].End.UTC().Format(time.RFC3339Nano)
+
+			n, err := sumCount(ctx, bucketConfig, query)
+			buckets[i].Count = n
+			return err
+		})
+	}
+	return g.Wait()
+}
+
+// printTimeBuckets Print a row per bucket with its start and its count,
+// followed by the sparkline of the counts and the peak.
+func printTimeBuckets(w io.Writer, buckets []timeBucket) error {
+	var total int64
+	peak := 0
+	counts := make([]int64, len(buckets))
+	for i, b := range buckets {
+		counts[i] = b.Count
+		total += b.Count
+		if b.Count > buckets[peak].Count {
+			peak = i
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "TIME\tCOUNT\t")
+	for _, b := range buckets {
+		fmt.Fprintf(tw, "%s\t%s\t\n", locale.Time(b.Start), locale.Int(b.Count))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s\n%s\n", sparkline.Render(counts),
+		locale.Sprintf("%d events, peak %d at %s", total, buckets[peak].Count, locale.Time(buckets[peak].Start)))
+	return err
+}
+
+// runHistogram Print the number of the events of the query per time
+// bucket of -interval, to spot the spikes without the web UI.
+func runHistogram(args []string) {
+	var config Config
+	flags := newFlagSet("loggly histogram", &config)
+	interval := flags.Duration("interval", 5*time.Minute, "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	if *interval < time.Second {
+		check(usageError(fmt.Errorf("interval must be at least 1s")))
+	}
+	if config.Input != "" {
+		check(usageError(fmt.Errorf("histogram counts the events in Loggly, -input can not be used")))
+	}
+
+	query, queryErr := resolveQuery(config, flags.Args())
+	check(queryErr)
+	if query == "" {
+		query = "*"
+	}
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+	useTimeLocation(config)
+	check(lintQuery(config, query))
+
+	now := time.Now()
+	from, _ := resolveTime(strings.TrimSpace(config.From), now)
+	until, _ := resolveTime(strings.TrimSpace(config.To), now)
+	buckets := timeBuckets(from, until, *interval)
+	if len(buckets) == 0 {
+		check(usageError(fmt.Errorf("from (%s) must be before until (%s)", config.From, config.To)))
+	}
+	if len(buckets) > histogramMaxBuckets {
+		check(usageError(fmt.Errorf("the time range has %d buckets of %s, at most %d are counted, raise -interval or shorten the range", len(buckets), *interval, histogramMaxBuckets)))
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	check(startAudit(config, "histogram", query))
+	check(countBuckets(ctx, config, query, buckets))
+
+	var total int64
+	for _, b := range buckets {
+		total += b.Count
+	}
+	check(finishAudit(total, nil))
+
+	check(printTimeBuckets(os.Stdout, buckets))
+	exitIfEmpty(config, total)
+}
@@ -0,0 +1,113 @@
+// Package histogram buckets numeric values into equal width ranges and
+// renders them as ASCII bars.
+package histogram
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Bucket Values in the [Low, High) range, the last bucket includes High.
+type Bucket struct {
+	Low   float64
+	High  float64
+	Count int
+}
+
+type Histogram struct {
+	// FormatCount Format the counts printed by Render.
+	FormatCount func(n int) string
+
+	buckets int
+	values  []float64
+}
+
+// New Create a histogram with the given number of buckets.
+func New(buckets int) *Histogram {
+	return &Histogram{buckets: max(buckets, 1), FormatCount: strconv.Itoa}
+}
+
+// Add Record a value. NaN and infinite values are ignored.
+func (h *Histogram) Add(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	h.values = append(h.values, v)
+}
+
+// Len Number of recorded values.
+func (h *Histogram) Len() int {
+	return len(h.values)
+}
+
+// Buckets Split the range of the recorded values into equal width buckets.
+func (h *Histogram) Buckets() []Bucket {
+	if len(h.values) == 0 {
+		return nil
+	}
+
+	low, high := h.values[0], h.values[0]
+	for _, v := range h.values {
+		low = min(low, v)
+		high = max(high, v)
+	}
+
+	if low == high {
+		return []Bucket{{Low: low, High: high, Count: len(h.values)}}
+	}
+
+	width := (high - low) / float64(h.buckets)
+	buckets := make([]Bucket, h.buckets)
+	for i := range buckets {
+		buckets[i].Low = low + float64(i)*width
+		buckets[i].High = low + float64(i+1)*width
+	}
+	buckets[len(buckets)-1].High = high
+
+	for _, v := range h.values {
+		i := min(int((v-low)/width), h.buckets-1)
+		buckets[i].Count++
+	}
+
+	return buckets
+}
+
+// Render Print the buckets with bars scaled to at most width characters.
+func (h *Histogram) Render(w io.Writer, width int) error {
+	buckets := h.Buckets()
+
+	maxCount := 0
+	lowWidth, highWidth := 0, 0
+	for _, b := range buckets {
+		maxCount = max(maxCount, b.Count)
+		lowWidth = max(lowWidth, len(formatValue(b.Low)))
+		highWidth = max(highWidth, len(formatValue(b.High)))
+	}
+
+	for _, b := range buckets {
+		bar := 0
+		if maxCount > 0 {
+			bar = b.Count * width / maxCount
+		}
+		if b.Count > 0 && bar == 0 {
+			bar = 1
+		}
+
+		_, err := fmt.Fprintf(w, "%*s - %-*s | %s\n",
+			lowWidth, formatValue(b.Low),
+			highWidth, formatValue(b.High),
+			strings.TrimSpace(strings.Repeat("#", bar)+" "+h.FormatCount(b.Count)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
@@ -0,0 +1,58 @@
+package histogram
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBuckets(t *testing.T) {
+	h := New(4)
+	for _, v := range []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, math.NaN()} {
+		h.Add(v)
+	}
+
+	buckets := h.Buckets()
+	if len(buckets) != 4 {
+		t.Fatalf("expected 4 buckets, got %d", len(buckets))
+	}
+
+	want := []int{2, 2, 2, 3}
+	for i, b := range buckets {
+		if b.Count != want[i] {
+			t.Errorf("bucket %d: expected %d values, got %d", i, want[i], b.Count)
+		}
+	}
+
+	if buckets[0].Low != 0 || buckets[3].High != 8 {
+		t.Errorf("unexpected range %v - %v", buckets[0].Low, buckets[3].High)
+	}
+}
+
+func TestBucketsSingleValue(t *testing.T) {
+	h := New(10)
+	h.Add(3)
+	h.Add(3)
+
+	buckets := h.Buckets()
+	if len(buckets) != 1 || buckets[0].Count != 2 {
+		t.Fatalf("expected one bucket with 2 values, got %v", buckets)
+	}
+}
+
+func TestRender(t *testing.T) {
+	h := New(2)
+	for _, v := range []float64{10, 10, 10, 10, 20} {
+		h.Add(v)
+	}
+
+	var sb strings.Builder
+	if err := h.Render(&sb, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "10 - 15 | ######## 4\n15 - 20 | ## 1\n"
+	if sb.String() != want {
+		t.Errorf("expected\n%q\ngot\n%q", want, sb.String())
+	}
+}
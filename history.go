@@ -0,0 +1,242 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+	"github.com/Ajnasz/go-loggly-cli/history"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyPath The history of the executed queries, history.jsonl in the
+// state directory. Empty when history.enabled is false in the config
+// file.
+func historyPath(file configfile.File) (string, error) {
+	if value := file["history.enabled"]; value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid history.enabled %q in the config file, use true or false", value)
+		}
+		if !enabled {
+			return "", nil
+		}
+	}
+
+	return platform.StateFile("history.jsonl")
+}
+
+// recordHistory Append the query started at the time to the history,
+// with the number of events it returned, or the error it failed with.
+func recordHistory(config Config, started time.Time, command, query string, results int64, err error) error {
+	if config.HistoryFile == "" {
+		return nil
+	}
+
+	e := history.Entry{
+		Time:    started.UTC(),
+		Command: command,
+		Account: config.Account,
+		Query:   query,
+		From:    config.From,
+		Until:   config.To,
+		Results: results,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return history.Append(config.HistoryFile, e, history.Size)
+}
+
+// loadHistory The entries of the history, oldest first.
+func loadHistory() []history.Entry {
+	fileConfig, err := loadConfigFile()
+	check(usageError(err))
+	path, err := historyPath(fileConfig)
+	check(usageError(err))
+	if path == "" {
+		check(usageError(errors.New("the history is off, remove history.enabled = false from the config file")))
+	}
+
+	entries, err := history.ReadFile(path)
+	check(err)
+	return entries
+}
+
+// runHistory Print the last entries of the history with their numbers,
+// the ones loggly rerun takes.
+func runHistory(args []string) {
+	flags := flag.NewFlagSet("loggly history", flag.ExitOnError)
+	n := flags.Int("n", 20, "")
+	jsonLines := flags.Bool("json", false, "")
+	flags.Parse(args)
+
+	if *n < 0 {
+		check(usageError(fmt.Errorf("n must not be negative")))
+	}
+
+	entries := loadHistory()
+	if *n > 0 && len(entries) > *n {
+		entries = entries[len(entries)-*n:]
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if !*jsonLines {
+		fmt.Fprintln(tw, "#\tTIME\tCOMMAND\tACCOUNT\tFROM\tUNTIL\tRESULTS\tQUERY\tERROR")
+	}
+	for _, e := range entries {
+		if *jsonLines {
+			check(printJSON(os.Stdout, []any{e}))
+			continue
+		}
+
+		results := locale.Int(e.Results)
+		if e.Error != "" {
+			results = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, locale.Time(e.Time), e.Command, e.Account, e.From, e.Until, results, e.Query, e.Error)
+	}
+	check(tw.Flush())
+}
+
+// rerunCommands The commands of the history entries loggly rerun runs
+// again, the searches of the TUI included. The options of the others
+// are not recorded.
+var rerunCommands = []string{"search", "count", "tui"}
+
+// runRerun Search again with the query, the account and the time range
+// of the history entry of the number, counting for the counts. The
+// options are the ones of loggly search, -from and -until override the
+// recorded time range.
+func runRerun(args []string) {
+	if len(args) == 0 {
+		check(usageError(fmt.Errorf("usage: loggly rerun <n> [options]")))
+	}
+	n, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		check(usageError(fmt.Errorf("invalid history entry %q, give its number from loggly history", args[0])))
+	}
+	options := args[1:]
+
+	var config Config
+	flags, _ := newRunFlagSet(&config)
+	flags.Parse(options)
+	if flags.NArg() > 0 || config.Query != "" || config.QueryFile != "" {
+		check(usageError(fmt.Errorf("the query of the history entry %d can not be changed, search with the new one", n)))
+	}
+
+	e, err := history.Recall(loadHistory(), n)
+	check(usageError(err))
+	if !slices.Contains(rerunCommands, e.Command) {
+		check(usageError(fmt.Errorf("the history entry %d was run by loggly %s, only the searches and the counts can be run again", n, e.Command)))
+	}
+
+	var runArgs []string
+	if e.Account != "" {
+		runArgs = append(runArgs, "-account", e.Account)
+	}
+	if e.From != "" {
+		runArgs = append(runArgs, "-from", e.From)
+	}
+	if e.Until != "" {
+		runArgs = append(runArgs, "-until", e.Until)
+	}
+	if e.Command == "count" {
+		runArgs = append(runArgs, "-count")
+	}
+	runArgs = append(runArgs, options...)
+	runArgs = append(runArgs, "--", e.Query)
+
+	run(runArgs, runOptions{})
+}
+
+// historyItem A query of the history in the TUI.
+type historyItem struct {
+	entry history.Entry
+}
+
+func (i historyItem) FilterValue() string { return i.entry.Query }
+func (i historyItem) Title() string       { return i.entry.Query }
+func (i historyItem) Description() string {
+	parts := []string{locale.Time(i.entry.Time), i.entry.Command}
+	if i.entry.From != "" {
+		parts = append(parts, i.entry.From+" → "+cmp.Or(i.entry.Until, "now"))
+	}
+	if i.entry.Error != "" {
+		parts = append(parts, locale.Sprintf("Error: %s", i.entry.Error))
+	} else {
+		parts = append(parts, locale.Sprintf("%d results", i.entry.Results))
+	}
+	return strings.Join(parts, " • ")
+}
+
+// openHistory Show the queries of the history over the panes, the last
+// one first, each query once.
+func (m *model) openHistory() {
+	if m.config.HistoryFile == "" {
+		m.debugView = locale.Sprintf("The history is off")
+		return
+	}
+
+	entries, err := history.ReadFile(m.config.HistoryFile)
+	if err != nil {
+		m.debugView = locale.Sprintf("The history could not be read: %s", err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	var items []list.Item
+	for _, e := range slices.Backward(entries) {
+		if seen[e.Query] {
+			continue
+		}
+		seen[e.Query] = true
+		items = append(items, historyItem{entry: e})
+	}
+
+	m.historyList.ResetFilter()
+	m.historyList.SetItems(items)
+	m.historyList.Select(0)
+	m.showingHistory = true
+	if m.config.Accessible {
+		m.debugView = locale.Sprintf("History, %d items", len(items))
+	}
+}
+
+// recallHistory Put the query of the selected history entry into the
+// query input and run it.
+func (m *model) recallHistory() tea.Cmd {
+	m.showingHistory = false
+	item, ok := m.historyList.SelectedItem().(historyItem)
+	if !ok {
+		return nil
+	}
+
+	m.queryInput.SetValue(item.entry.Query)
+	m.queryInput.CursorEnd()
+	m.currentPane = queryPane
+	m.updateFocus()
+	return m.startQuery()
+}
+
+// updateHistory Pass the message to the history list.
+func (m *model) updateHistory(msg tea.Msg) tea.Cmd {
+	previous := m.historyList.Index()
+	var cmd tea.Cmd
+	m.historyList, cmd = m.historyList.Update(msg)
+	if m.config.Accessible {
+		m.announceSelection(previous)
+	}
+	return cmd
+}
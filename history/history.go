@@ -0,0 +1,151 @@
+// Package history keeps the queries run by the user in a JSON lines
+// file, so they can be listed and run again.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/platform"
+)
+
+// Size The number of the entries kept, the older ones are dropped.
+const Size = 1000
+
+// Entry A query run by the user.
+type Entry struct {
+	// ID The number of the entry, one more than the one of the previous
+	// entry, kept when the older entries are dropped.
+	ID      int64     `json:"id"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Account string    `json:"account,omitempty"`
+	Query   string    `json:"query"`
+	// From and Until The time range as it was given, the relative times
+	// are relative to the next run as well.
+	From  string `json:"from,omitempty"`
+	Until string `json:"until,omitempty"`
+	// Results The number of events the query returned, or counted.
+	Results int64 `json:"results"`
+	// Error Why the query failed, empty if it did not.
+	Error string `json:"error,omitempty"`
+}
+
+// Append Append the entry to the history file as a line, numbered after
+// the last one. The invocations running at the same time take turns on
+// the lock file next to the history. Once the file holds a quarter more
+// than size entries, it is rewritten with the last size ones.
+func Append(path string, e Entry, size int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// the history itself is replaced when it is trimmed, the lock is
+	// taken on a file that stays
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	if err := platform.LockFile(lock); err != nil {
+		return fmt.Errorf("history: locking %s: %w", path, err)
+	}
+	defer platform.UnlockFile(lock)
+
+	entries, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	e.ID = 1
+	if len(entries) > 0 {
+		e.ID = entries[len(entries)-1].ID + 1
+	}
+	if entries = append(entries, e); len(entries) > size+size/4 {
+		return write(path, entries[len(entries)-size:])
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// write Replace the file with the entries, an interrupted write keeps
+// the previous history.
+func write(path string, entries []Entry) error {
+	return platform.WriteFile(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Read Read the entries of a history, oldest first.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("history: invalid entry at line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, scanner.Err()
+}
+
+// ReadFile Read the entries of the history file, none if it does not
+// exist.
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Recall The entry of the ID.
+func Recall(entries []Entry, id int64) (Entry, error) {
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("history: no entry %d", id)
+}
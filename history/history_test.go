@@ -0,0 +1,115 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history.jsonl")
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []Entry{
+		{Time: now, Command: "search", Account: "acme", Query: "json.level:error", From: "-1h", Until: "now", Results: 42},
+		{Time: now.Add(time.Minute), Command: "count", Account: "acme", Query: "*", Error: "401 Unauthorized"},
+	}
+	for _, e := range entries {
+		if err := Append(path, e, Size); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("expected %d entries, got %d", len(entries), len(got))
+	}
+	for i := range entries {
+		// numbered in the order they were appended
+		entries[i].ID = int64(i + 1)
+		if got[i] != entries[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, entries[i], got[i])
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected the mode 0600, got %o", perm)
+	}
+}
+
+func TestAppendTrims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := range 13 {
+		e := Entry{Time: now.Add(time.Duration(i) * time.Minute), Command: "search", Query: strings.Repeat("x", i+1)}
+		if err := Append(path, e, 10); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) > 12 {
+			t.Fatalf("expected at most 12 entries, got %d", len(got))
+		}
+		if last := got[len(got)-1].Query; last != e.Query {
+			t.Fatalf("expected the last entry %q, got %q", e.Query, last)
+		}
+	}
+
+	got, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 10 || got[0].Query != "xxxx" {
+		t.Errorf("expected the last 10 entries, got %d from %q", len(got), got[0].Query)
+	}
+	if got[0].ID != 4 || got[len(got)-1].ID != 13 {
+		t.Errorf("expected the entries 4 to 13 to keep their numbers, got %d to %d", got[0].ID, got[len(got)-1].ID)
+	}
+}
+
+func TestReadFileMissing(t *testing.T) {
+	got, err := ReadFile(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil || got != nil {
+		t.Errorf("expected no entries, got %v, %v", got, err)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	_, err := Read(strings.NewReader("{\"query\":\"*\"}\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error at line 2, got %v", err)
+	}
+}
+
+func TestRecall(t *testing.T) {
+	entries := []Entry{{ID: 8, Query: "first"}, {ID: 9, Query: "second"}, {ID: 10, Query: "third"}}
+
+	for id, want := range map[int64]string{10: "third", 8: "first"} {
+		e, err := Recall(entries, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Query != want {
+			t.Errorf("%d: expected %q, got %q", id, want, e.Query)
+		}
+	}
+
+	for _, id := range []int64{0, 1, 11} {
+		if _, err := Recall(entries, id); err == nil {
+			t.Errorf("%d: expected an error", id)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package ingest sends events to the HTTP/S ingest endpoints of Loggly,
+// which are authorized by the customer token instead of the API token of
+// the search.
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint The ingest endpoint of Loggly.
+const DefaultEndpoint = "https://logs-01.loggly.com"
+
+// Limits of the bulk endpoint.
+const (
+	MaxEventSize = 1 << 20
+	MaxBulkSize  = 5 << 20
+)
+
+// Error A response of the ingest endpoint other than 200 OK.
+type Error struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *Error) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("ingest failed with %s", e.Status)
+	}
+	return fmt.Sprintf("ingest failed with %s: %s", e.Status, e.Body)
+}
+
+// Client Sends events with a customer token.
+type Client struct {
+	Token string
+
+	endpoint  string
+	tags      []string
+	transport *http.Transport
+	timeout   time.Duration
+}
+
+// New Create a client sending events to the Loggly ingest endpoint.
+func New(token string) *Client {
+	return &Client{
+		Token:     token,
+		endpoint:  DefaultEndpoint,
+		transport: http.DefaultTransport.(*http.Transport).Clone(),
+		timeout:   30 * time.Second,
+	}
+}
+
+// SetEndpoint Override the base URL of the ingest endpoint, like
+// http://127.0.0.1:8080 for a mock.
+func (c *Client) SetEndpoint(endpoint string) *Client {
+	c.endpoint = strings.TrimSuffix(endpoint, "/")
+	return c
+}
+
+// SetTags Tag the sent events.
+func (c *Client) SetTags(tags []string) *Client {
+	c.tags = tags
+	return c
+}
+
+// SetProxy Send the requests through the given proxy. By default the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+func (c *Client) SetProxy(proxy *url.URL) *Client {
+	if proxy == nil {
+		c.transport.Proxy = http.ProxyFromEnvironment
+	} else {
+		c.transport.Proxy = http.ProxyURL(proxy)
+	}
+	return c
+}
+
+// SetTLSConfig Use custom TLS settings.
+func (c *Client) SetTLSConfig(config *tls.Config) *Client {
+	c.transport.TLSClientConfig = config
+	return c
+}
+
+// SetTimeout Set the timeout of a request, zero disables it.
+func (c *Client) SetTimeout(d time.Duration) *Client {
+	c.timeout = d
+	return c
+}
+
+func (c *Client) url(kind string) string {
+	u := c.endpoint + "/" + kind + "/" + url.PathEscape(c.Token) + "/"
+	if len(c.tags) > 0 {
+		u += "tag/" + url.PathEscape(strings.Join(c.tags, ",")) + "/"
+	}
+	return u
+}
+
+func (c *Client) post(ctx context.Context, kind string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(kind), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	client := &http.Client{Transport: c.transport, Timeout: c.timeout}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return &Error{StatusCode: res.StatusCode, Status: res.Status, Body: strings.TrimSpace(string(data))}
+	}
+
+	io.Copy(io.Discard, res.Body)
+	return nil
+}
+
+// Send Send a single event, a JSON object or a text message.
+func (c *Client) Send(ctx context.Context, event []byte) error {
+	if len(event) > MaxEventSize {
+		return fmt.Errorf("ingest: event of %d bytes is larger than the %d bytes limit", len(event), MaxEventSize)
+	}
+
+	return c.post(ctx, "inputs", event)
+}
+
+// SendBulk Send the events, one per line, in as few requests as the
+// size limit of the bulk endpoint allows. Returns the number of events
+// sent before an error.
+func (c *Client) SendBulk(ctx context.Context, events [][]byte) (int, error) {
+	var (
+		buf     bytes.Buffer
+		sent    int
+		pending int
+	)
+
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		if err := c.post(ctx, "bulk", buf.Bytes()); err != nil {
+			return err
+		}
+		sent += pending
+		pending = 0
+		buf.Reset()
+		return nil
+	}
+
+	for _, event := range events {
+		if bytes.ContainsAny(event, "\r\n") {
+			return sent, fmt.Errorf("ingest: the bulk endpoint needs single line events")
+		}
+		if len(event) > MaxEventSize {
+			return sent, fmt.Errorf("ingest: event of %d bytes is larger than the %d bytes limit", len(event), MaxEventSize)
+		}
+
+		if buf.Len()+len(event)+1 > MaxBulkSize {
+			if err := flush(); err != nil {
+				return sent, err
+			}
+		}
+
+		buf.Write(event)
+		buf.WriteByte('\n')
+		pending++
+	}
+
+	return sent, flush()
+}
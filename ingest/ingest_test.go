@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	paths  []string
+	bodies []string
+	status int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	r.paths = append(r.paths, req.URL.Path)
+	r.bodies = append(r.bodies, string(body))
+	r.mu.Unlock()
+
+	if r.status != 0 {
+		http.Error(w, "invalid token", r.status)
+		return
+	}
+	w.Write([]byte(`{"response":"ok"}`))
+}
+
+func TestSend(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	c := New("tok").SetEndpoint(srv.URL + "/").SetTags([]string{"smoke", "ci"})
+	if err := c.Send(context.Background(), []byte(`{"message":"hello"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.paths[0] != "/inputs/tok/tag/smoke,ci/" {
+		t.Errorf("unexpected path %s", rec.paths[0])
+	}
+	if rec.bodies[0] != `{"message":"hello"}` {
+		t.Errorf("unexpected body %s", rec.bodies[0])
+	}
+}
+
+func TestSendBulkSplitsAtTheSizeLimit(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	event := bytes.Repeat([]byte("x"), MaxEventSize-1)
+	events := make([][]byte, 7)
+	for i := range events {
+		events[i] = event
+	}
+
+	n, err := New("tok").SetEndpoint(srv.URL).SendBulk(context.Background(), events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(events) {
+		t.Errorf("expected %d events sent, got %d", len(events), n)
+	}
+
+	// five events of 1 MB with their new lines fill the 5 MB limit
+	if len(rec.bodies) != 2 || strings.Count(rec.bodies[0], "\n") != 5 || strings.Count(rec.bodies[1], "\n") != 2 {
+		t.Errorf("unexpected requests %d", len(rec.bodies))
+	}
+	if rec.paths[0] != "/bulk/tok/" {
+		t.Errorf("unexpected path %s", rec.paths[0])
+	}
+}
+
+func TestSendBulkRejectsMultilineEvents(t *testing.T) {
+	_, err := New("tok").SendBulk(context.Background(), [][]byte{[]byte("a\nb")})
+	if err == nil {
+		t.Error("expected an error for a multi line event")
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(&recorder{status: http.StatusForbidden})
+	defer srv.Close()
+
+	err := New("bad").SetEndpoint(srv.URL).Send(context.Background(), []byte("hello"))
+
+	var ingestErr *Error
+	if !errors.As(err, &ingestErr) || ingestErr.StatusCode != http.StatusForbidden || ingestErr.Body != "invalid token" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
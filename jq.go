@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/itchyny/gojq"
+)
+
+// jqFilter transforms the events with the -jq expression. Every value
+// the expression outputs for an event is printed in place of the event,
+// so select() drops events and paths pick parts of them.
+type jqFilter struct {
+	code   *gojq.Code
+	config Config
+	warned map[string]bool
+}
+
+func compileJQ(query string) (*gojq.Code, error) {
+	q, err := gojq.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return gojq.Compile(q)
+}
+
+// newJQFilter Compile the -jq expression, returns nil when it is not
+// set.
+func newJQFilter(config Config) (*jqFilter, error) {
+	if config.JQ == "" {
+		return nil, nil
+	}
+
+	code, err := compileJQ(config.JQ)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jqFilter{code: code, config: config, warned: make(map[string]bool)}, nil
+}
+
+// Apply Return the outputs of the expression for the events. Events
+// failing to evaluate are dropped with a warning printed once per
+// distinct error.
+func (f *jqFilter) Apply(events []any) []any {
+	if f == nil {
+		return events
+	}
+
+	var ret []any
+	for _, event := range events {
+		iter := f.code.Run(jqValue(event))
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				break
+			}
+
+			if err, ok := v.(error); ok {
+				if haltErr, ok := err.(*gojq.HaltError); ok && haltErr.Value() == nil {
+					break
+				}
+				if !f.warned[err.Error()] {
+					f.warned[err.Error()] = true
+					f.config.warnf("jq: %s, skipping the event", err)
+				}
+				break
+			}
+
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// jqValue Convert the json.Number values of the decoded events to the
+// number types gojq works with.
+func jqValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, child := range v {
+			m[k] = jqValue(child)
+		}
+		return m
+	case []any:
+		a := make([]any, len(v))
+		for i, child := range v {
+			a[i] = jqValue(child)
+		}
+		return a
+	case textMessage:
+		return string(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			if int64(int(i)) == i {
+				return int(i)
+			}
+			return big.NewInt(i)
+		}
+		if n, ok := new(big.Int).SetString(v.String(), 10); ok {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
@@ -0,0 +1,90 @@
+// Package kmerge merges already sorted channels into a single sorted
+// channel.
+package kmerge
+
+import (
+	"container/heap"
+	"context"
+)
+
+type head[T any] struct {
+	item  T
+	input int
+}
+
+type heads[T any] struct {
+	items []head[T]
+	less  func(a, b T) bool
+}
+
+func (h *heads[T]) Len() int      { return len(h.items) }
+func (h *heads[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *heads[T]) Push(x any)    { h.items = append(h.items, x.(head[T])) }
+func (h *heads[T]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.less(a.item, b.item) {
+		return true
+	}
+	if h.less(b.item, a.item) {
+		return false
+	}
+	// equal items keep the order of the inputs
+	return a.input < b.input
+}
+func (h *heads[T]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+func receive[T any](ctx context.Context, ch <-chan T) (T, bool, error) {
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, false, ctx.Err()
+	case item, ok := <-ch:
+		return item, ok, nil
+	}
+}
+
+// Merge Send the items of the inputs to out, ordered by less. Every
+// input must already be sorted by less. Items comparing equal keep the
+// order of the inputs. Out is closed when all inputs are drained or
+// the context is done.
+func Merge[T any](ctx context.Context, less func(a, b T) bool, out chan<- T, inputs ...<-chan T) error {
+	defer close(out)
+
+	h := &heads[T]{less: less}
+
+	for i, input := range inputs {
+		item, ok, err := receive(ctx, input)
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.items = append(h.items, head[T]{item: item, input: i})
+		}
+	}
+
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		next := heap.Pop(h).(head[T])
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- next.item:
+		}
+
+		item, ok, err := receive(ctx, inputs[next.input])
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Push(h, head[T]{item: item, input: next.input})
+		}
+	}
+
+	return nil
+}
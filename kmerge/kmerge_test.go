@@ -0,0 +1,65 @@
+package kmerge
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func feed(items ...int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for _, i := range items {
+			ch <- i
+		}
+	}()
+	return ch
+}
+
+func TestMerge(t *testing.T) {
+	out := make(chan int)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Merge(context.Background(), func(a, b int) bool { return a < b }, out,
+			feed(1, 4, 7),
+			feed(),
+			feed(2, 3, 8, 9),
+			feed(5, 6),
+		)
+	}()
+
+	var got []int
+	for i := range out {
+		got = append(got, i)
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMergeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan int)
+	blocked := make(chan int)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Merge(ctx, func(a, b int) bool { return a < b }, out, feed(1), blocked)
+	}()
+
+	cancel()
+
+	for range out {
+	}
+
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// lagProbeField The field of the probe event holding its unique id.
+const lagProbeField = "loggly_lag_probe"
+
+// runLag Send a uniquely tagged event through the ingest endpoint and
+// measure how long it takes until the search finds it.
+func runLag(args []string) {
+	var config Config
+	flags := newFlagSet("loggly lag", &config)
+	ingestOpts := addIngestFlags(flags)
+	interval := flags.Duration("interval", 2*time.Second, "")
+	wait := flags.Duration("wait", 5*time.Minute, "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	if *interval <= 0 || *wait <= 0 {
+		check(usageError(fmt.Errorf("interval and wait must be greater than 0")))
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	check(config.resolveToken(os.Stdin))
+	// the probe is searched from a minute before sending it, to tolerate
+	// clock skew between this machine and Loggly
+	config.From = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	config.To = "now"
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+	if len(splitList(config.Account)) > 1 {
+		check(usageError(fmt.Errorf("lag measures a single account")))
+	}
+
+	client, err := ingestOpts.newIngestClient(config, fileConfig)
+	check(err)
+	client.SetTags([]string{"loggly-lag"})
+
+	id := make([]byte, 8)
+	rand.Read(id)
+	probe := "lag-" + hex.EncodeToString(id)
+
+	sent := time.Now()
+	event, err := json.Marshal(map[string]any{
+		lagProbeField: probe,
+		"message":     "loggly lag probe",
+		"sent_at":     sent.UTC().Format(time.RFC3339Nano),
+	})
+	check(err)
+	check(client.Send(ctx, event))
+	accepted := time.Since(sent)
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Sent probe %s, accepted in %s", probe, accepted.Round(time.Millisecond)))
+
+	ctx, cancelWait := context.WithTimeout(ctx, *wait)
+	defer cancelWait()
+
+	query := fmt.Sprintf("json.%s:%s", lagProbeField, probe)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		n, err := fetchCount(ctx, config, query)
+		if err != nil && ctx.Err() == nil {
+			check(err)
+		}
+		if n > 0 {
+			fmt.Println(locale.Sprintf("Searchable after %s", time.Since(sent).Round(time.Millisecond)))
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			check(fmt.Errorf("the probe was not searchable within %s", *wait))
+		case <-ticker.C:
+		}
+	}
+}
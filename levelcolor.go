@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// style An ANSI style, the parameters of a select graphic rendition
+// escape sequence like ESC[1;31m.
+type style []int
+
+// Styles of the lines by the level of the event.
+var (
+	levelStyleError = style{31}
+	levelStyleWarn  = style{33}
+	levelStyleDebug = style{90}
+)
+
+// styleReset Ends the styled text.
+const styleReset = "\x1b[0m"
+
+// maxStyleParams The number of parameters of the fixed width sequences,
+// and so the most a style can have.
+const maxStyleParams = 6
+
+func (s style) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	params := make([]string, len(s))
+	for i, p := range s {
+		params[i] = strconv.Itoa(p)
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// fixedWidth The sequence of the style padded to the same length for
+// every style, the empty one included, so the sequences widen the first
+// column of the table equally. The padding is resets, which precede the
+// parameters of the style.
+func (s style) fixedWidth() string {
+	params := make([]string, maxStyleParams)
+	for i := range params {
+		params[i] = "000"
+	}
+	offset := maxStyleParams - len(s)
+	for i, p := range s {
+		params[offset+i] = fmt.Sprintf("%03d", p)
+	}
+
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// defaultLevelFields The fields holding the level in the messages and in
+// the whole events printed with -all.
+var defaultLevelFields = []string{"level", "severity", "json.level", "json.severity"}
+
+// levelNames Styles of the level names, the other levels, like info, are
+// printed in the default color.
+var levelNames = map[string]style{
+	"emerg":     levelStyleError,
+	"emergency": levelStyleError,
+	"alert":     levelStyleError,
+	"crit":      levelStyleError,
+	"critical":  levelStyleError,
+	"fatal":     levelStyleError,
+	"panic":     levelStyleError,
+	"err":       levelStyleError,
+	"error":     levelStyleError,
+	"warn":      levelStyleWarn,
+	"warning":   levelStyleWarn,
+	"debug":     levelStyleDebug,
+	"trace":     levelStyleDebug,
+}
+
+// numericLevelStyle Style of the numeric levels, syslog severities from
+// 0 to 7 and the bunyan and pino levels from 10 to 60.
+func numericLevelStyle(n float64) style {
+	switch {
+	case n <= 3:
+		return levelStyleError
+	case n == 4:
+		return levelStyleWarn
+	case n == 7, n >= 10 && n <= 20:
+		return levelStyleDebug
+	case n >= 50:
+		return levelStyleError
+	case n >= 40:
+		return levelStyleWarn
+	default:
+		return nil
+	}
+}
+
+// levelStyle The style of the line of the event by the value of the
+// first level field it has, nil for info and the unknown levels.
+func levelStyle(event any, fields []string) style {
+	for _, field := range fields {
+		v, ok := lookupField(event, field)
+		if !ok || v == nil {
+			continue
+		}
+
+		switch level := v.(type) {
+		case string:
+			return levelNames[strings.ToLower(strings.TrimSpace(level))]
+		case float64:
+			return numericLevelStyle(level)
+		case json.Number:
+			if n, err := level.Float64(); err == nil {
+				return numericLevelStyle(n)
+			}
+			return nil
+		default:
+			return levelNames[strings.ToLower(fmt.Sprint(level))]
+		}
+	}
+
+	return nil
+}
+
+// colorLine Wrap the line in the style, without a style the line is
+// returned as it is.
+func colorLine(line string, s style) string {
+	if len(s) == 0 {
+		return line
+	}
+	return s.String() + line + styleReset
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/querylint"
+)
+
+// lintQuery Print the lint issues of the query to the standard error.
+// In strict mode any issue is an error.
+func lintQuery(config Config, query string) error {
+	// local inputs cost nothing to search
+	if config.Input != "" {
+		return nil
+	}
+
+	window, _ := timeWindow(config.From, config.To, time.Now())
+	issues := querylint.Lint(query, window)
+
+	for _, issue := range issues {
+		config.warnf("%s", issue)
+	}
+	warnUnknownFields(config, query)
+
+	if config.Strict && len(issues) > 0 {
+		return fmt.Errorf("the query has %d lint issue(s), not running it in strict mode", len(issues))
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+// Package locale formats numbers and timestamps of the human readable
+// outputs, like the estimate and the histograms, for the user's locale,
+// and translates the user facing messages. Machine readable outputs must
+// not use it.
+package locale
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// TimeLayout Layout of the timestamps in human readable outputs.
+const TimeLayout = "2006-01-02 15:04:05 MST"
+
+// Formatter formats values for a locale.
+type Formatter struct {
+	printer *message.Printer
+	loc     *time.Location
+}
+
+// New Create a formatter for the language, printing times in the
+// location.
+func New(tag language.Tag, loc *time.Location) Formatter {
+	if loc == nil {
+		loc = time.Local
+	}
+	return Formatter{printer: message.NewPrinter(tag), loc: loc}
+}
+
+// Default Formatter of the locale and time zone of the environment.
+var Default = New(FromEnv(os.Getenv), time.Local)
+
+// FromEnv Language of the LC_ALL, LC_NUMERIC or LANG environment
+// variables, in this order, like "de_DE.UTF-8". The C and POSIX locales
+// and unknown values fall back to English.
+func FromEnv(getenv func(string) string) language.Tag {
+	return fromEnv(getenv, "LC_ALL", "LC_NUMERIC", "LANG")
+}
+
+// fromEnv Language of the first set environment variable of the names.
+func fromEnv(getenv func(string) string, names ...string) language.Tag {
+	for _, name := range names {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+
+		// strip the encoding and the modifier: de_DE.UTF-8@euro
+		value, _, _ = strings.Cut(value, ".")
+		value, _, _ = strings.Cut(value, "@")
+		if value == "C" || value == "POSIX" {
+			return language.English
+		}
+
+		tag, err := language.Parse(strings.ReplaceAll(value, "_", "-"))
+		if err != nil {
+			return language.English
+		}
+		return tag
+	}
+
+	return language.English
+}
+
+// Int Format the number with the thousands separators of the locale.
+func (f Formatter) Int(n int64) string {
+	return f.printer.Sprintf("%d", n)
+}
+
+// Time Format the time in the time zone of the formatter.
+func (f Formatter) Time(t time.Time) string {
+	return t.In(f.loc).Format(TimeLayout)
+}
+
+// Int Format the number with the Default formatter.
+func Int[T ~int | ~int64](n T) string {
+	return Default.Int(int64(n))
+}
+
+// Time Format the time with the Default formatter.
+func Time(t time.Time) string {
+	return Default.Time(t)
+}
@@ -0,0 +1,53 @@
+package locale
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want language.Tag
+	}{
+		{map[string]string{}, language.English},
+		{map[string]string{"LANG": "C.UTF-8"}, language.English},
+		{map[string]string{"LANG": "de_DE.UTF-8"}, language.MustParse("de-DE")},
+		{map[string]string{"LANG": "de_DE.UTF-8", "LC_NUMERIC": "fr_FR"}, language.MustParse("fr-FR")},
+		{map[string]string{"LANG": "de_DE", "LC_ALL": "en_US.UTF-8@x"}, language.MustParse("en-US")},
+		{map[string]string{"LANG": "not a locale"}, language.English},
+	}
+
+	for _, test := range tests {
+		got := FromEnv(func(name string) string { return test.env[name] })
+		if got != test.want {
+			t.Errorf("%v: expected %s, got %s", test.env, test.want, got)
+		}
+	}
+}
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		tag  language.Tag
+		want string
+	}{
+		{language.English, "58,214,321"},
+		{language.German, "58.214.321"},
+	}
+
+	for _, test := range tests {
+		if got := New(test.tag, nil).Int(58214321); got != test.want {
+			t.Errorf("%s: expected %q, got %q", test.tag, test.want, got)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	f := New(language.English, time.FixedZone("CET", 3600))
+	got := f.Time(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if want := "2024-01-02 04:04:05 CET"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
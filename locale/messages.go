@@ -0,0 +1,56 @@
+package locale
+
+import (
+	"os"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// messages The catalog of the user facing messages. The keys are the
+// English messages, the ones with counts get their plural forms in
+// messages_en.go, the translations are in a file per language.
+var messages = catalog.NewBuilder(catalog.Fallback(language.English))
+
+// MessagesFromEnv Language of the messages from the LC_ALL, LC_MESSAGES
+// or LANG environment variables, in this order.
+func MessagesFromEnv(getenv func(string) string) language.Tag {
+	return fromEnv(getenv, "LC_ALL", "LC_MESSAGES", "LANG")
+}
+
+// Printer translates the messages to a language of the catalog.
+type Printer struct {
+	printer *message.Printer
+}
+
+// NewPrinter Printer of the language of the catalog closest to the tag,
+// English when the catalog has no such language. The numbers of the
+// messages are formatted for the same language.
+func NewPrinter(tag language.Tag) Printer {
+	matched := language.English
+	if _, index, confidence := messages.Matcher().Match(tag); confidence != language.No {
+		matched = messages.Languages()[index]
+	}
+
+	return Printer{printer: message.NewPrinter(matched, message.Catalog(messages))}
+}
+
+// Sprintf Format the translation of the message, the key is the English
+// message with fmt verbs.
+func (p Printer) Sprintf(key string, args ...any) string {
+	return p.printer.Sprintf(key, args...)
+}
+
+// defaultPrinter Printer of the language of the environment, created on
+// the first use once every translation is registered.
+var defaultPrinter = sync.OnceValue(func() Printer {
+	return NewPrinter(MessagesFromEnv(os.Getenv))
+})
+
+// Sprintf Format the translation of the message to the language of the
+// environment.
+func Sprintf(key string, args ...any) string {
+	return defaultPrinter().Sprintf(key, args...)
+}
@@ -0,0 +1,68 @@
+package locale
+
+import (
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+)
+
+// The plural forms of the English messages with counts, the other
+// English messages are their keys.
+func init() {
+	plurals := map[string]catalog.Message{
+		"%d results": plural.Selectf(1, "%d",
+			plural.One, "%d result",
+			plural.Other, "%d results"),
+		"%d occurrences": plural.Selectf(1, "%d",
+			plural.One, "%d occurrence",
+			plural.Other, "%d occurrences"),
+		"Loaded %d results": plural.Selectf(1, "%d",
+			plural.One, "Loaded %d result",
+			plural.Other, "Loaded %d results"),
+		"%d of %d rules passed": plural.Selectf(2, "%d",
+			plural.One, "%d of %d rule passed",
+			plural.Other, "%d of %d rules passed"),
+		"Archived %d new events to %s, %d in total": plural.Selectf(1, "%d",
+			plural.One, "Archived %d new event to %s, %d in total",
+			plural.Other, "Archived %d new events to %s, %d in total"),
+		"Exported %d events": plural.Selectf(1, "%d",
+			plural.One, "Exported %d event",
+			plural.Other, "Exported %d events"),
+		"Opened the session with %d events, execute the query to search again": plural.Selectf(1, "%d",
+			plural.One, "Opened the session with %d event, execute the query to search again",
+			plural.Other, "Opened the session with %d events, execute the query to search again"),
+		"Replayed %d of %d events": plural.Selectf(2, "%d",
+			plural.One, "Replayed %d of %d event",
+			plural.Other, "Replayed %d of %d events"),
+		"Sent %d of %d events": plural.Selectf(2, "%d",
+			plural.One, "Sent %d of %d event",
+			plural.Other, "Sent %d of %d events"),
+		"%s: %d events": plural.Selectf(2, "%d",
+			plural.One, "%s: %d event",
+			plural.Other, "%s: %d events"),
+		"%s: %d events, %+d since the previous run": plural.Selectf(2, "%d",
+			plural.One, "%s: %d event, %+d since the previous run",
+			plural.Other, "%s: %d events, %+d since the previous run"),
+		"%d events, %d fields": plural.Selectf(1, "%d",
+			plural.One, "%d event, %d fields",
+			plural.Other, "%d events, %d fields"),
+		"%d events without %s": plural.Selectf(1, "%d",
+			plural.One, "%d event without %s",
+			plural.Other, "%d events without %s"),
+		"%d events without a numeric %s": plural.Selectf(1, "%d",
+			plural.One, "%d event without a numeric %s",
+			plural.Other, "%d events without a numeric %s"),
+		"%d events, peak %d at %s": plural.Selectf(1, "%d",
+			plural.One, "%d event, peak %d at %s",
+			plural.Other, "%d events, peak %d at %s"),
+		"page %d: DIFFERS, %d events instead of %d": plural.Selectf(2, "%d",
+			plural.One, "page %d: DIFFERS, %d event instead of %d",
+			plural.Other, "page %d: DIFFERS, %d events instead of %d"),
+	}
+
+	for key, msg := range plurals {
+		if err := messages.Set(language.English, key, msg); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package locale
+
+import "golang.org/x/text/language"
+
+// The Hungarian translations. Hungarian nouns stay singular after the
+// numbers, the messages need no plural forms.
+func init() {
+	translations := map[string]string{
+		" (nested)":                      " (beágyazott)",
+		"%d events without %s":           "%d esemény %s nélkül",
+		"%d events without a numeric %s": "%d esemény numerikus %s nélkül",
+		"%d events, %d fields":           "%d esemény, %d mező",
+		"%d events, peak %d at %s":       "%d esemény, csúcs: %d, ekkor: %s",
+		"%d groups added, %d removed, %d changed, %d unchanged since %s": "%d új csoport, %d eltűnt, %d változott, %d változatlan %s óta",
+		"%d occurrences":        "%d előfordulás",
+		"%d of %d rules passed": "%d/%d szabály teljesült",
+		"%d of %d: %s":          "%d/%d: %s",
+		"%d requests, %d from the cache, %d shared, %d sent to Loggly, %d responses cached": "%d kérés, %d a gyorsítótárból, %d közös, %d elküldve a Logglynak, %d válasz tárolva",
+		"%d results":                                "%d találat",
+		"%s pane, %d items":                         "%s panel, %d elem",
+		"%s pane, enter runs the query":             "%s panel, az enter lefuttatja a lekérdezést",
+		"%s, skipping the event":                    "%s, az esemény kimarad",
+		"%s: %d events":                             "%s: %d esemény",
+		"%s: %d events, %+d since the previous run": "%s: %d esemény, %+d az előző futás óta",
+		"-- more -- space: next page, enter: next line, q: quit":    "-- tovább -- szóköz: következő oldal, enter: következő sor, q: kilépés",
+		"-to is deprecated, use -until":                             "a -to elavult, használd a -until kapcsolót",
+		"-tui is deprecated, use `loggly tui [options] [query...]`": "a -tui elavult, használd a `loggly tui [options] [query...]` formát",
+		"A concurrency of %d may hit the rate limits of Loggly or get the account blocked for a while, lower it if the requests fail.": "%d párhuzamos kérés elérheti a Loggly korlátait, vagy egy időre letilthatja a fiókot, csökkentsd, ha a kérések hibát adnak.",
+		"A poll reached the page limits, some events may be missing, raise -maxPages or -size or lower -interval":                      "Egy lekérdezés elérte a lapkorlátot, néhány esemény hiányozhat, növeld a -maxPages vagy -size értékét, vagy csökkentsd az -interval értékét",
+		"Added to query: %s:%s":                     "Hozzáadva a lekérdezéshez: %s:%s",
+		"Archived %d new events to %s, %d in total": "%d új esemény archiválva ide: %s, összesen %d",
+		"Authentication failed for account %q: %s":  "Sikertelen azonosítás a(z) %q fiókhoz: %s",
+		"Cancelled":                   "Megszakítva",
+		"Copied to clipboard":         "Vágólapra másolva",
+		"Copy failed: %s":             "A másolás nem sikerült: %s",
+		"Deleted the saved search %s": "A(z) %s mentett keresés törölve",
+		"Downloaded:      %s":         "Letöltve:          %s",
+		"Editing the note of the event, enter saves it, escape cancels": "Az esemény jegyzetének szerkesztése, az enter menti, az escape elveti",
+		"Error: %s":                                     "Hiba: %s",
+		"Error: %s: %s":                                 "Hiba: %s: %s",
+		"Error: line %s: %s":                            "Hiba: %s. sor: %s",
+		"Error: the interactive mode failed: %s":        "Hiba: az interaktív mód leállt: %s",
+		"Esc: Cancel":                                   "Esc: Megszakítás",
+		"Estimated size:  ~%s":                          "Becsült méret:     ~%s",
+		"Every %s: %s":                                  "%s időközönként: %s",
+		"Export failed: %s":                             "Az exportálás nem sikerült: %s",
+		"Exported %d events to %s, %d of them new":      "%d esemény exportálva ide: %s, ebből %d új",
+		"Exported %d events":                            "%d esemény exportálva",
+		"Exported the events to %s":                     "Az események exportálva ide: %s",
+		"Exported the field analysis to %s":             "A mezőelemzés exportálva ide: %s",
+		"Fetch events?":                                 "Lekéred az eseményeket?",
+		"Fetched events:  %s":                           "Lekért események:  %s",
+		"Fetched pages:   %s":                           "Lekért oldalak:    %s",
+		"Fields":                                        "Mezők",
+		"Flags must come before the query, ignoring %s": "A kapcsolóknak a lekérdezés előtt a helyük, figyelmen kívül hagyva: %s",
+		"Focus: %s":                                     "Fókusz: %s",
+		"HTTP requests:   %s":                           "HTTP kérések:      %s",
+		"Help topics:":                                  "Súgótémák:",
+		"Help":                                          "Súgó",
+		"History, %d items":                             "Előzmények, %d elem",
+		"Invalid message in the %s field. Filter the messages, run without -strict, or print the whole events with -all and parse the message yourself.\n\n%s": "Érvénytelen üzenet a(z) %s mezőben. Szűrd az üzeneteket, futtasd -strict nélkül, vagy írasd ki a teljes eseményeket a -all kapcsolóval, és dolgozd fel magad az üzenetet.\n\n%s",
+		"Loaded %d results":                    "%d találat betöltve",
+		"Loading, please wait, escape cancels": "Betöltés, kérlek várj, az escape megszakítja",
+		"Loading...":                           "Betöltés...",
+		"Matching events: %s":                  "Egyező események:  %s",
+		"Note removed":                         "Jegyzet törölve",
+		"Note saved":                           "Jegyzet mentve",
+		"Note: %s":                             "Jegyzet: %s",
+		"Notes are not available":              "A jegyzetek nem érhetők el",
+		"Notes are not available: %s":          "A jegyzetek nem érhetők el: %s",
+		"Nothing to export":                    "Nincs mit exportálni",
+		"Nothing to export, run a query first": "Nincs mit exportálni, előbb futtass egy lekérdezést",
+		"OK: the token can search account %q":  "OK: a token kereshet a(z) %q fiókban",
+		"Opened the session with %d events, execute the query to search again": "Munkamenet megnyitva %d eseménnyel, az újabb kereséshez futtasd a lekérdezést",
+		"Page latency:    %s":      "Oldalak ideje:     %s",
+		"Pages to fetch:  %s":      "Lekérendő oldalak: %s",
+		"Pinned %s":                "Kitűzve: %s",
+		"Query":                    "Lekérdezés",
+		"Query: %s":                "Lekérdezés: %s",
+		"Ready":                    "Kész",
+		"Replayed %d of %d events": "%d/%d esemény újraküldve",
+		"Result %d of %d":          "%d/%d. találat",
+		"Result Detail":            "Találat részletei",
+		"Results":                  "Találatok",
+		"Saved snapshot %s: %d events in %d groups": "A(z) %s pillanatkép elmentve: %d esemény %d csoportban",
+		"Saved the search %s":                       "A(z) %s keresés elmentve",
+		"Saved the session to %s":                   "Munkamenet mentve ide: %s",
+		"Saving the note failed: %s":                "A jegyzet mentése nem sikerült: %s",
+		"Saving the note of %s failed: %s":          "A(z) %s jegyzetének mentése nem sikerült: %s",
+		"Saving the session failed: %s":             "A munkamenet mentése nem sikerült: %s",
+		"Searchable after %s":                       "Kereshető %s után",
+		"Selected leaf field: %s":                   "Kiválasztott mező: %s",
+		"Selected nested field: %s":                 "Kiválasztott beágyazott mező: %s",
+		"Sent %d of %d events":                      "%d/%d esemény elküldve",
+		"Sent probe %s, accepted in %s":             "A(z) %s próba elküldve, %s alatt fogadva",
+		"Serving the Loggly API on %s, use it with -daemon %s or LOGGLY_DAEMON": "A Loggly API kiszolgálása itt: %s, használd a -daemon %s kapcsolóval vagy a LOGGLY_DAEMON változóval",
+		"State: %s": "Állapot: %s",
+		"Suggestion: %s, the right arrow accepts it": "Javaslat: %s, a jobbra nyíl elfogadja",
+		"Tab/Shift+Tab: Switch panes • Enter: Execute/Select/View • Backspace: Go up • x/X: Export fields as CSV/JSON • a: Note • A: Annotated only • e: Export events • p/m: Pin field/Select event • Ctrl+S: Save session • Ctrl+R: History • F1: Help • q: Quit": "Tab/Shift+Tab: Panelváltás • Enter: Futtatás/Kiválasztás/Megtekintés • Backspace: Fel • x/X: Mezők exportálása CSV/JSON formában • a: Jegyzet • A: Csak jegyzetelt • e: Események exportálása • p/m: Mező kitűzése/Esemény kiválasztása • Ctrl+S: Munkamenet mentése • Ctrl+R: Előzmények • F1: Súgó • q: Kilépés",
+		"The event has no id to attach the note to": "Az eseménynek nincs azonosítója, amelyhez a jegyzet kapcsolható",
+		"The field cache could not be updated: %s":  "A mezőgyorsítótár nem frissíthető: %s",
+		"The history could not be read: %s":         "Az előzmények nem olvashatók: %s",
+		"The history could not be updated: %s":      "Az előzmények nem frissíthetők: %s",
+		"The history is off":                        "Az előzmények ki vannak kapcsolva",
+		"The page limits were reached, export again with -diff-against to get the newer events":                           "Elérted az oldalkorlátot, az újabb eseményekért exportálj újra a -diff-against kapcsolóval",
+		"The page limits were reached, only the first %d events are counted, raise -maxPages or -size":                    "Elérted az oldalkorlátot, csak az első %d esemény számít, növeld a -maxPages vagy a -size értékét",
+		"The page limits were reached, run the sync again to archive the newer events":                                    "Elérted az oldalkorlátot, az újabb események archiválásához futtasd újra a szinkronizálást",
+		"The sample is drawn from the first %d of the %d matching events, raise -maxPages or -size to sample all of them": "A minta csak az első %d eseményből készült a(z) %d egyező közül, növeld a -maxPages vagy a -size értékét, hogy mindegyikből mintavételezzen",
+		"The saved searches of Loggly could not be listed, only the local ones are used: %s":                              "A Loggly mentett keresései nem listázhatók, csak a helyiek használhatók: %s",
+		"Timed out after %s":                 "Időtúllépés ennyi után: %s",
+		"Unknown field %s, did you mean %s?": "Ismeretlen mező: %s, erre gondoltál: %s?",
+		"Unpinned %s":                        "Kitűzés törölve: %s",
+		"Values":                             "Értékek",
+		"Wall time:       %s":                "Teljes idő:        %s",
+		"Warning: %s":                        "Figyelmeztetés: %s",
+		"annotated only":                     "csak jegyzetelt",
+		"close detail":                       "részletek bezárása",
+		"copy":                               "másolás",
+		"execute query":                      "lekérdezés futtatása",
+		"export csv":                         "csv exportálás",
+		"export json":                        "json exportálás",
+		"export with notes":                  "exportálás jegyzetekkel",
+		"formatted view":                     "formázott nézet",
+		"go up":                              "fel",
+		"jq: %s, skipping the event":         "jq: %s, az esemény kimarad",
+		"marked: ":                           "kijelölve: ",
+		"next result":                        "következő találat",
+		"note":                               "jegyzet",
+		"note: ":                             "jegyzet: ",
+		"page %d: DIFFERS, %d events instead of %d":   "%d. oldal: ELTÉR, %d esemény %d helyett",
+		"page %d: DIFFERS, checksum %s instead of %s": "%d. oldal: ELTÉR, %s ellenőrzőösszeg %s helyett",
+		"page %d: ok":     "%d. oldal: ok",
+		"pin":             "kitűzés",
+		"pinned: ":        "kitűzve: ",
+		"previous result": "előző találat",
+		"raw view":        "nyers nézet",
+		"searching without a subcommand is deprecated, use `loggly search [options] [query...]`": "az alparancs nélküli keresés elavult, használd a `loggly search [options] [query...]` formát",
+		"select field":       "mező kiválasztása",
+		"select for session": "kiválasztás a munkamenethez",
+		"select value":       "érték kiválasztása",
+		"view detail":        "részletek",
+		"↑/↓: Scroll • Esc/F1: Close • q: Quit":                                    "↑/↓: Görgetés • Esc/F1: Bezárás • q: Kilépés",
+		"↑/↓: Scroll • n/p: Next/Previous • y: Copy • Esc: Back to list • q: Quit": "↑/↓: Görgetés • n/p: Következő/Előző • y: Másolás • Esc: Vissza a listához • q: Kilépés",
+		"↑/↓: Select • /: Filter • Enter: Run • Esc/Ctrl+R: Close • q: Quit":       "↑/↓: Kiválasztás • /: Szűrés • Enter: Futtatás • Esc/Ctrl+R: Bezárás • q: Kilépés",
+	}
+
+	for key, msg := range translations {
+		if err := messages.SetString(language.Hungarian, key, msg); err != nil {
+			panic(err)
+		}
+	}
+}
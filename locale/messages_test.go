@@ -0,0 +1,40 @@
+package locale
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMessagesFromEnv(t *testing.T) {
+	env := map[string]string{"LANG": "de_DE.UTF-8", "LC_NUMERIC": "fr_FR", "LC_MESSAGES": "hu_HU.UTF-8"}
+	got := MessagesFromEnv(func(name string) string { return env[name] })
+	if want := language.MustParse("hu-HU"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	tests := []struct {
+		tag  language.Tag
+		key  string
+		args []any
+		want string
+	}{
+		{language.English, "Sent %d of %d events", []any{1, 1}, "Sent 1 of 1 event"},
+		{language.English, "Sent %d of %d events", []any{1200, 1500}, "Sent 1,200 of 1,500 events"},
+		{language.English, "%d results", []any{1}, "1 result"},
+		{language.English, "Warning: %s", []any{"x"}, "Warning: x"},
+		{language.MustParse("hu-HU"), "Sent %d of %d events", []any{1, 2}, "1/2 esemény elküldve"},
+		{language.MustParse("hu-HU"), "Warning: %s", []any{"x"}, "Figyelmeztetés: x"},
+		// untranslated messages and languages fall back to English
+		{language.MustParse("hu-HU"), "not in the catalog %d", []any{3}, "not in the catalog 3"},
+		{language.German, "%d results", []any{1}, "1 result"},
+	}
+
+	for _, test := range tests {
+		if got := NewPrinter(test.tag).Sprintf(test.key, test.args...); got != test.want {
+			t.Errorf("%s %q: expected %q, got %q", test.tag, test.key, test.want, got)
+		}
+	}
+}
@@ -1,184 +1,637 @@
 package main
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/Ajnasz/go-loggly-cli/help"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/reservoir"
 	"github.com/Ajnasz/go-loggly-cli/search"
 )
 
 var version string
 
-const usage = `
-  Usage: loggly [options] [query...]
+// usage The options and the commands, followed by the query syntax of
+// the help topics.
+var usage = `
+  Usage: loggly search [options] [query...]
+         loggly tui [options] [query...]
 
 
   Options:
 
-    -account <name>   account name
-    -token <word>     user token
+    -account <name>   account name, comma separated or repeated to query several accounts
+    -token <word>     user token, "-" reads it from the standard input, comma separated for several accounts
+                      [the output of the credential_helper of the config file]
+    -token-file <file> read the user token from the file
+    -auth <scheme>    send the token as a "bearer" token, as the user:password of "basic"
+                      authentication, or as the value of the -auth-header of a gateway
+                      fronting Loggly with "header" [defaults.auth, bearer]
+    -auth-header <name> the header of -auth header, e.g. X-Gateway-Token [defaults.auth-header]
+    -q <query>        the query, "-" reads it from the standard input
+    -query-file <file> read the query from the file, lines starting with # are comments
+    -input <archive>  search a local archive, archive://<name>, instead of Loggly
     -size <count>     response event count [100]
-    -from <time>      starting time [-24h]
-    -to <time>        ending time [now]
+    -from <time>      starting time, relative like -24h or -7d, ISO 8601, a unix epoch
+                      or an expression like "yesterday 14:00" or "2 days ago" [-24h]
+    -until <time>     ending time [now]
+    -to <time>        deprecated alias of -until
     -count            print total event count
-    -all              print the entire loggly event instead of just the message
+    -group-by <fields> with -count, print a table of the counts per distinct values of the
+                      comma separated fields, counted over the fetched events kept by
+                      -grep, -grep-v and -where
+    -watch <duration> run the search or the count again every duration, clearing the terminal,
+                      and print how the number of the events changed since the previous run
+    -estimate         print the expected cost of the query before fetching it
+    -strict           refuse to run queries with lint warnings (leading wildcards, regexps over long ranges, ...)
+                      and fail on messages which are not JSON
+    -dry-run          print the HTTP requests of the query, with the token redacted, without sending them
+    -print-curl       print an equivalent curl command of every request to the standard error
+    -curl-token-env   use $LOGGLY_TOKEN instead of the token in the printed curl commands
+    -all              print the entire loggly event instead of just the message, as the API returned it
+    -raw              print the messages which are not JSON as they are, instead of {"raw": "<message>"}
+    -message-field <fields> the comma separated fields of the loggly event holding the message,
+                      the first one the event has is used, e.g. logmsg,event.json [logmsg]
+    -merge            print the message enriched with the -merge-fields of the loggly event,
+                      the fields of the message win
+    -merge-fields <fields> the comma separated fields of the loggly event added with -merge,
+                      from timestamp, tags, logtypes, host and id [timestamp,tags,logtypes,host,id]
+    -format <format>  print the events as "ndjson", a compact JSON object per line, as a
+                      "json-array", as a "table", as "csv", "tsv", "logfmt", as "es-bulk"
+                      actions of the Elasticsearch _bulk API, as "otlp" OpenTelemetry
+                      log records, rendered by the -template, or written into the -o
+                      file as "parquet"
+                      [format.tty or format.pipe of the config file, ndjson]
+    -columns <fields> the comma separated fields printed with -format csv, tsv, logfmt and parquet,
+                      e.g. json.level,json.hostname
+                      [the fields of the first event]
+    -template <tmpl>  the Go text/template printing an event per line with -format template,
+                      e.g. '{{.json.timestamp}} {{.json.level}} {{.json.msg}}'
+    -index <name>     the Elasticsearch index of the -format es-bulk actions
+    -tz <zone>        print the timestamp of the loggly events and the ISO 8601 timestamps of
+                      the messages in the time zone, e.g. Europe/Budapest
+    -utc              print the timestamps in UTC
+    -time-format <format> the format of the timestamps printed with -tz and -utc, "rfc3339",
+                      "rfc3339ms", "rfc3339nano", "datetime", "unix", "unixms"
+                      or a Go time layout like "2006-01-02 15:04:05" [rfc3339ms]
+    -pretty           indent the printed JSON
+    -color <when>     highlight the printed JSON and color the lines by the level of the event:
+                      "auto" on terminals unless $NO_COLOR is set, "always" or "never" [auto]
+    -level-field <fields> the comma separated fields holding the level of the event, the first
+                      one the event has is used [level,severity,json.level,json.severity]
+    -highlight        highlight the terms of the query in the colored output, the negated
+                      terms and the ranges excluded
+    -highlight-regex <regexp> highlight the matches of the regular expression as well
+    -o <file>         write the events to the file instead of the standard output, gzip
+                      compressed when its name ends with .gz
+    -output <file>    same as -o
+    -tee              with -o, print the events to the standard output as well, in the
+                      -format, while the file gets them in format.pipe of the config
+                      file or as JSON lines
+    -rotate-size <size> with -o, split the events into numbered parts of this size, like 500M
+                      or 2G, counted before the compression: events-0001.ndjson.gz
+    -page-size <lines> on terminals stop after every page of lines until a key is pressed, the
+                      pages of the query are fetched as they are reached [0, no paging]
+    -no-pager         do not pipe the events printed to a terminal through the $PAGER
+    -no-field-cache   do not record the fields and values of the events for the completions
     -maxPages <count> maximum number of pages to query [3]
+    -max-events <count> stop after printing this many events across all pages, counted after
+                      -grep, -grep-v, -where, -jq and -dedup [0, unlimited]
+    -sample-output <count> print a uniform random sample of this many events from all fetched pages
+    -dedup            print the events repeated across the pages once, by their Loggly id
+    -dedup-by <field> print once the events with the same value of the field, e.g. json.requestId
+    -sort <field>     print the events ordered by the field once every page is fetched, numbers
+                      by value, the events without the field last
+    -desc             with -sort, print the largest values first
+    -where <expr>     print only the events matching the expression, e.g. 'json.status >= 500 && json.duration > 1000'
+    -grep <regexp>    print only the events whose JSON matches the regular expression
+    -grep-v <regexp>  leave out the events whose JSON matches the regular expression
+    -jq <expr>        filter and transform the events with a jq expression after -where, e.g.
+                      'select(.level == "error") | .message'
+    -fields <fields>  print only the comma separated fields of the events, nested fields by
+                      their dotted path, e.g. json.level,json.http.status
+    -exclude-fields <fields> remove the comma separated fields from the printed events
+    -flatten          print the nested objects of the events as a single level object,
+                      keyed by the paths of the values, e.g. {"http.status": 200}
+    -flatten-separator <sep> the separator of the -flatten keys [.]
+    -fail-empty       exit with 5 when no event matched
+    -value-histogram <field> print the distribution of the numeric field instead of the events
+    -buckets <count>  number of -value-histogram buckets [10]
+    -pivot <row,column> print the number of events per value of the two fields as a table instead
+                      of the events, e.g. json.service,json.level
+    -proxy <url>      send requests through the proxy, e.g. http://proxy:3128 [$HTTPS_PROXY]
+    -endpoint <url>   API base instead of loggly.com/apiv2, prefixed with the account subdomain,
+                      unless it is a full URL like http://127.0.0.1:8080/apiv2 [$LOGGLY_ENDPOINT]
+    -ca-cert <file>   trust the PEM encoded CA certificates in the file as well
+    -client-cert <file> PEM encoded client certificate for mutual TLS
+    -client-key <file>  PEM encoded key of the client certificate
+    -insecure-skip-verify do not verify the server certificate (unsafe)
+    -timeout <duration>  abort the whole run after the duration, in the TUI every query,
+                      e.g. 2m [0, no timeout]
+    -page-timeout <duration> abort a single HTTP request after the duration, e.g. 30s [0, no timeout]
     -concurrency <count> number of concurrent page fetchers [3]. If loggly returns with http error consider reducing this value.
-    -tui              launch interactive terminal UI
+    -shared-budget <file> share the request budget with the other invocations using the same file,
+                      e.g. ~/.cache/loggly/budget
+    -shared-budget-rate <count> requests per minute allowed by the shared budget [60]
+    -daemon <socket>  send the requests through the loggly daemon listening on the socket
+                      [defaults.daemon]
+    -tui              launch interactive terminal UI, deprecated, use loggly tui
+    -accessible       high contrast terminal UI without animations, one pane at a time and the
+                      state and the selection announced in words [defaults.accessible]
+    -quiet            do not print warnings, for scripts and cron jobs
+    -error-format <format> print errors as "text" or as a "json" object with code, message, status
+                      and whether the failure is transient [text]
+    -verbose          log the requests, their status and timing to the standard error
+    -debug            log the paging decisions and request details as well
+    -stats            print a summary of the matching and fetched events, the pages, their
+                      latencies, the downloaded bytes and the wall time to the standard error
+    -defaults         print the effective size, maxPages, concurrency, from and to values and their source
     -version          print version information
 
-  Operators:
+  Exit codes:
 
-    "foo bar" AND baz
-    foo AND bar NOT baz
-    +foo +bar -baz
-    foo OR bar
-    json.responseTime[50 TO 100]
-    json.duration[1000 TO *]
+    0 success, 1 error, 2 invalid usage, 3 authentication failed,
+    4 rate limited by Loggly, 5 no events matched with -fail-empty
 
-  Fields:
+  Commands:
 
-    json.level:error
-    json.type:"upload failed"
-    json.hostname:"api-*"
+    search [options] [query...] print the matching events
+    tui [options] [query...]    explore the events in the interactive terminal UI
+    archive sync -name <name> [options] [query...]
+                               mirror the new events of the query into a local archive,
+                               -index <fields> selects the indexed fields
+    archive list               print the local archives
+    export [options] [query...] print the whole events oldest first, -manifest <file>
+                               records the export, -diff-against <file> prints only the
+                               events newer than the export of the manifest, -verify <file>
+                               fetches -verify-pages <count> random pages of the export of
+                               the manifest again and compares their checksums [3]
+    export sqlite -db <file> [options] [query...]
+                               write the whole events into a table of a SQLite database, a
+                               row per event with its id, timestamp, the -columns <fields>
+                               and the event as JSON, -table <name> [events]
+    assert [options] <rules.yaml> run the golden queries of the rules file and print a JSON
+                               report, exits with 6 when an expectation is not met
+    audit [options]            print the audit log of the executed queries, -from <time>
+                               and -user <name> filter it, -json prints the entries as JSON
+                               lines, -file <path> reads another log
+    history [options]          print the last executed queries with their numbers and result
+                               counts, -n <count> [20], 0 prints all, -json prints the
+                               entries as JSON lines
+    rerun <n> [options]        search or count again with the query, the account and the
+                               time range of the query numbered n in the history, -from and
+                               -until override the range
+    auth check [options]       verify that the account and the token work
+    batch [options] <file>     run every line of the file as a query, prefixing the
+                               events with the line number, -parallel <count> runs
+                               several at once [1], -count prints the counts only
+    daemon [options]           serve the API to the invocations using -daemon over a unix socket,
+                               sharing the connections, a response cache and the request rate,
+                               -socket <path> [daemon.sock in the state directory],
+                               -cache-ttl <duration> [1m], -cache-size <count> [256],
+                               -rate <count> requests per minute [0, no limit],
+                               -status prints the counters of the running daemon
+    demo [options] [query...]  try the CLI and the TUI on a built-in fake dataset,
+                               no credentials needed
+    lag [options]              send a probe event through the ingest endpoint and print how
+                               long until it is searchable, -ingest-token <token> is the
+                               customer token [$LOGGLY_INGEST_TOKEN], -wait <duration> [5m]
+    monitor [options] -q <query> [query...]
+                               keep a line per query with the count of its events in the
+                               last -interval <duration> [30s] and a sparkline of the last 60
+    send [options] [file...]   post every line of the files, or of the standard input, as an
+                               event to the bulk ingest endpoint, -tag <tags> tags them,
+                               -profile <name> selects the ingest token of the config file
+    replay [options] <file>    re-send the exported events to the ingest endpoint keeping their
+                               timing, -speed <factor> speeds it up, e.g. 10x or max [1x],
+                               -rewrite-timestamps, -redact <fields>, -profile <name>
+    open [options] <file>      open a session file saved in the TUI with Ctrl+S, showing
+                               its query, time range, pinned fields, notes and events
+    snapshot save -by <fields> [options] <name> [query...]
+                               count the events of the query by the values of the fields
+                               and store the counts under the name
+    snapshot diff [options] <name>
+                               run the query of the snapshot again and print the groups
+                               added, removed and changed since it was saved
+    snapshot list              print the saved snapshots
+    help [topic|words...]      print the help topics, a topic like query-syntax or
+                               time-ranges, or the topics and examples mentioning the words
+    examples [words...]        print example command lines, the ones mentioning the words
+    tail [options] [query...]  print the new events as they become searchable, like tail -f,
+                               polling every -interval <duration> [5s] from -lag <duration>
+                               before [1m], the first poll from -from [-1m]
+    completion bash|zsh|fish   print the shell completion script, completing the commands, the
+                               flags and the fields and values seen in the earlier results
+    histogram [options] [query...]
+                               print the number of the events per -interval <duration> [5m]
+                               as a table and a sparkline, a count request per bucket
+    stats -field <field> [options] [query...]
+                               print the count, min, max, mean, median, p95 and p99 of the
+                               numeric field over the fetched events, -by <field> per its values
+    top -field <field> [options] [query...]
+                               print the -n <count> [10] most frequent values of the field over
+                               the fetched events, with their counts and shares
+    analyze [options] [query...] print every field of the fetched events with the number of the
+                               events having it, its distinct values and its -top <count> [5]
+                               most frequent values, -json prints a JSON report
+    distinct -field <field> [options] [query...]
+                               print the unique values of the field across the fetched pages,
+                               each as soon as it is seen, -counts prints them at the end with
+                               their counts, tab separated, the most frequent first
+    saved list [options]       print the saved searches of Loggly and the local ones
+    saved run <name> [options] search with the query and the time range of the saved search,
+                               the local one first, -from and -until override the range
+    saved save [options] <name> [query...]
+                               save the query locally under the name, with -from and -until
+                               when given
+    saved delete <name>        delete the local saved search
+` + help.Usage()
 
-  Grouping:
+// Print usage and exit.
+func printUsage() {
+	fmt.Print(usage)
+	os.Exit(0)
+}
 
-    foo AND (bar OR baz)
+// execCount Print the number of matching events, per account when
+// several are given, and return their sum.
+func execCount(ctx context.Context, config Config, query string) int64 {
+	configs, err := config.accountConfigs()
+	check(err)
 
-  Regexps:
+	var sum int64
+	for _, accountConfig := range configs {
+		total := countEvents(ctx, accountConfig, query)
+		if len(configs) > 1 {
+			fmt.Printf("%s\t%d\n", accountConfig.Account, total)
+		} else {
+			fmt.Println(total)
+		}
+		sum += total
+	}
 
-    /Black(Berry)?/
-`
+	return sum
+}
 
-type Config struct {
-	Account     string
-	Token       string
-	Size        int
-	From        string
-	To          string
-	AllMsg      bool
-	MaxPages    int64
-	Concurrency int
-	Debug       bool
+func countEvents(ctx context.Context, config Config, query string) int64 {
+	total, err := fetchCount(ctx, config, query)
+	check(err)
+	return total
 }
 
-func (c Config) Validate() error {
-	if c.Account == "" {
-		return fmt.Errorf("account is required")
+// fetchCount Fetch the number of events matching the query.
+func fetchCount(ctx context.Context, config Config, query string) (int64, error) {
+	c, clientErr := config.newClient()
+	if clientErr != nil {
+		return 0, clientErr
 	}
-	if c.Token == "" {
-		return fmt.Errorf("token is required")
+	q := search.NewQuery(query).Size(1).From(config.From).To(config.To)
+	res, err := c.Fetch(ctx, *q)
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case r := <-res:
+			return r.Total, nil
+		case e := <-err:
+			return 0, e
+		}
 	}
-	if c.MaxPages <= 0 {
-		return fmt.Errorf("maxPages must be greater than 0")
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
 	}
-	return nil
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
-// Print usage and exit.
-func printUsage() {
-	fmt.Print(usage)
-	os.Exit(0)
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
 }
 
-func check(err error) {
+// readQuery Read a query written in multiple lines, lines starting
+// with # are comments.
+func readQuery(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 1<<20))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s", err)
-		os.Exit(1)
+		return "", fmt.Errorf("reading the query: %w", err)
 	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, " "), nil
 }
 
-func printJSON(events []any) error {
-	for _, event := range events {
-		data, err := json.Marshal(event)
-		if err != nil {
-			return err
+// resolveQuery The query from -q, -query-file or the arguments. Like
+// other Unix filters read it from a pipe when none is given, unless the
+// token is read from there.
+func resolveQuery(config Config, args []string) (string, error) {
+	given := 0
+	for _, set := range []bool{len(args) > 0, config.Query != "", config.QueryFile != ""} {
+		if set {
+			given++
 		}
+	}
+	if given > 1 {
+		return "", usageError(fmt.Errorf("give the query either as arguments, with -q or with -query-file"))
+	}
 
-		fmt.Println(string(data))
+	if (config.Query == "-" || config.QueryFile == "-") && config.Token == "-" {
+		return "", usageError(fmt.Errorf("the standard input can not provide both the token and the query"))
 	}
 
-	return nil
+	switch {
+	case config.Query == "-", config.QueryFile == "-":
+		return readQuery(os.Stdin)
+	case config.Query != "":
+		return config.Query, nil
+	case config.QueryFile != "":
+		f, err := os.Open(config.QueryFile)
+		if err != nil {
+			return "", usageError(fmt.Errorf("reading the query file: %w", err))
+		}
+		defer f.Close()
+		return readQuery(f)
+	case len(args) == 0 && config.Token != "-" && !isTerminal(os.Stdin):
+		return readQuery(os.Stdin)
+	default:
+		return strings.Join(args, " "), nil
+	}
 }
 
-func printLogMSG(events []any) error {
-	var ret []any
+func confirm(prompt string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", locale.Sprintf(prompt))
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
 
-	for i, event := range events {
-		msg := event.(map[string]any)["logmsg"].(string)
-		m := make(map[string]any)
-		if err := json.Unmarshal([]byte(msg), &m); err != nil {
-			return fmt.Errorf("Error at event %d: %w", i+1, err)
-		}
+// execEstimate reports the cost of the query. It returns true if the
+// query should be executed afterwards.
+func execEstimate(ctx context.Context, config Config, query string) bool {
+	c, err := config.newClient()
+	check(err)
+	q := config.newQuery(query)
+	e, err := c.Estimate(ctx, *q)
+	check(err)
+
+	interactive := isTerminal(os.Stdin) && isTerminal(os.Stderr)
+	out := os.Stdout
+	if interactive {
+		out = os.Stderr
+	}
+
+	fmt.Fprintln(out, locale.Sprintf("Matching events: %s", locale.Int(e.Total)))
+	fmt.Fprintln(out, locale.Sprintf("Pages to fetch:  %s", locale.Int(e.Pages)))
+	fmt.Fprintln(out, locale.Sprintf("HTTP requests:   %s", locale.Int(e.Requests)))
+	fmt.Fprintln(out, locale.Sprintf("Estimated size:  ~%s", formatBytes(e.Bytes)))
 
-		ret = append(ret, m)
+	if !interactive {
+		return false
 	}
 
-	return printJSON(ret)
+	return confirm("Fetch events?")
 }
 
-func execCount(ctx context.Context, config Config, query string) {
-	c := search.New(config.Account, config.Token)
-	q := search.NewQuery(query).Size(1).From(config.From).To(config.To)
-	res, err := c.Fetch(ctx, *q)
-	for {
-		select {
-		case <-ctx.Done():
-			check(ctx.Err())
-			return
-		case r := <-res:
-			fmt.Println(r.Total)
-			return
-		case e := <-err:
-			check(e)
-			return
+// execDryRun prints the requests the query would make, with the token
+// redacted, without sending them.
+func execDryRun(ctx context.Context, config Config, query string) {
+	c, err := config.newClient()
+	check(err)
+
+	requests, err := c.PlanRequests(ctx, *config.newQuery(query))
+	check(err)
+
+	for _, r := range requests {
+		if config.PrintCurl {
+			fmt.Println(curlCommand(r, config.CurlTokenEnv, config.AuthHeader))
+			continue
+		}
+
+		fmt.Printf("%s %s\n", r.Method, r.URL)
+		for _, name := range slices.Sorted(maps.Keys(r.Header)) {
+			for _, value := range r.Header[name] {
+				fmt.Printf("%s: %s\n", name, redactHeader(name, value, config.AuthHeader))
+			}
 		}
+		fmt.Println()
 	}
 }
 
-func printRes(allMsg bool, res search.Response) {
-	if allMsg {
-		check(printJSON(res.Events))
-		return
+func redactHeader(name, value, authHeader string) string {
+	if authHeader != "" && strings.EqualFold(name, authHeader) {
+		return "<redacted>"
+	}
+	if !strings.EqualFold(name, "Authorization") {
+		return value
+	}
+
+	scheme, _, ok := strings.Cut(value, " ")
+	if !ok {
+		return "<redacted>"
+	}
+	return scheme + " <redacted>"
+}
+
+// decodeRes Events of the response to print: the whole events with
+// -all, the parsed messages otherwise, with -merge enriched with the
+// envelope fields.
+func decodeRes(config Config, res search.Response) ([]any, bool) {
+	if config.AllMsg {
+		return res.Events, true
+	}
+
+	events, err := parseLogMSG(res.Events, splitList(config.MessageField), config.Strict, config.RawText)
+	if err != nil {
+		fmt.Fprint(os.Stderr, locale.Sprintf("Invalid message in the %s field. Filter the messages, run without -strict, or print the whole events with -all and parse the message yourself.\n\n%s", config.MessageField, err))
+		return nil, false
 	}
 
-	if err := printLogMSG(res.Events); err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid JSON in the 'logmsg' field. Consider to filter the messages, or use the -all flag and parse the message yourself.\n\n%s", err.Error())
+	if config.Merge {
+		mergeEnvelope(res.Events, events, splitList(config.MergeFields))
 	}
+
+	// the events without message are dropped after merging, which pairs
+	// the messages with their events by index
+	return slices.DeleteFunc(events, func(e any) bool { return e == nil }), true
 }
 
+// sendQuery Fetch and print the events, returns the number of printed
+// events.
 func sendQuery(
 	ctx context.Context,
 	config Config,
 	query string,
-) {
-	c := search.New(config.Account, config.Token).SetConcurrency(config.Concurrency)
-	q := search.NewQuery(query).Size(config.Size).From(config.From).To(config.To).MaxPage(config.MaxPages)
-	res, err := c.Fetch(ctx, *q)
+) int {
+	config.raw = config.rawPassthrough()
+	limit := newEventLimit(&config)
+	// the fetching stops once -max-events of the matching events are kept
+	fetchCtx, stopFetching := context.WithCancel(ctx)
+	defer stopFetching()
+	stream, fetchErr := fetchEvents(fetchCtx, config, query)
+	check(fetchErr)
+	res, err := stream.Responses, stream.Errors
+
+	out, outErr := newOutput(config, query)
+	check(outErr)
+	defer func() { check(out.Close()) }()
+	pager, pagerErr := startPager(config)
+	check(pagerErr)
+	if pager != nil {
+		out.pageStdout(pager)
+	}
+
+	filters, filtersErr := newEventFilters(config)
+	check(filtersErr)
+	dedup := newDedupFilter(config)
+	fields := newFieldRecorder(config)
+	defer fields.Save(config)
 
+	// With -value-histogram and -pivot the events are summarized instead
+	// of printed.
+	write := out.Write
+	hist := newValueHistogram(config)
+	if hist != nil {
+		write = hist.Write
+	}
+	pivot := newPivotTable(config)
+	if pivot != nil {
+		write = pivot.Write
+	}
+
+	// With sampling nothing is printed until every page is fetched. The
+	// sample is drawn from the fetched pages only, fetched and total tell
+	// whether they hold every matching event.
+	var sample *reservoir.Reservoir[any]
+	if config.SampleOutput > 0 {
+		sample = reservoir.New[any](config.SampleOutput, nil)
+	}
+
+	// With -sort nothing is printed until every page is fetched either.
+	sorter := newEventSorter(config)
+
+	printed := 0
+	var fetched, total int64
 	for {
 		select {
 		case <-ctx.Done():
 			check(ctx.Err())
-			return
+			return printed
 		case r := <-res:
-			printRes(config.AllMsg, r)
+			if r.Raw != nil {
+				fields.AddRaw(r.Raw)
+				if pagerQuit(out.WriteRaw(r.Raw)) {
+					return printed
+				}
+				printed += len(r.Raw)
+				continue
+			}
+
+			if limit.Reached() {
+				continue
+			}
+			fetched += int64(r.Len())
+			total = max(total, r.Total)
+			fields.Add(r.Events)
+			r.Events = dedup.Apply(r.Events)
+			events, ok := decodeRes(config, r)
+			if !ok {
+				continue
+			}
+			events = limit.Apply(filters.Apply(events))
+			if limit.Reached() {
+				stopFetching()
+			}
+
+			if sample != nil {
+				for _, event := range events {
+					sample.Add(event)
+				}
+				continue
+			}
+			if sorter != nil {
+				sorter.Add(events)
+				continue
+			}
+			if pagerQuit(write(events)) {
+				return printed
+			}
+			printed += len(events)
 		case e := <-err:
-			check(e)
-			return
+			if !limit.Reached() || !errors.Is(e, context.Canceled) {
+				check(e)
+			}
+			if sample != nil && sorter != nil {
+				sorter.Add(sample.Items())
+			}
+			if sample != nil && fetched < total && !limit.Reached() {
+				config.warnf("The sample is drawn from the first %d of the %d matching events, raise -maxPages or -size to sample all of them", fetched, total)
+			}
+			if sample != nil && sorter == nil {
+				if pagerQuit(write(sample.Items())) {
+					return printed
+				}
+				printed = len(sample.Items())
+			}
+			if sorter != nil {
+				sorted := sorter.Sorted()
+				if pagerQuit(write(sorted)) {
+					return printed
+				}
+				printed = len(sorted)
+			}
+			if hist != nil {
+				pagerQuit(hist.Print(out.stdout))
+			}
+			if pivot != nil {
+				pagerQuit(pivot.Print(out.stdout))
+			}
+			return printed
 		}
 	}
+}
 
+// exitIfEmpty Exit with exitNoResults when nothing matched and
+// -fail-empty is set.
+func exitIfEmpty(config Config, n int64) {
+	if config.FailEmpty && n == 0 {
+		os.Exit(exitNoResults)
+	}
 }
 
-func warnInvalidFlagPlacement(flags *flag.FlagSet, args []string) {
+func warnInvalidFlagPlacement(config Config, flags *flag.FlagSet, args []string) {
 	currentFlags := make(map[string]bool)
 	flags.VisitAll(func(f *flag.Flag) {
 		currentFlags["-"+f.Name] = true
@@ -192,13 +645,13 @@ func warnInvalidFlagPlacement(flags *flag.FlagSet, args []string) {
 	}
 
 	if len(invalidFlags) > 0 {
-		fmt.Fprintf(os.Stderr, "Warning: Possible invalid flag placement. Flags must be specified before the query. Ignoring flags: %s\n", strings.Join(invalidFlags, ", "))
+		config.warnf("Flags must come before the query, ignoring %s", strings.Join(invalidFlags, ", "))
 	}
 }
 
-func warnHighConcurrency(concurrency int) {
-	if concurrency > 3 {
-		fmt.Fprintf(os.Stderr, "Warning: High concurrency (%d) may lead to rate limiting or temporary blocking by Loggly. If loggly returns with error, consider reducing the concurrency level.\n", concurrency)
+func warnHighConcurrency(config Config) {
+	if config.Concurrency > 3 {
+		config.warnf("A concurrency of %d may hit the rate limits of Loggly or get the account blocked for a while, lower it if the requests fail.", config.Concurrency)
 	}
 }
 
@@ -218,53 +671,371 @@ func contextWithInterrupt(ctx context.Context) (context.Context, context.CancelF
 	return ctx, cancel
 }
 
-func main() {
-	var config Config
-	// Command options.
-	var flags = flag.NewFlagSet("loggly", flag.ExitOnError)
-
-	var versionQuery = flags.Bool("version", false, "")
-	var tui = flags.Bool("tui", false, "")
-	var count = flags.Bool("count", false, "")
+// newFlagSet Create a flag set with the options shared by every
+// command, storing their values in config.
+func newFlagSet(name string, config *Config) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
 
 	flags.BoolVar(&config.AllMsg, "all", false, "")
 	flags.BoolVar(&config.Debug, "debug", false, "")
+	flags.BoolVar(&config.Verbose, "verbose", false, "")
+	flags.BoolVar(&config.Quiet, "quiet", false, "")
+	flags.StringVar(&config.ErrorFormat, "error-format", "text", "")
 	flags.Int64Var(&config.MaxPages, "maxPages", 3, "")
+	flags.Int64Var(&config.MaxEvents, "max-events", 0, "")
+	flags.IntVar(&config.SampleOutput, "sample-output", 0, "")
 	flags.IntVar(&config.Concurrency, "concurrency", 3, "")
 	flags.IntVar(&config.Size, "size", 100, "")
-	flags.StringVar(&config.Account, "account", "", "")
+	flags.Var(&accountsFlag{value: &config.Account}, "account", "")
 	flags.StringVar(&config.From, "from", "-24h", "")
+	flags.StringVar(&config.Output, "o", "", "")
+	flags.StringVar(&config.Output, "output", "", "")
+	flags.BoolVar(&config.Tee, "tee", false, "")
+	flags.StringVar(&config.RotateSize, "rotate-size", "", "")
+	flags.IntVar(&config.PageSize, "page-size", 0, "")
+	flags.BoolVar(&config.NoPager, "no-pager", false, "")
+	flags.BoolVar(&config.NoFieldCache, "no-field-cache", false, "")
+	flags.BoolVar(&config.Stats, "stats", false, "")
+	flags.BoolVar(&config.Accessible, "accessible", false, "")
 	flags.StringVar(&config.To, "to", "now", "")
+	flags.StringVar(&config.To, "until", "now", "")
 	flags.StringVar(&config.Token, "token", "", "")
+	flags.StringVar(&config.Auth, "auth", authBearer, "")
+	flags.StringVar(&config.AuthHeader, "auth-header", "", "")
+	flags.StringVar(&config.TokenFile, "token-file", "", "")
+	flags.StringVar(&config.Proxy, "proxy", "", "")
+	flags.StringVar(&config.SharedBudget, "shared-budget", "", "")
+	flags.IntVar(&config.BudgetRate, "shared-budget-rate", 60, "")
+	flags.StringVar(&config.Daemon, "daemon", "", "")
+	flags.StringVar(&config.Endpoint, "endpoint", os.Getenv("LOGGLY_ENDPOINT"), "")
+	flags.StringVar(&config.CACert, "ca-cert", "", "")
+	flags.StringVar(&config.ClientCert, "client-cert", "", "")
+	flags.StringVar(&config.ClientKey, "client-key", "", "")
+	flags.BoolVar(&config.InsecureSkipVerify, "insecure-skip-verify", false, "")
+	flags.BoolVar(&config.Strict, "strict", false, "")
+	flags.StringVar(&config.Where, "where", "", "")
+	flags.StringVar(&config.Grep, "grep", "", "")
+	flags.StringVar(&config.GrepV, "grep-v", "", "")
+	flags.StringVar(&config.JQ, "jq", "", "")
+	flags.StringVar(&config.Fields, "fields", "", "")
+	flags.StringVar(&config.ExcludeFields, "exclude-fields", "", "")
+	flags.BoolVar(&config.Flatten, "flatten", false, "")
+	flags.BoolVar(&config.Merge, "merge", false, "")
+	flags.BoolVar(&config.RawText, "raw", false, "")
+	flags.StringVar(&config.MessageField, "message-field", defaultMessageField, "")
+	flags.StringVar(&config.MergeFields, "merge-fields", strings.Join(envelopeFieldNames, ","), "")
+	flags.StringVar(&config.FlattenSeparator, "flatten-separator", ".", "")
+	flags.BoolVar(&config.FailEmpty, "fail-empty", false, "")
+	flags.StringVar(&config.Format, "format", "", "")
+	flags.StringVar(&config.Columns, "columns", "", "")
+	flags.StringVar(&config.Template, "template", "", "")
+	flags.StringVar(&config.Index, "index", "", "")
+	flags.StringVar(&config.TimeZone, "tz", "", "")
+	flags.BoolVar(&config.UTC, "utc", false, "")
+	flags.StringVar(&config.TimeFormat, "time-format", "", "")
+	flags.BoolVar(&config.Pretty, "pretty", false, "")
+	flags.StringVar(&config.Color, "color", colorAuto, "")
+	flags.StringVar(&config.LevelField, "level-field", strings.Join(defaultLevelFields, ","), "")
+	flags.BoolVar(&config.Highlight, "highlight", false, "")
+	flags.StringVar(&config.HighlightRegex, "highlight-regex", "", "")
+	flags.StringVar(&config.Query, "q", "", "")
+	flags.StringVar(&config.QueryFile, "query-file", "", "")
+	flags.StringVar(&config.Input, "input", "", "")
+	flags.StringVar(&config.ValueHistogram, "value-histogram", "", "")
+	flags.IntVar(&config.Buckets, "buckets", 10, "")
+	flags.StringVar(&config.Pivot, "pivot", "", "")
+	flags.StringVar(&config.Sort, "sort", "", "")
+	flags.BoolVar(&config.Dedup, "dedup", false, "")
+	flags.StringVar(&config.DedupBy, "dedup-by", "", "")
+	flags.BoolVar(&config.Desc, "desc", false, "")
+	flags.BoolVar(&config.PrintCurl, "print-curl", false, "")
+	flags.BoolVar(&config.CurlTokenEnv, "curl-token-env", false, "")
+	flags.DurationVar(&config.Timeout, "timeout", 0, "")
+	flags.DurationVar(&config.PageTimeout, "page-timeout", 0, "")
 
 	flags.Usage = printUsage
-	flags.Parse(os.Args[1:])
+
+	return flags
+}
+
+// warnDeprecatedUsage Point the deprecated invocations to their
+// replacements.
+func warnDeprecatedUsage(config Config, flags *flag.FlagSet, opts runOptions) {
+	var tuiFlag, toFlag bool
+	flags.Visit(func(f *flag.Flag) {
+		tuiFlag = tuiFlag || f.Name == "tui"
+		toFlag = toFlag || f.Name == "to"
+	})
+
+	switch {
+	case opts.legacy && tuiFlag:
+		warnDeprecated(config, "tui-flag")
+	case opts.legacy:
+		warnDeprecated(config, "bare-query")
+	}
+
+	if toFlag {
+		warnDeprecated(config, "to-flag")
+	}
+}
+
+// checkUntilAlias Reject conflicting -to and -until values, as both set
+// the end of the time range.
+func checkUntilAlias(flags *flag.FlagSet) error {
+	set := 0
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "to" || f.Name == "until" {
+			set++
+		}
+	})
+
+	if set > 1 {
+		return fmt.Errorf("use either -to or -until, not both")
+	}
+
+	return nil
+}
+
+// logTimeWindow Log the absolute time range the query will search.
+func logTimeWindow(config Config) {
+	logger := config.logger()
+	if logger == nil {
+		return
+	}
+
+	now := time.Now()
+	attrs := []any{"from", config.From, "until", config.To}
+	if start, ok := resolveTime(config.From, now); ok {
+		attrs = append(attrs, "from_resolved", start.Format(time.RFC3339))
+	}
+	if end, ok := resolveTime(config.To, now); ok {
+		attrs = append(attrs, "until_resolved", end.Format(time.RFC3339))
+	}
+	logger.Info("time window", attrs...)
+}
+
+// commands Subcommands selected by the first argument, receiving the
+// rest of the arguments.
+var commands = map[string]func(args []string){
+	"analyze":    runAnalyze,
+	"archive":    runArchive,
+	"assert":     runAssert,
+	"audit":      runAudit,
+	"completion": runCompletion,
+	"auth":       runAuth,
+	"batch":      runBatch,
+	"daemon":     runDaemon,
+	"demo":       runDemo,
+	"distinct":   runDistinct,
+	"examples":   runExamples,
+	"export":     runExport,
+	"help":       runHelp,
+	"histogram":  runHistogram,
+	"history":    runHistory,
+	"lag":        runLag,
+	"monitor":    runMonitor,
+	"open":       runOpen,
+	"replay":     runReplay,
+	"rerun":      runRerun,
+	"saved":      runSaved,
+	"search":     func(args []string) { run(args, runOptions{}) },
+	"send":       runSend,
+	"snapshot":   runSnapshot,
+	"stats":      runFieldStats,
+	"tail":       runTail,
+	"top":        runTop,
+	"tui":        func(args []string) { run(args, runOptions{tui: true}) },
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
+
+	run(os.Args[1:], runOptions{legacy: true})
+}
+
+type runOptions struct {
+	// override Adjust the parsed configuration before validation.
+	override func(*Config)
+	// tui Start the terminal UI.
+	tui bool
+	// legacy Invoked without a subcommand, the deprecated form.
+	legacy bool
+}
+
+// runFlags The flags of search and tui only.
+type runFlags struct {
+	version      *bool
+	tui          *bool
+	count        *bool
+	estimate     *bool
+	dryRun       *bool
+	showDefaults *bool
+	watch        *time.Duration
+	groupBy      *string
+}
+
+// newRunFlagSet The flags of search and tui, the common ones and their
+// own.
+func newRunFlagSet(config *Config) (*flag.FlagSet, runFlags) {
+	flags := newFlagSet("loggly", config)
+	return flags, runFlags{
+		version:      flags.Bool("version", false, ""),
+		tui:          flags.Bool("tui", false, ""),
+		count:        flags.Bool("count", false, ""),
+		estimate:     flags.Bool("estimate", false, ""),
+		dryRun:       flags.Bool("dry-run", false, ""),
+		showDefaults: flags.Bool("defaults", false, ""),
+		watch:        flags.Duration("watch", 0, ""),
+		groupBy:      flags.String("group-by", "", ""),
+	}
+}
+
+// run Parse the options and execute the query.
+func run(arguments []string, opts runOptions) {
+	var config Config
+	// Command options.
+	flags, own := newRunFlagSet(&config)
+	versionQuery, tui, count, estimate, dryRun := own.version, own.tui, own.count, own.estimate, own.dryRun
+	showDefaults, watch, groupBy := own.showDefaults, own.watch, own.groupBy
+
+	flags.Parse(arguments)
+
+	if opts.override != nil {
+		opts.override(&config)
+	}
 
 	if *versionQuery {
 		fmt.Println(version)
 		return
 	}
 
-	fmt.Println("loggly-cli version:", config)
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	// before the defaults are applied, which would look like given flags
+	if !*showDefaults {
+		warnDeprecatedUsage(config, flags, opts)
+	}
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	sources, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+	colorRules, colorErr := parseColorRules(fileConfig)
+	check(usageError(colorErr))
+	config.ColorRules = colorRules
+
+	if *showDefaults {
+		printDefaults(os.Stdout, flags, sources)
+		return
+	}
+
+	*tui = *tui || opts.tui
 
 	args := flags.Args()
-	warnInvalidFlagPlacement(flags, args)
-	warnHighConcurrency(config.Concurrency)
-	query := strings.Join(args, " ")
+	warnInvalidFlagPlacement(config, flags, args)
+	warnHighConcurrency(config)
+	query, queryErr := resolveQuery(config, args)
+	check(queryErr)
 	ctx, cancel := contextWithInterrupt(context.Background())
 	defer cancel()
 
-	check(config.Validate())
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	config.Format = resolveFormat(config, fileConfig)
+	config.FileFormat = resolveFileFormat(config, fileConfig)
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+	useTimeLocation(config)
+	logTimeWindow(config)
+
+	if len(splitList(config.Account)) > 1 && (*tui || *estimate || *dryRun) {
+		check(usageError(fmt.Errorf("multiple accounts can not be used with -tui, -estimate or -dry-run")))
+	}
+	if (len(splitList(config.Account)) > 1 || config.Input != "") && config.PageSize > 0 {
+		check(usageError(fmt.Errorf("page-size can not be used with multiple accounts or -input")))
+	}
+	if config.Input != "" && (*count || *estimate || *dryRun) {
+		check(usageError(fmt.Errorf("input can not be used with -count, -estimate or -dry-run")))
+	}
+
+	if *watch != 0 && (*watch < time.Second || *tui || *estimate || *dryRun || config.PageSize > 0 || *groupBy != "") {
+		check(usageError(fmt.Errorf("watch must be at least 1s and can not be used with -tui, -estimate, -dry-run, -page-size or -group-by")))
+	}
+	// the count comes from Loggly, the events are not fetched to filter
+	if *count && *groupBy == "" && (config.Grep != "" || config.GrepV != "" || config.Where != "" || config.JQ != "") {
+		check(usageError(fmt.Errorf("count is computed by Loggly, -grep, -grep-v, -where and -jq can not filter it, count the filtered events with -group-by")))
+	}
+	groupFields := splitList(*groupBy)
+	if *groupBy != "" && (!*count || len(groupFields) == 0) {
+		check(usageError(fmt.Errorf("group-by requires -count and the comma separated fields grouping the events")))
+	}
 
 	if *tui {
 		runInteractive(ctx, config, query)
 		return
 	}
 
+	check(lintQuery(config, query))
+
+	if *watch > 0 {
+		runWatch(ctx, config, query, *watch, *count)
+		return
+	}
+
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	if *dryRun {
+		execDryRun(ctx, config, query)
+		return
+	}
+
+	if *count && len(groupFields) > 0 {
+		groups := countGroups(config, "count", query, groupFields)
+		check(printGroupCounts(os.Stdout, groups, groupFields))
+		exitIfEmpty(config, int64(len(groups)))
+		return
+	}
+
 	if *count {
-		execCount(ctx, config, query)
+		check(startAudit(config, "count", query))
+		n := execCount(ctx, config, query)
+		check(finishAudit(n, nil))
+		exitIfEmpty(config, n)
+		return
+	}
+
+	if *estimate && !execEstimate(ctx, config, query) {
 		return
 	}
 
-	sendQuery(ctx, config, query)
+	check(startAudit(config, "search", query))
+	config.Pager = pagerCommand(fileConfig)
+	if config.Stats {
+		config.stats = newRunStats()
+	}
+	var printed int
+	if pager := newTerminalPager(config); pager != nil {
+		printed = sendPagedQuery(ctx, config, query, pager)
+	} else {
+		printed = sendQuery(ctx, config, query)
+	}
+	if config.stats != nil {
+		check(config.stats.Print(os.Stderr))
+	}
+	check(finishAudit(int64(printed), nil))
+	exitIfEmpty(config, int64(printed))
 }
@@ -0,0 +1,531 @@
+// Package mockserver implements the subset of the Loggly search API
+// used by the search client, serving an embedded fake dataset, and the
+// HTTP ingest endpoint. It backs the demo mode and the end-to-end tests.
+package mockserver
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed events.ndjson
+var dataset []byte
+
+// Event A stored event in the shape returned by the Loggly events API.
+type Event struct {
+	ID        string   `json:"id"`
+	Timestamp int64    `json:"timestamp"`
+	LogMsg    string   `json:"logmsg"`
+	Tags      []string `json:"tags"`
+	LogTypes  []string `json:"logtypes"`
+	Event     any      `json:"event"`
+}
+
+// SavedSearch A saved search in the shape returned by the saved
+// searches API.
+type SavedSearch struct {
+	ID      int64              `json:"id"`
+	Name    string             `json:"name"`
+	Context SavedSearchContext `json:"context"`
+}
+
+// SavedSearchContext The query and the time range of a saved search.
+type SavedSearchContext struct {
+	Terms string `json:"terms"`
+	From  string `json:"from"`
+	Until string `json:"until"`
+}
+
+type datasetLine struct {
+	Offset  int64           `json:"offset"`
+	Message json.RawMessage `json:"message"`
+}
+
+// Server Loggly search API mock.
+type Server struct {
+	// URL Base URL of the API once the server is started, usable as
+	// the endpoint of the search client.
+	URL string
+	// InputsURL Base URL of the ingest endpoint once the server is
+	// started.
+	InputsURL string
+	// IngestDelay How long the ingested events take to become
+	// searchable, like the indexing of Loggly.
+	IngestDelay time.Duration
+	// EventsDelay How long the events page takes to be served, to
+	// simulate slow and out of order pages. Nil serves them at once.
+	EventsDelay func(page int) time.Duration
+	// SavedSearches The saved searches of the account.
+	SavedSearches []SavedSearch
+
+	events   []Event
+	listener net.Listener
+	server   *http.Server
+
+	mu       sync.Mutex
+	searches map[string]searchParams
+	nextID   int
+}
+
+type searchParams struct {
+	query string
+	from  time.Time
+	until time.Time
+	order string
+	size  int
+}
+
+// New Create a server serving the embedded dataset, with the newest
+// event timestamped at now.
+func New(now time.Time) (*Server, error) {
+	events, err := loadEvents(dataset, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewWithEvents(events)
+	s.SavedSearches = []SavedSearch{
+		{ID: 1, Name: "errors", Context: SavedSearchContext{Terms: "json.level:error", From: "-1h", Until: "now"}},
+		{ID: 2, Name: "billing", Context: SavedSearchContext{Terms: "json.service:billing", From: "-24h", Until: "now"}},
+	}
+	return s, nil
+}
+
+// NewWithEvents Create a server serving the given events.
+func NewWithEvents(events []Event) *Server {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp > events[j].Timestamp
+	})
+
+	return &Server{
+		events:   events,
+		searches: make(map[string]searchParams),
+	}
+}
+
+func loadEvents(data []byte, now time.Time) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for i := 0; scanner.Scan(); i++ {
+		var line datasetLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("mockserver: invalid dataset line %d: %w", i+1, err)
+		}
+
+		logMsg := string(line.Message)
+		logTypes := []string{"json"}
+		var event any = map[string]any{}
+
+		var parsed map[string]any
+		if err := json.Unmarshal(line.Message, &parsed); err == nil {
+			event = map[string]any{"json": parsed}
+		} else {
+			// plain text message stored as a JSON string
+			json.Unmarshal(line.Message, &logMsg)
+			logTypes = []string{}
+		}
+
+		events = append(events, Event{
+			ID:        fmt.Sprintf("mock-%06d", i+1),
+			Timestamp: now.Add(-time.Duration(line.Offset) * time.Second).UnixMilli(),
+			LogMsg:    logMsg,
+			Tags:      []string{"demo"},
+			LogTypes:  logTypes,
+			Event:     event,
+		})
+	}
+
+	return events, scanner.Err()
+}
+
+// Start Listen on a random local port and serve the API in the background.
+func (s *Server) Start() error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	s.listener = l
+	s.URL = "http://" + l.Addr().String() + "/apiv2"
+	s.InputsURL = "http://" + l.Addr().String()
+	s.server = &http.Server{Handler: s}
+
+	go s.server.Serve(l)
+
+	return nil
+}
+
+// Close Stop the server.
+func (s *Server) Close() error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// ServeHTTP Handle the /apiv2/search, /apiv2/events and
+// /apiv2/savedsearches endpoints, and the /inputs/<token>/tag/<tags>/
+// ingest endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the ingest endpoint is authorized by the token in the path
+	if strings.HasPrefix(r.URL.Path, "/inputs/") || strings.HasPrefix(r.URL.Path, "/bulk/") {
+		s.handleInputs(w, r)
+		return
+	}
+
+	if r.Header.Get("Authorization") == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"message": "missing authorization"})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/apiv2/search":
+		s.handleSearch(w, r)
+	case "/apiv2/events":
+		s.handleEvents(w, r)
+	case "/apiv2/savedsearches":
+		writeJSON(w, http.StatusOK, s.SavedSearches)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	now := time.Now()
+
+	size, err := strconv.Atoi(qs.Get("size"))
+	if err != nil || size <= 0 {
+		size = 50
+	}
+
+	from, err := parseTime(qs.Get("from"), now, now.Add(-24*time.Hour))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return
+	}
+
+	until, err := parseTime(qs.Get("until"), now, now)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.searches[id] = searchParams{
+		query: qs.Get("q"),
+		from:  from,
+		until: until,
+		order: qs.Get("order"),
+		size:  size,
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"rsid": map[string]any{
+			"id":        id,
+			"status":    "SCHEDULED",
+			"date_from": from.UnixMilli(),
+			"date_to":   until.UnixMilli(),
+		},
+	})
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	s.mu.Lock()
+	params, ok := s.searches[qs.Get("rsid")]
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "unknown rsid"})
+		return
+	}
+
+	page, err := strconv.Atoi(qs.Get("page"))
+	if err != nil || page < 0 {
+		page = 0
+	}
+
+	if s.EventsDelay != nil {
+		select {
+		case <-time.After(s.EventsDelay(page)):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	matched := s.match(params)
+	start := min(page*params.size, len(matched))
+	end := min(start+params.size, len(matched))
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"total_events": len(matched),
+		"page":         page,
+		"events":       matched[start:end],
+	})
+}
+
+// handleInputs Store the posted events, one JSON or text message, or
+// with /bulk/ one per line. They become searchable after IngestDelay.
+func (s *Server) handleInputs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// /inputs/<token>/tag/<tags>/
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[1] == "" {
+		writeJSON(w, http.StatusForbidden, map[string]any{"response": "invalid token"})
+		return
+	}
+	tags := []string{}
+	if len(parts) >= 4 && parts[2] == "tag" {
+		tags = strings.Split(parts[3], ",")
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"response": err.Error()})
+		return
+	}
+
+	messages := [][]byte{body}
+	if parts[0] == "bulk" {
+		messages = bytes.Split(bytes.TrimSpace(body), []byte("\n"))
+	}
+
+	visible := time.Now().Add(s.IngestDelay)
+	go func() {
+		time.Sleep(time.Until(visible))
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, message := range messages {
+			s.nextID++
+			e := Event{
+				ID:        fmt.Sprintf("ingested-%06d", s.nextID),
+				Timestamp: time.Now().UnixMilli(),
+				LogMsg:    string(message),
+				Tags:      tags,
+				LogTypes:  []string{},
+				Event:     map[string]any{},
+			}
+
+			var parsed map[string]any
+			if err := json.Unmarshal(message, &parsed); err == nil {
+				e.LogTypes = []string{"json"}
+				e.Event = map[string]any{"json": parsed}
+			}
+
+			// newest first
+			s.events = append([]Event{e}, s.events...)
+		}
+	}()
+
+	writeJSON(w, http.StatusOK, map[string]any{"response": "ok"})
+}
+
+func (s *Server) match(params searchParams) []Event {
+	terms := parseQuery(params.query)
+	from := params.from.UnixMilli()
+	until := params.until.UnixMilli()
+
+	s.mu.Lock()
+	events := s.events
+	s.mu.Unlock()
+
+	matched := []Event{}
+	for _, e := range events {
+		if e.Timestamp < from || e.Timestamp > until {
+			continue
+		}
+
+		if terms.match(e) {
+			matched = append(matched, e)
+		}
+	}
+
+	if params.order == "asc" {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+
+	return matched
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// parseTime Understand "now", relative offsets like -24h or -7d and
+// RFC 3339 timestamps.
+func parseTime(value string, now time.Time, def time.Time) (time.Time, error) {
+	switch {
+	case value == "":
+		return def, nil
+	case value == "now":
+		return now, nil
+	case strings.HasPrefix(value, "-"):
+		unit := value[len(value)-1]
+		n, err := strconv.Atoi(value[1 : len(value)-1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", value)
+		}
+
+		durations := map[byte]time.Duration{
+			's': time.Second,
+			'm': time.Minute,
+			'h': time.Hour,
+			'd': 24 * time.Hour,
+			'w': 7 * 24 * time.Hour,
+		}
+		d, ok := durations[unit]
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid time %q", value)
+		}
+
+		return now.Add(-time.Duration(n) * d), nil
+	default:
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.UnixMilli(ms), nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", value)
+		}
+		return t, nil
+	}
+}
+
+type term struct {
+	field  string
+	value  string
+	negate bool
+}
+
+type terms []term
+
+// parseQuery Understand a small subset of the Loggly query language:
+// whitespace or AND separated terms, field:value pairs with * wildcards,
+// and negation with NOT or a leading -.
+func parseQuery(query string) terms {
+	var ret terms
+	negateNext := false
+
+	for _, word := range splitWords(query) {
+		switch word {
+		case "AND", "*", "":
+			continue
+		case "NOT":
+			negateNext = true
+			continue
+		}
+
+		t := term{negate: negateNext}
+		negateNext = false
+
+		if strings.HasPrefix(word, "-") {
+			t.negate = true
+			word = word[1:]
+		}
+		word = strings.TrimPrefix(word, "+")
+
+		if field, value, ok := strings.Cut(word, ":"); ok && !strings.HasPrefix(word, "\"") {
+			t.field = field
+			t.value = strings.Trim(value, "\"")
+		} else {
+			t.value = strings.Trim(word, "\"")
+		}
+
+		ret = append(ret, t)
+	}
+
+	return ret
+}
+
+func splitWords(query string) []string {
+	var words []string
+	var current strings.Builder
+	inQuote := false
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			current.WriteRune(r)
+		case r == ' ' && !inQuote:
+			words = append(words, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	return append(words, current.String())
+}
+
+func (ts terms) match(e Event) bool {
+	for _, t := range ts {
+		if t.match(e) == t.negate {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (t term) match(e Event) bool {
+	if t.field == "" {
+		return strings.Contains(strings.ToLower(e.LogMsg), strings.ToLower(t.value))
+	}
+
+	value, ok := lookup(e, t.field)
+	if !ok {
+		return false
+	}
+
+	matched, err := path.Match(strings.ToLower(t.value), strings.ToLower(fmt.Sprint(value)))
+	return err == nil && matched
+}
+
+func lookup(e Event, field string) (any, bool) {
+	parts := strings.Split(field, ".")
+	if parts[0] != "json" {
+		return nil, false
+	}
+
+	event, _ := e.Event.(map[string]any)
+	var current any = event["json"]
+	for _, p := range parts[1:] {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[p]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/scheduler"
+	"github.com/Ajnasz/go-loggly-cli/sparkline"
+)
+
+// monitorPoints Number of counts in the sparkline of a query.
+const monitorPoints = 60
+
+// monitorLine The counts of a monitored query and its last error.
+type monitorLine struct {
+	query  string
+	counts *sparkline.Series
+	err    error
+}
+
+// monitor prints a line per query. Terminals get the lines redrawn in
+// place, the other outputs a new line per count.
+type monitor struct {
+	mu    sync.Mutex
+	w     io.Writer
+	tty   bool
+	drawn bool
+	lines []monitorLine
+}
+
+func (m *monitor) add(i int, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lines[i].counts.Add(n)
+	m.lines[i].err = nil
+	if !m.tty {
+		fmt.Fprintf(m.w, "%s\t%s\t%d\n", time.Now().UTC().Format(time.RFC3339), m.lines[i].query, n)
+		return
+	}
+	m.draw()
+}
+
+func (m *monitor) fail(i int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lines[i].err = err
+	if !m.tty {
+		fmt.Fprintln(os.Stderr, locale.Sprintf("Error: %s: %s", m.lines[i].query, err))
+		return
+	}
+	m.draw()
+}
+
+// draw Print every line, over the previously drawn ones.
+func (m *monitor) draw() {
+	width := 0
+	for _, line := range m.lines {
+		width = max(width, len(line.query))
+	}
+
+	var sb strings.Builder
+	if m.drawn {
+		fmt.Fprintf(&sb, "\x1b[%dA", len(m.lines))
+	}
+	m.drawn = true
+
+	for _, line := range m.lines {
+		count := "-"
+		if n, ok := line.counts.Last(); ok {
+			count = locale.Int(n)
+		}
+
+		fmt.Fprintf(&sb, "\r\x1b[2K%-*s %8s %s", width, line.query, count, line.counts)
+		if line.err != nil {
+			fmt.Fprintf(&sb, " error: %s", line.err)
+		}
+		sb.WriteByte('\n')
+	}
+
+	io.WriteString(m.w, sb.String())
+}
+
+// runMonitor Count the events of the queries every interval and keep a
+// line per query with the last count and a sparkline of the previous
+// ones, a lightweight alternative to the TUI to watch error rates.
+func runMonitor(args []string) {
+	var config Config
+	flags := newFlagSet("loggly monitor", &config)
+	interval := flags.Duration("interval", 30*time.Second, "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	// every argument is a query of its own
+	var queries []string
+	if config.Query != "" {
+		queries = append(queries, config.Query)
+	}
+	queries = append(queries, flags.Args()...)
+	if len(queries) == 0 {
+		check(usageError(fmt.Errorf("usage: loggly monitor [options] -q <query> [query...]")))
+	}
+	if *interval < time.Second {
+		check(usageError(fmt.Errorf("interval must be at least 1s")))
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	// the polls are not recorded in the history, they would push out the
+	// queries worth running again
+	check(config.resolveToken(os.Stdin))
+	check(usageError(config.Validate()))
+
+	m := &monitor{w: os.Stdout, tty: isTerminal(os.Stdout)}
+	for _, query := range queries {
+		m.lines = append(m.lines, monitorLine{query: query, counts: sparkline.NewSeries(monitorPoints)})
+	}
+
+	s := scheduler.New(scheduler.Options{
+		Interval: *interval,
+		Jitter:   time.Second,
+		OnError: func(key string, err error) {
+			i, _ := strconv.Atoi(key)
+			m.fail(i, err)
+		},
+	})
+
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Go(func() {
+			s.Every(ctx, strconv.Itoa(i), func(ctx context.Context) error {
+				// a point is the number of events of the last interval
+				now := time.Now()
+				pointConfig := config
+				pointConfig.From = now.Add(-*interval).UTC().Format(time.RFC3339Nano)
+				pointConfig.To = now.UTC().Format(time.RFC3339Nano)
+
+				n, err := auditQuery(pointConfig, "monitor", query, func() (int64, error) {
+					return sumCount(ctx, pointConfig, query)
+				})
+				if err != nil {
+					return err
+				}
+				m.add(i, n)
+				return nil
+			})
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,189 @@
+// Package naturaltime parses human friendly time expressions like
+// "yesterday 14:00", "last monday" or "2 days ago".
+package naturaltime
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var units = map[string]time.Duration{
+	"second": time.Second,
+	"sec":    time.Second,
+	"minute": time.Minute,
+	"min":    time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Parse Resolve the expression relative to now. Understood are
+//
+//	N units ago       2 days ago, an hour ago, 90 minutes ago
+//	last unit         last hour, last week
+//	day [at] [time]   today, yesterday 14:00, last monday at 9am
+//	weekday [time]    friday 17:30, the most recent one before today
+//
+// where time is 14:00, 14:00:30, 2pm, 2:30pm, noon or midnight. A day
+// without a time means its start.
+func Parse(value string, now time.Time) (time.Time, bool) {
+	words := strings.Fields(strings.ToLower(value))
+	if len(words) == 0 {
+		return time.Time{}, false
+	}
+
+	if t, ok := parseAgo(words, now); ok {
+		return t, true
+	}
+
+	if len(words) == 2 && words[0] == "last" {
+		if unit, ok := parseUnit(words[1]); ok {
+			return now.Add(-unit), true
+		}
+	}
+
+	day, rest, ok := parseDay(words, now)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	if len(rest) == 2 && rest[0] == "at" {
+		rest = rest[1:]
+	}
+
+	switch len(rest) {
+	case 0:
+		return day, true
+	case 1:
+		h, m, s, ok := parseClock(rest[0])
+		if !ok {
+			return time.Time{}, false
+		}
+		return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// parseAgo Understand "2 days ago" and "an hour ago".
+func parseAgo(words []string, now time.Time) (time.Time, bool) {
+	if len(words) != 3 || words[2] != "ago" {
+		return time.Time{}, false
+	}
+
+	n := 0
+	switch words[0] {
+	case "a", "an":
+		n = 1
+	default:
+		var err error
+		if n, err = strconv.Atoi(words[0]); err != nil || n < 0 {
+			return time.Time{}, false
+		}
+	}
+
+	unit, ok := parseUnit(words[1])
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return now.Add(-time.Duration(n) * unit), true
+}
+
+func parseUnit(word string) (time.Duration, bool) {
+	unit, ok := units[strings.TrimSuffix(word, "s")]
+	return unit, ok
+}
+
+// parseDay Resolve the leading day words to the start of the day, and
+// return the remaining words.
+func parseDay(words []string, now time.Time) (time.Time, []string, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch words[0] {
+	case "today":
+		return today, words[1:], true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), words[1:], true
+	case "last":
+		if len(words) > 1 {
+			if wd, ok := weekdays[words[1]]; ok {
+				return previousWeekday(today, wd), words[2:], true
+			}
+		}
+	default:
+		if wd, ok := weekdays[words[0]]; ok {
+			return previousWeekday(today, wd), words[1:], true
+		}
+	}
+
+	return time.Time{}, nil, false
+}
+
+// previousWeekday The most recent day of the week before today.
+func previousWeekday(today time.Time, wd time.Weekday) time.Time {
+	days := (int(today.Weekday()) - int(wd) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return today.AddDate(0, 0, -days)
+}
+
+// parseClock Understand 14:00, 14:00:30, 2pm, 2:30pm, noon and midnight.
+func parseClock(word string) (hour, minute, second int, ok bool) {
+	switch word {
+	case "noon":
+		return 12, 0, 0, true
+	case "midnight":
+		return 0, 0, 0, true
+	}
+
+	suffix := ""
+	for _, s := range []string{"am", "pm"} {
+		if trimmed, found := strings.CutSuffix(word, s); found {
+			word, suffix = trimmed, s
+		}
+	}
+
+	parts := strings.Split(word, ":")
+	if len(parts) > 3 || (suffix == "" && len(parts) < 2) {
+		return 0, 0, 0, false
+	}
+
+	values := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, 0, 0, false
+		}
+		values[i] = n
+	}
+	hour, minute, second = values[0], values[1], values[2]
+
+	if suffix != "" {
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0, false
+		}
+		hour %= 12
+		if suffix == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 || second > 59 {
+		return 0, 0, 0, false
+	}
+
+	return hour, minute, second, true
+}
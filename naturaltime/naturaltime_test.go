@@ -0,0 +1,50 @@
+package naturaltime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2 days ago", now.Add(-48 * time.Hour)},
+		{"an hour ago", now.Add(-time.Hour)},
+		{"90 Minutes ago", now.Add(-90 * time.Minute)},
+		{"last hour", now.Add(-time.Hour)},
+		{"today", time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{"yesterday 14:00", time.Date(2024, 5, 14, 14, 0, 0, 0, time.UTC)},
+		{"yesterday at 2:30pm", time.Date(2024, 5, 14, 14, 30, 0, 0, time.UTC)},
+		{"last monday", time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{"last wednesday 9am", time.Date(2024, 5, 8, 9, 0, 0, 0, time.UTC)},
+		{"friday 17:30:15", time.Date(2024, 5, 10, 17, 30, 15, 0, time.UTC)},
+		{"today noon", time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)},
+		{"today 12am", time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		got, ok := Parse(test.value, now)
+		if !ok {
+			t.Errorf("%q: not understood", test.value)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%q: expected %s, got %s", test.value, test.want, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+
+	for _, value := range []string{"", "soon", "2 fortnights ago", "yesterday 25:00", "yesterday 13pm", "last", "today 14", "monday at", "yesterday 14:00 extra"} {
+		if got, ok := Parse(value, now); ok {
+			t.Errorf("%q: expected an error, got %s", value, got)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package notes stores short triage notes attached to events, keyed by
+// the event id, in a JSON file.
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Note A note of an event.
+type Note struct {
+	Text    string    `json:"text"`
+	Updated time.Time `json:"updated"`
+}
+
+// Store The notes of the events.
+type Store struct {
+	path  string
+	notes map[string]Note
+}
+
+// Open Read the notes stored in the file, none if it does not exist.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, notes: make(map[string]Note)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.notes); err != nil {
+		return nil, fmt.Errorf("notes: invalid file %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// Get The note of the event.
+func (s *Store) Get(id string) (Note, bool) {
+	n, ok := s.notes[id]
+	return n, ok
+}
+
+// Len Number of the annotated events.
+func (s *Store) Len() int {
+	return len(s.notes)
+}
+
+// Set Store the note of the event, an empty text removes it. The file
+// is written right away.
+func (s *Store) Set(id, text string, now time.Time) error {
+	if id == "" {
+		return fmt.Errorf("notes: the event has no id")
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		delete(s.notes, id)
+	} else {
+		s.notes[id] = Note{Text: text, Updated: now}
+	}
+
+	return s.save()
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.notes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
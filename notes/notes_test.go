@@ -0,0 +1,51 @@
+package notes
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "notes.json")
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Set("ev-1", "  root cause: expired cert ", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("ev-2", "duplicate", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("ev-2", "", now); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, ok := s.Get("ev-1")
+	if !ok || n.Text != "root cause: expired cert" || !n.Updated.Equal(now) {
+		t.Errorf("unexpected note %+v", n)
+	}
+	if _, ok := s.Get("ev-2"); ok || s.Len() != 1 {
+		t.Error("expected the emptied note to be removed")
+	}
+}
+
+func TestSetWithoutID(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "notes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Set("", "note", time.Now()); err == nil {
+		t.Error("expected an error for an event without id")
+	}
+}
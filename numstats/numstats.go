@@ -0,0 +1,56 @@
+// Package numstats summarizes the values of a numeric field, like the
+// latencies of the requests: the extremes, the mean and the percentiles.
+package numstats
+
+import (
+	"math"
+	"slices"
+)
+
+// Summary The statistics of a set of values.
+type Summary struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+}
+
+// Summarize Compute the statistics of the values, the zero Summary when
+// there are none. The values are sorted in place.
+func Summarize(values []float64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+
+	slices.Sort(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return Summary{
+		Count:  len(values),
+		Min:    values[0],
+		Max:    values[len(values)-1],
+		Mean:   sum / float64(len(values)),
+		Median: Percentile(values, 50),
+		P95:    Percentile(values, 95),
+		P99:    Percentile(values, 99),
+	}
+}
+
+// Percentile The value the p percent of the sorted values are within, by
+// the nearest rank, so it is always one of the values. NaN when there
+// are no values.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
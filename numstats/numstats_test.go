@@ -0,0 +1,51 @@
+package numstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		// 100, 99, ..., 1
+		values[i] = float64(100 - i)
+	}
+
+	got := Summarize(values)
+	want := Summary{Count: 100, Min: 1, Max: 100, Mean: 50.5, Median: 50, P95: 95, P99: 99}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	if got := Summarize(nil); got != (Summary{}) {
+		t.Errorf("expected the zero summary, got %+v", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 1},
+		{20, 1},
+		{21, 2},
+		{50, 3},
+		{95, 5},
+		{100, 5},
+	}
+
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("p%g: expected %g, got %g", tt.p, tt.want, got)
+		}
+	}
+
+	if got := Percentile(nil, 50); !math.IsNaN(got) {
+		t.Errorf("expected NaN without values, got %g", got)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/session"
+)
+
+// runOpen Open a session file shared by a teammate in the terminal UI.
+func runOpen(args []string) {
+	var config Config
+	flags := newFlagSet("loggly open", &config)
+	flags.Parse(args)
+
+	// the options given on the command line override the session, the
+	// defaults do not
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	if flags.NArg() != 1 {
+		check(usageError(fmt.Errorf("usage: loggly open [options] <file%s>", session.Ext)))
+	}
+
+	s, err := session.Load(flags.Arg(0))
+	check(usageError(err))
+
+	if s.From != "" && !given["from"] {
+		config.From = s.From
+	}
+	if s.Until != "" && !given["to"] && !given["until"] {
+		config.To = s.Until
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+
+	if len(splitList(config.Account)) > 1 {
+		check(usageError(fmt.Errorf("multiple accounts can not be used with open")))
+	}
+
+	m := initialModel(ctx, config, s.Query)
+	m.loadSession(s)
+	runModel(m)
+}
+
+// loadSession Show the events of the session and take over its pinned
+// fields. The notes are added to the local ones, without replacing the
+// notes written locally.
+func (m *model) loadSession(s session.Session) {
+	m.pinned = s.PinnedFields
+
+	if m.notes != nil {
+		for id, text := range s.Annotations {
+			if _, ok := m.notes.Get(id); ok {
+				continue
+			}
+			if err := m.notes.Set(id, text, time.Now()); err != nil {
+				m.config.warnf("Saving the note of %s failed: %s", id, err)
+			}
+		}
+	}
+
+	m.results = nil
+	m.resultIDs = nil
+	m.marked = make(map[int]bool)
+	for i, e := range s.Events {
+		m.results = append(m.results, e.Data)
+		m.resultIDs = append(m.resultIDs, e.ID)
+		m.marked[i] = true
+	}
+
+	m.analyzeResults()
+	m.updateFieldsList()
+	m.updateResultsView()
+	m.debugView = locale.Sprintf("Opened the session with %d events, execute the query to search again", len(s.Events))
+}
+
+// currentSession The session of the query, the pinned fields, the notes
+// of the results and the selected events.
+func (m *model) currentSession() session.Session {
+	s := session.Session{
+		Query:        m.queryInput.Value(),
+		From:         m.config.From,
+		Until:        m.config.To,
+		PinnedFields: m.pinned,
+	}
+
+	for i, result := range m.results {
+		id := ""
+		if i < len(m.resultIDs) {
+			id = m.resultIDs[i]
+		}
+
+		if note, ok := m.noteOf(id); ok {
+			if s.Annotations == nil {
+				s.Annotations = make(map[string]string)
+			}
+			s.Annotations[id] = note.Text
+		}
+
+		if m.marked[i] {
+			s.Events = append(s.Events, session.Event{ID: id, Data: result})
+		}
+	}
+
+	return s
+}
+
+// saveSession Write the session to a timestamped file in the working
+// directory.
+func (m *model) saveSession() {
+	name := filepath.Join(".", fmt.Sprintf("loggly-session-%s%s", time.Now().Format("20060102-150405"), session.Ext))
+	if err := m.currentSession().Save(name); err != nil {
+		m.debugView = locale.Sprintf("Saving the session failed: %s", err)
+		return
+	}
+	m.debugView = locale.Sprintf("Saved the session to %s", name)
+}
+
+// toggleMark Select the event for the session file or remove it.
+func (m *model) toggleMark() {
+	item, ok := m.selectedResult()
+	if !ok {
+		return
+	}
+
+	if m.marked == nil {
+		m.marked = make(map[int]bool)
+	}
+	if m.marked[item.index] {
+		delete(m.marked, item.index)
+	} else {
+		m.marked[item.index] = true
+	}
+
+	m.updateResultsView()
+}
+
+// togglePin Pin the selected field to the top of the field list or
+// unpin it.
+func (m *model) togglePin() {
+	item, ok := m.fieldsList.SelectedItem().(fieldItem)
+	if !ok {
+		return
+	}
+
+	path := strings.Join(slices.Concat(m.fieldPath, []string{item.name}), ".")
+	if i := slices.Index(m.pinned, path); i >= 0 {
+		m.pinned = slices.Delete(slices.Clone(m.pinned), i, i+1)
+		m.debugView = locale.Sprintf("Unpinned %s", path)
+	} else {
+		m.pinned = append(slices.Clone(m.pinned), path)
+		m.debugView = locale.Sprintf("Pinned %s", path)
+	}
+
+	m.updateFieldsList()
+	for i, listItem := range m.fieldsList.Items() {
+		if f, ok := listItem.(fieldItem); ok && f.name == item.name {
+			m.fieldsList.Select(i)
+			break
+		}
+	}
+}
@@ -9,6 +9,9 @@ type OrderedBuffer[T any] struct {
 	mu          sync.Mutex
 	lastSentIdx int
 	ch          chan T
+	// held while sending, so a later item can not overtake an earlier
+	// one being sent by another goroutine
+	sendMu sync.Mutex
 }
 
 func NewOrderedBuffer[T any](ch chan T) *OrderedBuffer[T] {
@@ -20,6 +23,9 @@ func NewOrderedBuffer[T any](ch chan T) *OrderedBuffer[T] {
 }
 
 func (s *OrderedBuffer[T]) send() {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
 	for {
 		s.mu.Lock()
 		newIdx := s.lastSentIdx + 1
@@ -1,6 +1,7 @@
 package orderedbuffer
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
@@ -98,3 +99,59 @@ func BenchmarkOrderedBufferConcurrent(b *testing.B) {
 		}
 	})
 }
+
+// FuzzOrderedBufferConcurrentStores Store the items from concurrent
+// goroutines in the order given by the input and check they arrive in
+// index order.
+func FuzzOrderedBufferConcurrentStores(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3})
+	f.Add([]byte{3, 2, 1, 0})
+	f.Add([]byte{1, 0, 3, 2, 5, 4, 7, 6})
+	f.Add([]byte{9, 1, 8, 2, 7, 3, 6, 4, 5, 0})
+
+	f.Fuzz(func(t *testing.T, order []byte) {
+		if len(order) == 0 || len(order) > 64 {
+			return
+		}
+
+		// turn the input into a permutation of the indexes
+		n := len(order)
+		indexes := make([]int, n)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		for i, b := range order {
+			j := int(b) % n
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		}
+
+		ch := make(chan int)
+		buf := NewOrderedBuffer(ch)
+
+		var wg sync.WaitGroup
+		for _, i := range indexes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				buf.Store(i, i)
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			close(ch)
+		}()
+
+		next := 0
+		for got := range ch {
+			if got != next {
+				t.Fatalf("expected %d, got %d (store order %v)", next, got, indexes)
+			}
+			next++
+		}
+
+		if next != n {
+			t.Fatalf("expected %d items, got %d", n, next)
+		}
+	})
+}
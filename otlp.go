@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// otlpBodyFields The fields holding the message of the events, the first
+// one an event has becomes the body of its log record.
+var otlpBodyFields = []string{"message", "msg", "json.message", "json.msg", "logmsg"}
+
+// otlpSeverities The OpenTelemetry severity numbers of the level names.
+var otlpSeverities = map[string]int{
+	"trace":       1,
+	"debug":       5,
+	"info":        9,
+	"information": 9,
+	"notice":      10,
+	"warn":        13,
+	"warning":     13,
+	"err":         17,
+	"error":       17,
+	"crit":        18,
+	"critical":    18,
+	"alert":       19,
+	"emerg":       21,
+	"emergency":   21,
+	"fatal":       21,
+	"panic":       21,
+}
+
+// otlpNumericSeverity The severity number of the syslog severities from
+// 0 to 7 and of the bunyan and pino levels from 10 to 60, 0 for the
+// unknown ones.
+func otlpNumericSeverity(n float64) int {
+	syslog := []int{21, 19, 18, 17, 13, 10, 9, 5}
+	switch {
+	case n >= 0 && n <= 7 && n == math.Trunc(n):
+		return syslog[int(n)]
+	case n >= 60:
+		return 21
+	case n >= 50:
+		return 17
+	case n >= 40:
+		return 13
+	case n >= 30:
+		return 9
+	case n >= 20:
+		return 5
+	case n >= 10:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// otlpSeverity The severity text and number of a level.
+func otlpSeverity(level any) (string, int) {
+	switch v := level.(type) {
+	case string:
+		return v, otlpSeverities[strings.ToLower(strings.TrimSpace(v))]
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), otlpNumericSeverity(v)
+	case json.Number:
+		n, err := v.Float64()
+		if err != nil {
+			return v.String(), 0
+		}
+		return v.String(), otlpNumericSeverity(n)
+	default:
+		return fmt.Sprint(v), 0
+	}
+}
+
+// otlpValue The AnyValue of the OTLP JSON encoding, nil for null. The
+// integers are strings, like the int64 of the protobuf JSON mapping.
+func otlpValue(v any) map[string]any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return map[string]any{"intValue": strconv.FormatInt(int64(v), 10)}
+		}
+		return map[string]any{"doubleValue": v}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return map[string]any{"intValue": strconv.FormatInt(n, 10)}
+		}
+		f, _ := v.Float64()
+		return map[string]any{"doubleValue": f}
+	case []any:
+		values := make([]map[string]any, 0, len(v))
+		for _, item := range v {
+			if value := otlpValue(item); value != nil {
+				values = append(values, value)
+			}
+		}
+		return map[string]any{"arrayValue": map[string]any{"values": values}}
+	case map[string]any:
+		return map[string]any{"kvlistValue": map[string]any{"values": otlpAttributes(v, slices.Sorted(maps.Keys(v)), nil)}}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+// otlpKeyValue An attribute of a log record.
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// otlpAttributes The fields of the event as attributes, without the
+// null ones and the ones mapped to the record itself.
+func otlpAttributes(event any, keys []string, mapped map[string]bool) []otlpKeyValue {
+	attributes := make([]otlpKeyValue, 0, len(keys))
+	for _, key := range keys {
+		if key == "" || mapped[key] {
+			continue
+		}
+		v, _ := lookupField(event, key)
+		if value := otlpValue(v); value != nil {
+			attributes = append(attributes, otlpKeyValue{Key: key, Value: value})
+		}
+	}
+	return attributes
+}
+
+// otlpFieldPath The flattened path of the field lookupField finds, the
+// json.* paths are found in the whole events too.
+func otlpFieldPath(event any, path string) string {
+	if _, ok := lookupPath(event, path); ok {
+		return path
+	}
+	rest, ok := strings.CutPrefix(path, "json.")
+	if !ok {
+		return path
+	}
+	if _, ok := lookupPath(event, rest); ok {
+		return rest
+	}
+	return "event." + path
+}
+
+// otlpLogRecord A LogRecord of the OTLP JSON encoding.
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano,omitempty"`
+	SeverityNumber int            `json:"severityNumber,omitempty"`
+	SeverityText   string         `json:"severityText,omitempty"`
+	Body           map[string]any `json:"body,omitempty"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+// otlpFormatter prints the events as OpenTelemetry log records, a JSON
+// encoded ExportLogsServiceRequest per line like the file exporter of
+// the OpenTelemetry Collector writes, so the events can be replayed into
+// any OTLP backend. The time, the level of the -level-field and the
+// message become the time, the severity and the body of the records, the
+// other fields their attributes, by their flattened paths.
+type otlpFormatter struct {
+	levelFields []string
+}
+
+// record The log record of an event.
+func (f otlpFormatter) record(event any) otlpLogRecord {
+	if text, ok := event.(textMessage); ok {
+		return otlpLogRecord{Body: otlpValue(string(text))}
+	}
+	if _, ok := event.(map[string]any); !ok {
+		return otlpLogRecord{Body: otlpValue(event)}
+	}
+
+	var r otlpLogRecord
+	mapped := make(map[string]bool)
+
+	if t, ok := search.EventTimestamp(event); ok {
+		r.TimeUnixNano = strconv.FormatInt(t.UnixNano(), 10)
+		mapped["timestamp"] = true
+	} else {
+		for _, field := range timeKeys {
+			s, ok := event.(map[string]any)[field].(string)
+			if !ok {
+				continue
+			}
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				r.TimeUnixNano = strconv.FormatInt(t.UnixNano(), 10)
+				mapped[field] = true
+				break
+			}
+		}
+	}
+
+	for _, field := range f.levelFields {
+		if v, ok := lookupField(event, field); ok && v != nil {
+			r.SeverityText, r.SeverityNumber = otlpSeverity(v)
+			mapped[otlpFieldPath(event, field)] = true
+			break
+		}
+	}
+
+	for _, field := range otlpBodyFields {
+		if v, ok := lookupField(event, field); ok && v != nil {
+			r.Body = otlpValue(v)
+			mapped[otlpFieldPath(event, field)] = true
+			break
+		}
+	}
+
+	r.Attributes = otlpAttributes(event, flattenedKeys(event), mapped)
+	return r
+}
+
+func (f otlpFormatter) Format(w io.Writer, events []any) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	records := make([]otlpLogRecord, len(events))
+	for i, event := range events {
+		records[i] = f.record(event)
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]any{"name": "go-loggly-cli"},
+				"logRecords": records,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// FormatRaw Decode the whole events of an export into records.
+func (f otlpFormatter) FormatRaw(w io.Writer, events []json.RawMessage) error {
+	decoded := make([]any, len(events))
+	for i, raw := range events {
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		if err := d.Decode(&decoded[i]); err != nil {
+			return err
+		}
+	}
+
+	return f.Format(w, decoded)
+}
@@ -0,0 +1,170 @@
+// Package outfile writes the output file of the events, gzip compressed
+// by its name and split into numbered parts of a size.
+package outfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// byteUnits The suffixes of the sizes.
+var byteUnits = map[string]int64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+}
+
+// ParseSize Parse a size like 500M or 2G, the units are powers of 1024.
+func ParseSize(s string) (int64, error) {
+	upper := strings.TrimSuffix(strings.ToUpper(s), "B")
+	number := strings.TrimRight(upper, "KMG")
+	unit, ok := byteUnits[upper[len(number):]]
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if !ok || err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q, use a number of bytes like 500M or 2G", s)
+	}
+
+	return n * unit, nil
+}
+
+// PartPath The name of a numbered part of the output file, the number
+// goes before the extensions: events.ndjson.gz becomes
+// events-0001.ndjson.gz.
+func PartPath(path string, part int) string {
+	dir, base := filepath.Split(path)
+	name, ext, _ := strings.Cut(base, ".")
+	if ext != "" {
+		ext = "." + ext
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%s-%04d%s", name, part, ext))
+}
+
+// File Writes the events to the output file, gzip compressed when its
+// name ends with .gz. With a limit the events are split into numbered
+// parts, a new part starts at the first line after the part reached the
+// limit, counted before the compression.
+type File struct {
+	path  string
+	gzip  bool
+	limit int64
+	// repeatHeader Start every part with the first line of the first one,
+	// the column names of the formats with a header.
+	repeatHeader bool
+	header       []byte
+	headerDone   bool
+	part         int
+	written      int64
+	file         *os.File
+	gz           *gzip.Writer
+}
+
+// Create Create the file, or the first part of it with a limit. With
+// header the first line is written again at the start of every part.
+func Create(path string, limit int64, header bool) (*File, error) {
+	f := &File{
+		path:         path,
+		gzip:         strings.HasSuffix(path, ".gz"),
+		limit:        limit,
+		repeatHeader: header && limit > 0,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *File) open() error {
+	path := f.path
+	if f.limit > 0 {
+		f.part++
+		path = PartPath(f.path, f.part)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	f.file = file
+	f.written = 0
+	if f.gzip {
+		f.gz = gzip.NewWriter(file)
+	}
+
+	if f.part > 1 && len(f.header) > 0 {
+		if _, err := f.write(f.header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *File) write(b []byte) (int, error) {
+	var n int
+	var err error
+	if f.gz != nil {
+		n, err = f.gz.Write(b)
+	} else {
+		n, err = f.file.Write(b)
+	}
+	f.written += int64(n)
+
+	return n, err
+}
+
+func (f *File) Write(b []byte) (int, error) {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.repeatHeader && !f.headerDone {
+		line := b
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			line, f.headerDone = b[:i+1], true
+		}
+		f.header = append(f.header, line...)
+	}
+
+	n, err := f.write(b)
+	if err != nil {
+		return n, err
+	}
+
+	// the formatters may write a line in pieces, the parts end with whole
+	// lines; the next part is opened by the next write, so no empty part
+	// is left behind
+	if f.limit > 0 && f.written >= f.limit && n > 0 && b[n-1] == '\n' {
+		return n, f.Close()
+	}
+
+	return n, nil
+}
+
+// Close Flush and close the current part.
+func (f *File) Close() error {
+	if f.file == nil {
+		return nil
+	}
+
+	var errs []error
+	if f.gz != nil {
+		errs = append(errs, f.gz.Close())
+	}
+	errs = append(errs, f.file.Close())
+	f.file, f.gz = nil, nil
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,143 @@
+package outfile
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "100", want: 100},
+		{in: "1K", want: 1 << 10},
+		{in: "500M", want: 500 << 20},
+		{in: "500mb", want: 500 << 20},
+		{in: "2G", want: 2 << 30},
+		{in: "2GB", want: 2 << 30},
+		{in: "", wantErr: true},
+		{in: "M", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "-1M", wantErr: true},
+		{in: "1T", wantErr: true},
+		{in: "1.5G", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSize(%q): expected an error, got %d", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q): expected %d, got %d", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestPartPath(t *testing.T) {
+	tests := []struct {
+		path string
+		part int
+		want string
+	}{
+		{path: "events.ndjson", part: 1, want: "events-0001.ndjson"},
+		{path: "events.ndjson.gz", part: 12, want: "events-0012.ndjson.gz"},
+		{path: "events", part: 3, want: "events-0003"},
+		{path: filepath.Join("out", "v1.2", "events.csv"), part: 2, want: filepath.Join("out", "v1.2", "events-0002.csv")},
+	}
+
+	for _, tt := range tests {
+		if got := PartPath(tt.path, tt.part); got != tt.want {
+			t.Errorf("PartPath(%q, %d): expected %q, got %q", tt.path, tt.part, tt.want, got)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if filepath.Ext(path) == ".gz" {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFileParts(t *testing.T) {
+	for _, name := range []string{"events.ndjson", "events.ndjson.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+		f, err := Create(path, 10, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a line written in pieces stays in one part
+		for _, s := range []string{"{\"a\":1}\n", "{\"a\":", "22}\n", "{\"a\":3}\n"} {
+			if _, err := f.Write([]byte(s)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"{\"a\":1}\n{\"a\":22}\n", "{\"a\":3}\n"}
+		for i, w := range want {
+			if got := readFile(t, PartPath(path, i+1)); got != w {
+				t.Errorf("%s part %d: expected %q, got %q", name, i+1, w, got)
+			}
+		}
+		if _, err := os.Stat(PartPath(path, len(want)+1)); !os.IsNotExist(err) {
+			t.Errorf("%s: expected no empty part, got %v", name, err)
+		}
+	}
+}
+
+func TestFileRepeatsHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.csv")
+	f, err := Create(path, 12, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"ti", "me,msg\n", "1,first\n", "2,second\n", "3,third\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"time,msg\n1,first\n", "time,msg\n2,second\n", "time,msg\n3,third\n"}
+	for i, w := range want {
+		if got := readFile(t, PartPath(path, i+1)); got != w {
+			t.Errorf("part %d: expected %q, got %q", i+1, w, got)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Ajnasz/go-loggly-cli/outfile"
+)
+
+// outputSink A writer of the events with the formatter of its format.
+type outputSink struct {
+	w         io.Writer
+	formatter eventFormatter
+}
+
+// output writes the events to every configured sink.
+type output struct {
+	sinks   []outputSink
+	closers []io.Closer
+	// stdout The standard output, or the pager printing it.
+	stdout io.Writer
+}
+
+// newOutput The standard output and the -o file. With -tee the -format
+// is for reading on the terminal, the file is written in its own format,
+// JSON lines by default.
+func newOutput(config Config, query string) (*output, error) {
+	out := &output{stdout: os.Stdout}
+	formatter := newFormatter(config, query)
+
+	if config.Output == "" || config.Tee {
+		out.sinks = append(out.sinks, outputSink{w: os.Stdout, formatter: formatter})
+	}
+
+	if config.Output != "" {
+		var limit int64
+		if config.RotateSize != "" {
+			// checked by Validate
+			limit, _ = outfile.ParseSize(config.RotateSize)
+		}
+
+		fileFormat := config.Format
+		if config.Tee {
+			fileConfig := config
+			fileConfig.Format, fileConfig.Pretty = cmp.Or(config.FileFormat, formatNDJSON), false
+			fileFormat = fileConfig.Format
+			formatter = newFormatter(fileConfig, query)
+		}
+
+		f, err := outfile.Create(config.Output, limit, slices.Contains(headerFormats, fileFormat))
+		if err != nil {
+			return nil, err
+		}
+		out.sinks = append(out.sinks, outputSink{w: f, formatter: formatter})
+		out.closers = append(out.closers, f)
+	}
+
+	return out, nil
+}
+
+// pageStdout Print the events written to the standard output through
+// the pager, closing it with the output.
+func (o *output) pageStdout(p io.Writer) {
+	for i, sink := range o.sinks {
+		if sink.w == io.Writer(os.Stdout) {
+			o.sinks[i].w = p
+		}
+	}
+	o.stdout = p
+	if c, ok := p.(io.Closer); ok {
+		o.closers = append(o.closers, c)
+	}
+}
+
+func (o *output) Write(events []any) error {
+	for _, sink := range o.sinks {
+		if err := sink.formatter.Format(sink.w, events); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteRaw Write the undecoded events of a raw query.
+func (o *output) WriteRaw(events []json.RawMessage) error {
+	for _, sink := range o.sinks {
+		var err error
+		if f, ok := sink.formatter.(rawFormatter); ok {
+			err = f.FormatRaw(sink.w, events)
+		} else {
+			err = printRaw(sink.w, events)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *output) Close() error {
+	var errs []error
+	for _, sink := range o.sinks {
+		if f, ok := sink.formatter.(finishingFormatter); ok {
+			// nothing is left to finish once the pager is quit
+			if err := f.Finish(sink.w); !errors.Is(err, errPagerQuit) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, c := range o.closers {
+		errs = append(errs, c.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func printJSON(w io.Writer, events []any) error {
+	for _, event := range events {
+		if text, ok := event.(textMessage); ok {
+			if _, err := fmt.Fprintln(w, string(text)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printRaw Print the events as NDJSON, compacting them in case the
+// API returned them indented.
+func printRaw(w io.Writer, events []json.RawMessage) error {
+	var buf bytes.Buffer
+	for _, event := range events {
+		buf.Reset()
+		if err := json.Compact(&buf, event); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// envelopeFields Picks the fields of the Loggly envelope added to the
+// parsed messages with -merge.
+var envelopeFields = map[string]func(event map[string]any) (any, bool){
+	"id":        envelopeValue("id"),
+	"timestamp": envelopeValue("timestamp"),
+	"tags":      envelopeValue("tags"),
+	"logtypes":  envelopeValue("logtypes"),
+	"host": func(event map[string]any) (any, bool) {
+		if host, ok := lookupPath(event, "event.syslog.host"); ok {
+			return host, true
+		}
+		return lookupPath(event, "event.http.clientHost")
+	},
+}
+
+var envelopeFieldNames = []string{"timestamp", "tags", "logtypes", "host", "id"}
+
+func envelopeValue(key string) func(event map[string]any) (any, bool) {
+	return func(event map[string]any) (any, bool) {
+		v, ok := event[key]
+		return v, ok
+	}
+}
+
+// mergeEnvelope Add the envelope fields of the events to their parsed
+// messages. The fields of the message win over the envelope.
+func mergeEnvelope(events []any, parsed []any, fields []string) {
+	for i, event := range events {
+		envelope, ok := event.(map[string]any)
+		if !ok {
+			continue
+		}
+		m, ok := parsed[i].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		for _, field := range fields {
+			if _, exists := m[field]; exists {
+				continue
+			}
+			if v, ok := envelopeFields[field](envelope); ok {
+				m[field] = v
+			}
+		}
+	}
+}
+
+// rawField Key of the messages which are not JSON objects.
+const rawField = "raw"
+
+// defaultMessageField The field of the events holding the message.
+const defaultMessageField = "logmsg"
+
+// textMessage A message which is not a JSON object, printed as it is
+// with -raw.
+type textMessage string
+
+// messageOf The message of the event, the value of the first of the
+// -message-field paths the event has.
+func messageOf(event map[string]any, fields []string) (any, bool) {
+	for _, field := range fields {
+		if v, ok := lookupPath(event, field); ok && v != nil {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+// decodeMessage The message as a JSON object, its numbers as
+// json.Number. Sources sending JSON have it parsed by Loggly already,
+// like event.json, the others as a string.
+func decodeMessage(msg any) (map[string]any, error) {
+	switch v := msg.(type) {
+	case map[string]any:
+		return maps.Clone(v), nil
+	case string:
+		// the numbers are kept as they were sent, like the ones of the
+		// messages parsed by Loggly, so the large integers print whole
+		d := json.NewDecoder(strings.NewReader(v))
+		d.UseNumber()
+		m := make(map[string]any)
+		if err := d.Decode(&m); err != nil {
+			return nil, err
+		}
+		if _, err := d.Token(); err != io.EOF {
+			return nil, fmt.Errorf("invalid data after the JSON object of the message")
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("the message is a %T, not an object", msg)
+	}
+}
+
+// messageText The message as a string, for the messages which are not
+// JSON objects.
+func messageText(msg any) string {
+	if s, ok := msg.(string); ok {
+		return s
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Sprint(msg)
+	}
+	return string(b)
+}
+
+// parseLogMSG Parse the messages of the events, read from the first of
+// the fields the event has. Messages which are not JSON objects are
+// returned as {"raw": message}, or with rawText as they are, unless
+// strict makes them an error. Events without message are nil, unless
+// strict makes them an error.
+func parseLogMSG(events []any, fields []string, strict, rawText bool) ([]any, error) {
+	var ret []any
+
+	for i, event := range events {
+		msg, ok := messageOf(event.(map[string]any), fields)
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("Error at event %d: none of the message fields %s", i+1, strings.Join(fields, ", "))
+			}
+			ret = append(ret, nil)
+			continue
+		}
+
+		m, err := decodeMessage(msg)
+		if err != nil {
+			if strict {
+				return nil, fmt.Errorf("Error at event %d: %w", i+1, err)
+			}
+			if rawText {
+				ret = append(ret, textMessage(messageText(msg)))
+				continue
+			}
+			m = map[string]any{rawField: messageText(msg)}
+		}
+
+		if account, ok := event.(map[string]any)[accountField]; ok {
+			m[accountField] = account
+		}
+
+		ret = append(ret, m)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// errPagerQuit The output was stopped at the prompt of the pager.
+var errPagerQuit = errors.New("quit paging")
+
+// pagerPrompt Printed after every page of lines, erased once a key is
+// pressed.
+const pagerPrompt = "-- more -- space: next page, enter: next line, q: quit"
+
+// terminalPager stops the output written to a terminal after every page
+// of lines until a key is pressed, so large results do not scroll the
+// earlier lines away.
+type terminalPager struct {
+	out   *os.File
+	in    *os.File
+	size  int
+	lines int
+	// width The characters written of the current line, the writers
+	// may write a line in pieces.
+	width int
+}
+
+// newTerminalPager The pager of -page-size, nil when it is not set or
+// the events are not printed to a terminal where a key can be pressed.
+func newTerminalPager(config Config) *terminalPager {
+	if config.PageSize == 0 || (config.Output != "" && !config.Tee) {
+		return nil
+	}
+	if !isTerminal(os.Stdout) || !isTerminal(os.Stdin) {
+		return nil
+	}
+
+	return &terminalPager{out: os.Stdout, in: os.Stdin, size: config.PageSize}
+}
+
+// Write Write the lines, stopping at the prompt whenever a page is
+// full. Returns errPagerQuit when the reader quits.
+func (p *terminalPager) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		line := b
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			line = b[:i+1]
+		}
+
+		if p.lines >= p.size {
+			if err := p.prompt(); err != nil {
+				return written, err
+			}
+		}
+
+		n, err := p.out.Write(line)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		text := escapeSequence.ReplaceAll(bytes.TrimRight(line, "\r\n"), nil)
+		p.width += utf8.RuneCount(text)
+		if line[len(line)-1] == '\n' {
+			p.lines += p.rows()
+			p.width = 0
+		}
+		b = b[len(line):]
+	}
+
+	return written, nil
+}
+
+// rows The number of terminal rows the current line takes, the long
+// lines wrap.
+func (p *terminalPager) rows() int {
+	width, _, err := term.GetSize(p.out.Fd())
+	if err != nil || width <= 0 {
+		return 1
+	}
+
+	return max((p.width+width-1)/width, 1)
+}
+
+// prompt Wait for a key: space shows the next page, enter the next line,
+// q, escape and ctrl-c quit.
+func (p *terminalPager) prompt() error {
+	state, err := term.MakeRaw(p.in.Fd())
+	if err != nil {
+		return err
+	}
+	defer term.Restore(p.in.Fd(), state)
+
+	if _, err := io.WriteString(p.out, colorLine(locale.Sprintf(pagerPrompt), highlightStyle)); err != nil {
+		return err
+	}
+	defer io.WriteString(p.out, "\r\x1b[2K")
+
+	key := make([]byte, 1)
+	for {
+		if _, err := p.in.Read(key); err != nil {
+			return err
+		}
+
+		switch key[0] {
+		case ' ':
+			p.lines = 0
+			return nil
+		case '\r', '\n':
+			p.lines = p.size - 1
+			return nil
+		case 'q', 'Q', 0x1b, 0x03:
+			return errPagerQuit
+		}
+	}
+}
+
+// pagerQuit Whether the reader quit the pager, so the rest of the events
+// are not needed, exits on the other errors.
+func pagerQuit(err error) bool {
+	if errors.Is(err, errPagerQuit) {
+		return true
+	}
+	check(err)
+	return false
+}
+
+// defaultPager The pager used when none is set, if it is installed.
+const defaultPager = "less"
+
+// pagerCommand The pager program of the output: $LOGGLY_PAGER, the pager
+// of the config file, $PAGER or less. Empty and cat turn the paging off.
+// Without less, like on Windows, the events are printed without a pager,
+// a pager set but missing is an error.
+func pagerCommand(file configfile.File) string {
+	if pager, ok := os.LookupEnv("LOGGLY_PAGER"); ok {
+		return pager
+	}
+	if pager, ok := file["pager"]; ok {
+		return pager
+	}
+	if pager, ok := os.LookupEnv("PAGER"); ok {
+		return pager
+	}
+	if _, err := exec.LookPath(defaultPager); err != nil {
+		return ""
+	}
+	return defaultPager
+}
+
+// externalPager pipes the events printed to a terminal through the
+// pager program, like git does. Less quits right away when the output
+// fits on the screen and keeps the colors.
+type externalPager struct {
+	// out The terminal the pager shows the events on.
+	out  *os.File
+	pipe io.WriteCloser
+	cmd  *exec.Cmd
+}
+
+// runningPager The pager of the command, check waits for it to be quit
+// before printing the error, so the terminal is not left to both.
+var runningPager *externalPager
+
+// startPager Start the pager of the events printed to the standard
+// output, nil when -no-pager or -page-size is set, the pager is turned
+// off or the standard output is not a terminal.
+func startPager(config Config) (*externalPager, error) {
+	if config.NoPager || config.PageSize > 0 || (config.Output != "" && !config.Tee) {
+		return nil, nil
+	}
+	if !isTerminal(os.Stdout) {
+		return nil, nil
+	}
+
+	args := strings.Fields(config.Pager)
+	if len(args) == 0 || args[0] == "cat" {
+		return nil, nil
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if _, ok := os.LookupEnv("LV"); !ok {
+		cmd.Env = append(cmd.Env, "LV=-c")
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	pipe, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("pager %s: %w", args[0], err)
+	}
+
+	runningPager = &externalPager{out: os.Stdout, pipe: pipe, cmd: cmd}
+	return runningPager, nil
+}
+
+// Write Write to the pager. Returns errPagerQuit once the reader quit
+// the pager.
+func (p *externalPager) Write(b []byte) (int, error) {
+	n, err := p.pipe.Write(b)
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+		return n, errPagerQuit
+	}
+	return n, err
+}
+
+// Close Wait until the reader quits the pager.
+func (p *externalPager) Close() error {
+	if runningPager == p {
+		runningPager = nil
+	}
+	p.pipe.Close()
+	// the exit status of the pager does not matter
+	p.cmd.Wait()
+	return nil
+}
+
+// sendPagedQuery Fetch and print the events through the pager, returns
+// the number of printed events. The pages of the query are fetched one
+// by one as the reader pages through the events, the ones never reached
+// are not downloaded.
+func sendPagedQuery(ctx context.Context, config Config, query string, pager *terminalPager) int {
+	config.raw = config.rawPassthrough()
+	limit := newEventLimit(&config)
+	c, err := config.newClient()
+	check(err)
+	pages := c.Pages(*config.newQuery(query))
+
+	out, outErr := newOutput(config, query)
+	check(outErr)
+	defer func() { check(out.Close()) }()
+	out.pageStdout(pager)
+
+	filters, filtersErr := newEventFilters(config)
+	check(filtersErr)
+	dedup := newDedupFilter(config)
+	fields := newFieldRecorder(config)
+	defer fields.Save(config)
+
+	printed := 0
+	for !limit.Reached() {
+		r, ok, err := pages.Next(ctx)
+		check(err)
+		if !ok {
+			return printed
+		}
+
+		var n int
+		if r.Raw != nil {
+			fields.AddRaw(r.Raw)
+			n, err = len(r.Raw), out.WriteRaw(r.Raw)
+		} else {
+			fields.Add(r.Events)
+			r.Events = dedup.Apply(r.Events)
+			events, ok := decodeRes(config, r)
+			if !ok {
+				continue
+			}
+			events = limit.Apply(filters.Apply(events))
+			n, err = len(events), out.Write(events)
+		}
+
+		if errors.Is(err, errPagerQuit) {
+			return printed
+		}
+		check(err)
+		printed += n
+	}
+
+	return printed
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Types of the Parquet columns, inferred from the values of the field.
+const (
+	parquetNull = iota
+	parquetBool
+	parquetInt
+	parquetFloat
+	parquetString
+)
+
+// parquetKind The column type a value fits, objects and arrays are
+// stored as JSON strings.
+func parquetKind(v any) int {
+	switch v := v.(type) {
+	case nil:
+		return parquetNull
+	case bool:
+		return parquetBool
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return parquetInt
+		}
+		return parquetFloat
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return parquetInt
+		}
+		return parquetFloat
+	default:
+		return parquetString
+	}
+}
+
+// widenParquetKind The column type fitting the values of both types:
+// the integers widen to floats, everything else to strings.
+func widenParquetKind(a, b int) int {
+	switch {
+	case a == b || b == parquetNull:
+		return a
+	case a == parquetNull:
+		return b
+	case (a == parquetInt || a == parquetFloat) && (b == parquetInt || b == parquetFloat):
+		return parquetFloat
+	default:
+		return parquetString
+	}
+}
+
+// parquetNode The optional Parquet column of the type.
+func parquetNode(kind int) parquet.Node {
+	switch kind {
+	case parquetBool:
+		return parquet.Optional(parquet.Leaf(parquet.BooleanType))
+	case parquetInt:
+		return parquet.Optional(parquet.Int(64))
+	case parquetFloat:
+		return parquet.Optional(parquet.Leaf(parquet.DoubleType))
+	default:
+		return parquet.Optional(parquet.String())
+	}
+}
+
+// parquetValue The value stored in a column of the type.
+func parquetValue(v any, kind int) parquet.Value {
+	switch kind {
+	case parquetBool:
+		return parquet.BooleanValue(v.(bool))
+	case parquetInt:
+		if n, ok := v.(json.Number); ok {
+			i, _ := n.Int64()
+			return parquet.Int64Value(i)
+		}
+		return parquet.Int64Value(int64(v.(float64)))
+	case parquetFloat:
+		f, _ := numericValue(v)
+		return parquet.DoubleValue(f)
+	default:
+		return parquet.ByteArrayValue([]byte(formatValue(v)))
+	}
+}
+
+// parquetFormatter writes the events into a Parquet file, to load them
+// into DuckDB or Spark without a conversion. The columns are the
+// -columns, or every field of the events, typed by the values of every
+// event, so the file is written by Finish.
+type parquetFormatter struct {
+	columns []string
+	events  map[io.Writer][]any
+}
+
+func (f *parquetFormatter) Format(w io.Writer, events []any) error {
+	f.events[w] = append(f.events[w], events...)
+	return nil
+}
+
+// FormatRaw Keep the whole events of an export, decoded.
+func (f *parquetFormatter) FormatRaw(w io.Writer, events []json.RawMessage) error {
+	for _, raw := range events {
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		var event map[string]any
+		if err := d.Decode(&event); err != nil {
+			return err
+		}
+		f.events[w] = append(f.events[w], event)
+	}
+	return nil
+}
+
+// Finish Infer the schema and write the file.
+func (f *parquetFormatter) Finish(w io.Writer) error {
+	events := f.events[w]
+	delete(f.events, w)
+
+	columns := f.columns
+	if len(columns) == 0 {
+		seen := make(map[string]bool)
+		for _, event := range events {
+			for _, key := range flattenedKeys(event) {
+				if key != "" && !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+		}
+		slices.Sort(columns)
+	}
+
+	values := make([][]any, len(events))
+	kinds := make([]int, len(columns))
+	for i, event := range events {
+		values[i] = make([]any, len(columns))
+		for j, column := range columns {
+			v, _ := lookupField(event, column)
+			values[i][j] = v
+			kinds[j] = widenParquetKind(kinds[j], parquetKind(v))
+		}
+	}
+
+	group := make(parquet.Group, len(columns))
+	for j, column := range columns {
+		group[column] = parquetNode(kinds[j])
+	}
+	schema := parquet.NewSchema("event", group)
+
+	// the group orders the columns by their names
+	indexes := make([]int, len(columns))
+	for j, column := range columns {
+		leaf, _ := schema.Lookup(column)
+		indexes[j] = leaf.ColumnIndex
+	}
+
+	pw := parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy))
+	rows := make([]parquet.Row, 0, len(values))
+	for _, event := range values {
+		row := make(parquet.Row, len(columns))
+		for j, v := range event {
+			if v == nil {
+				row[indexes[j]] = parquet.NullValue().Level(0, 0, indexes[j])
+				continue
+			}
+			row[indexes[j]] = parquetValue(v, kinds[j]).Level(0, 1, indexes[j])
+		}
+		rows = append(rows, row)
+	}
+
+	if _, err := pw.WriteRows(rows); err != nil {
+		return err
+	}
+	return pw.Close()
+}
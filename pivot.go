@@ -0,0 +1,102 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// pivotMissing The row or column of the events without the field.
+const pivotMissing = "-"
+
+// pivotTable counts the events by the values of the two -pivot fields,
+// the first one gives the rows, the second one the columns.
+type pivotTable struct {
+	rowField string
+	colField string
+	counts   map[string]map[string]int
+	rows     map[string]int
+	cols     map[string]int
+	total    int
+}
+
+func newPivotTable(config Config) *pivotTable {
+	fields := splitList(config.Pivot)
+	if len(fields) != 2 {
+		return nil
+	}
+
+	return &pivotTable{
+		rowField: fields[0],
+		colField: fields[1],
+		counts:   make(map[string]map[string]int),
+		rows:     make(map[string]int),
+		cols:     make(map[string]int),
+	}
+}
+
+// pivotValue The value of the field as a row or column name.
+func pivotValue(event any, field string) string {
+	v, ok := lookupField(event, field)
+	if !ok || v == nil {
+		return pivotMissing
+	}
+	return formatValue(v)
+}
+
+func (p *pivotTable) Write(events []any) error {
+	for _, event := range events {
+		row, col := pivotValue(event, p.rowField), pivotValue(event, p.colField)
+		if p.counts[row] == nil {
+			p.counts[row] = make(map[string]int)
+		}
+		p.counts[row][col]++
+		p.rows[row]++
+		p.cols[col]++
+		p.total++
+	}
+
+	return nil
+}
+
+// byCount The keys from the largest count to the smallest, the equal
+// ones by name.
+func byCount(counts map[string]int) []string {
+	return slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+}
+
+// Print Print the counts as a table with a total row and column, the
+// rows and the columns ordered by their totals.
+func (p *pivotTable) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+
+	cols := byCount(p.cols)
+	fmt.Fprintf(tw, "%s \\ %s\t", p.rowField, p.colField)
+	for _, col := range cols {
+		fmt.Fprintf(tw, "%s\t", col)
+	}
+	fmt.Fprintln(tw, "TOTAL\t")
+
+	for _, row := range byCount(p.rows) {
+		fmt.Fprintf(tw, "%s\t", row)
+		for _, col := range cols {
+			fmt.Fprintf(tw, "%s\t", locale.Int(p.counts[row][col]))
+		}
+		fmt.Fprintf(tw, "%s\t\n", locale.Int(p.rows[row]))
+	}
+
+	fmt.Fprint(tw, "TOTAL\t")
+	for _, col := range cols {
+		fmt.Fprintf(tw, "%s\t", locale.Int(p.cols[col]))
+	}
+	fmt.Fprintf(tw, "%s\t\n", locale.Int(p.total))
+
+	return tw.Flush()
+}
@@ -0,0 +1,32 @@
+package platform
+
+import (
+	"io"
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// CopyToClipboard Put the text on the system clipboard. When no native
+// clipboard is available (for example over SSH) it falls back to the
+// OSC52 terminal escape sequence written to out.
+func CopyToClipboard(text string, out io.Writer) error {
+	if err := clipboard.WriteAll(text); err == nil {
+		return nil
+	}
+
+	if err := copyFallback(text); err == nil {
+		return nil
+	}
+
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if os.Getenv("STY") != "" {
+		seq = seq.Screen()
+	}
+
+	_, err := seq.WriteTo(out)
+	return err
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package platform
+
+import "errors"
+
+func copyFallback(string) error {
+	return errors.New("no clipboard fallback")
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package platform
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// copyFallback Use PowerShell when the clipboard API is not reachable,
+// for example from a restricted console host.
+func copyFallback(text string) error {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", "$input | Set-Clipboard")
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
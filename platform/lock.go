@@ -0,0 +1,14 @@
+package platform
+
+import "os"
+
+// LockFile Wait for an exclusive lock of the file, shared with other
+// processes. Release it with UnlockFile.
+func LockFile(f *os.File) error {
+	return lockFile(f)
+}
+
+// UnlockFile Release the lock taken by LockFile.
+func UnlockFile(f *os.File) error {
+	return unlockFile(f)
+}
@@ -0,0 +1,76 @@
+// Package platform hides the differences between operating systems,
+// like where the configuration and state files live or how to reach
+// the system clipboard.
+package platform
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// AppName Name of the directory created under the platform specific
+// config, state and cache directories.
+const AppName = "loggly"
+
+// ConfigDir Directory of the configuration files, for example
+// ~/.config/loggly on Linux or %AppData%\loggly on Windows.
+func ConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, AppName), nil
+}
+
+// CacheDir Directory of files which can be recreated any time, for
+// example ~/.cache/loggly on Linux or %LocalAppData%\loggly on Windows.
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, AppName), nil
+}
+
+// StateDir Directory of files which should survive between runs but
+// are not configuration, like history or annotations. For example
+// ~/.local/state/loggly on Linux or %LocalAppData%\loggly\state on Windows.
+func StateDir() (string, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, AppName), nil
+}
+
+// ConfigFile Path of the file in the config directory.
+func ConfigFile(name string) (string, error) {
+	return fileIn(ConfigDir, name)
+}
+
+// CacheFile Path of the file in the cache directory.
+func CacheFile(name string) (string, error) {
+	return fileIn(CacheDir, name)
+}
+
+// StateFile Path of the file in the state directory.
+func StateFile(name string) (string, error) {
+	return fileIn(StateDir, name)
+}
+
+// EnsureDir Create the parent directory of the file if it does not exist.
+func EnsureDir(file string) error {
+	return os.MkdirAll(filepath.Dir(file), 0o700)
+}
+
+func fileIn(dir func() (string, error), name string) (string, error) {
+	d, err := dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(d, filepath.FromSlash(name)), nil
+}
@@ -0,0 +1,39 @@
+package platform
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirsEndWithAppName(t *testing.T) {
+	for name, dir := range map[string]func() (string, error){
+		"config": ConfigDir,
+		"cache":  CacheDir,
+		"state":  StateDir,
+	} {
+		t.Run(name, func(t *testing.T) {
+			d, err := dir()
+			if err != nil {
+				t.Skipf("%s dir not available: %s", name, err)
+			}
+			if filepath.Base(d) != AppName {
+				t.Errorf("expected %q to end with %q", d, AppName)
+			}
+			if !filepath.IsAbs(d) {
+				t.Errorf("expected absolute path, got %q", d)
+			}
+		})
+	}
+}
+
+func TestStateFileUsesPlatformSeparator(t *testing.T) {
+	f, err := StateFile("notes/db.json")
+	if err != nil {
+		t.Skipf("state dir not available: %s", err)
+	}
+
+	if !strings.HasSuffix(f, filepath.Join("notes", "db.json")) {
+		t.Errorf("unexpected state file path %q", f)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+func userStateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return "", errors.New("path in $XDG_STATE_HOME is relative")
+		}
+		return dir, nil
+	}
+
+	if runtime.GOOS == "darwin" {
+		// macOS has no state directory, keep the files next to
+		// the configuration like other CLI tools do.
+		return os.UserConfigDir()
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "state"), nil
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+func userStateDir() (string, error) {
+	dir := os.Getenv("LocalAppData")
+	if dir == "" {
+		return "", errors.New("%LocalAppData% is not defined")
+	}
+
+	return filepath.Join(dir, "state"), nil
+}
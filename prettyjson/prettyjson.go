@@ -0,0 +1,126 @@
+// Package prettyjson prints JSON values for humans, indented and
+// syntax highlighted with ANSI colors.
+package prettyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// ANSI colors of the JSON tokens.
+const (
+	colorKey    = "\x1b[1;34m"
+	colorString = "\x1b[32m"
+	colorNumber = "\x1b[36m"
+	colorBool   = "\x1b[33m"
+	colorNull   = "\x1b[90m"
+	colorReset  = "\x1b[0m"
+)
+
+// Options How to print the values.
+type Options struct {
+	// Indent Indentation of a nesting level, no new lines when empty.
+	Indent string
+	// Color Highlight the keys and the values.
+	Color bool
+}
+
+// Marshal Encode v like json.Marshal, formatted according to the
+// options.
+func Marshal(v any, opts Options) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return Format(data, opts), nil
+}
+
+// Format Reformat compact JSON, like the output of json.Marshal.
+func Format(data []byte, opts Options) []byte {
+	var out bytes.Buffer
+	depth := 0
+
+	newline := func() {
+		if opts.Indent != "" {
+			out.WriteByte('\n')
+			out.WriteString(strings.Repeat(opts.Indent, depth))
+		}
+	}
+	colored := func(color string, token []byte) {
+		if opts.Color {
+			out.WriteString(color)
+			out.Write(token)
+			out.WriteString(colorReset)
+		} else {
+			out.Write(token)
+		}
+	}
+
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '{', '[':
+			out.WriteByte(c)
+			if i+1 < len(data) && (data[i+1] == '}' || data[i+1] == ']') {
+				out.WriteByte(data[i+1])
+				i++
+				continue
+			}
+			depth++
+			newline()
+		case '}', ']':
+			depth--
+			newline()
+			out.WriteByte(c)
+		case ',':
+			out.WriteByte(c)
+			newline()
+		case ':':
+			out.WriteByte(c)
+			if opts.Indent != "" {
+				out.WriteByte(' ')
+			}
+		case '"':
+			end := stringEnd(data, i)
+			color := colorString
+			if end+1 < len(data) && data[end+1] == ':' {
+				color = colorKey
+			}
+			colored(color, data[i:end+1])
+			i = end
+		default:
+			end := i
+			for end+1 < len(data) && !bytes.ContainsRune([]byte(",:]} \t\n\r"), rune(data[end+1])) {
+				end++
+			}
+			token := data[i : end+1]
+			switch token[0] {
+			case 't', 'f':
+				colored(colorBool, token)
+			case 'n':
+				colored(colorNull, token)
+			case ' ', '\t', '\n', '\r':
+				// whitespace of input that was not compact
+			default:
+				colored(colorNumber, token)
+			}
+			i = end
+		}
+	}
+
+	return out.Bytes()
+}
+
+// stringEnd Index of the closing quote of the string starting at i.
+func stringEnd(data []byte, i int) int {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j
+		}
+	}
+	return len(data) - 1
+}
@@ -0,0 +1,95 @@
+package prettyjson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalIndent(t *testing.T) {
+	v := map[string]any{
+		"b":     []any{1, "x", true, nil},
+		"a":     "q\"uo:te,",
+		"empty": map[string]any{},
+		"list":  []any{},
+	}
+
+	got, err := Marshal(v, Options{Indent: "  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := json.MarshalIndent(v, "", "  ")
+	if string(got) != string(want) {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestMarshalCompact(t *testing.T) {
+	v := map[string]any{"a": []any{1, map[string]any{"b": "c"}}}
+
+	got, err := Marshal(v, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != `{"a":[1,{"b":"c"}]}` {
+		t.Errorf("unexpected %s", got)
+	}
+}
+
+func TestMarshalColor(t *testing.T) {
+	got, err := Marshal(map[string]any{"k": "v", "n": 1.5, "t": false, "z": nil}, Options{Color: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		colorKey + `"k"` + colorReset,
+		colorString + `"v"` + colorReset,
+		colorNumber + `1.5` + colorReset,
+		colorBool + `false` + colorReset,
+		colorNull + `null` + colorReset,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
+
+// formatBenchEvent A typical event printed by the output formatters.
+var formatBenchEvent = []byte(`{"id":"ev-000001","timestamp":1700000000000,"tags":["web","prod"],"event":{"json":{"level":"info","message":"request completed","duration":12.5,"ok":true,"err":null,"http":{"method":"GET","path":"/v1/orders","status":200},"user":{"id":"u001"}}}}`)
+
+var formatBenchOptions = []struct {
+	name string
+	opts Options
+}{
+	{"compact", Options{}},
+	{"indented", Options{Indent: "  "}},
+	{"colored", Options{Indent: "  ", Color: true}},
+}
+
+// TestFormatAllocs The allocation budget of formatting an event, the
+// output buffer growing a few times.
+func TestFormatAllocs(t *testing.T) {
+	for _, tt := range formatBenchOptions {
+		allocs := testing.AllocsPerRun(100, func() { Format(formatBenchEvent, tt.opts) })
+		if allocs > 8 {
+			t.Errorf("%s: expected at most 8 allocations, got %.0f", tt.name, allocs)
+		}
+	}
+}
+
+// BenchmarkFormat Memory of formatting a typical event by the output
+// formatters.
+func BenchmarkFormat(b *testing.B) {
+	for _, tt := range formatBenchOptions {
+		b.Run(tt.name, func(b *testing.B) {
+			b.SetBytes(int64(len(formatBenchEvent)))
+			b.ReportAllocs()
+			for b.Loop() {
+				Format(formatBenchEvent, tt.opts)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import "strings"
+
+// fieldProjection keeps the fields selected with -fields and removes
+// the ones selected with -exclude-fields from the printed events.
+type fieldProjection struct {
+	fields  []string
+	exclude []string
+}
+
+// newFieldProjection Returns nil when neither -fields nor
+// -exclude-fields is set.
+func newFieldProjection(config Config) *fieldProjection {
+	fields, exclude := splitList(config.Fields), splitList(config.ExcludeFields)
+	if len(fields) == 0 && len(exclude) == 0 {
+		return nil
+	}
+
+	return &fieldProjection{fields: fields, exclude: exclude}
+}
+
+// Apply Project the events. Events which are not objects, like the
+// values picked by -jq, are left as they are.
+func (p *fieldProjection) Apply(events []any) []any {
+	if p == nil {
+		return events
+	}
+
+	ret := make([]any, len(events))
+	for i, event := range events {
+		m, ok := event.(map[string]any)
+		if !ok {
+			ret[i] = event
+			continue
+		}
+
+		if len(p.fields) > 0 {
+			m = pickFields(m, p.fields)
+		}
+		for _, path := range p.exclude {
+			m = removeField(m, path)
+		}
+		ret[i] = m
+	}
+
+	return ret
+}
+
+// fieldKeys The keys leading to the field of the dotted path, resolving
+// the json.* paths like lookupField.
+func fieldKeys(event map[string]any, path string) ([]string, bool) {
+	candidates := []string{path}
+	if rest, ok := strings.CutPrefix(path, "json."); ok {
+		candidates = append(candidates, rest, "event."+path)
+	}
+
+	for _, candidate := range candidates {
+		if _, ok := lookupPath(event, candidate); ok {
+			return strings.Split(candidate, "."), true
+		}
+	}
+
+	return nil, false
+}
+
+// pickFields A new event holding only the fields of the paths, nested
+// as in the event.
+func pickFields(event map[string]any, paths []string) map[string]any {
+	ret := make(map[string]any)
+	for _, path := range paths {
+		keys, ok := fieldKeys(event, path)
+		if !ok {
+			continue
+		}
+
+		v, _ := lookupPath(event, strings.Join(keys, "."))
+		parent := ret
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := parent[key].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				parent[key] = child
+			}
+			parent = child
+		}
+		parent[keys[len(keys)-1]] = v
+	}
+
+	return ret
+}
+
+// removeField The event without the field of the path. The maps on the
+// path are copied, the event itself is not modified.
+func removeField(event map[string]any, path string) map[string]any {
+	keys, ok := fieldKeys(event, path)
+	if !ok {
+		return event
+	}
+
+	var remove func(m map[string]any, keys []string) map[string]any
+	remove = func(m map[string]any, keys []string) map[string]any {
+		copied := make(map[string]any, len(m))
+		for k, v := range m {
+			copied[k] = v
+		}
+
+		if len(keys) == 1 {
+			delete(copied, keys[0])
+			return copied
+		}
+
+		child, ok := m[keys[0]].(map[string]any)
+		if !ok {
+			return m
+		}
+		copied[keys[0]] = remove(child, keys[1:])
+		return copied
+	}
+
+	return remove(event, keys)
+}
@@ -0,0 +1,118 @@
+package querylint
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TokenKind Type of a query token.
+type TokenKind int
+
+const (
+	// Term a word or a field:value pair
+	Term TokenKind = iota
+	// Phrase a double quoted string
+	Phrase
+	// Regexp a /regular expression/
+	Regexp
+	// Operator AND, OR, NOT, TO
+	Operator
+	// Paren a grouping or range bracket
+	Paren
+)
+
+// Token A lexical element of a Loggly query.
+type Token struct {
+	Kind TokenKind
+	// Field the field name of a field:value term, empty for free text
+	Field string
+	// Value the text of the token without the field and modifiers
+	Value string
+	// Negated the token has a - prefix
+	Negated bool
+}
+
+var operators = map[string]bool{"AND": true, "OR": true, "NOT": true, "TO": true}
+
+// Tokenize Split a Loggly query into tokens. It is forgiving: any
+// input produces tokens, unbalanced quotes run to the end of the query.
+func Tokenize(query string) []Token {
+	var tokens []Token
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("()[]{}", r):
+			tokens = append(tokens, Token{Kind: Paren, Value: string(r)})
+			i++
+		default:
+			var tok Token
+			tok, i = readTerm(runes, i)
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return tokens
+}
+
+func readTerm(runes []rune, i int) (Token, int) {
+	tok := Token{Kind: Term}
+
+	switch runes[i] {
+	case '-':
+		tok.Negated = true
+		i++
+	case '+':
+		i++
+	}
+
+	if i < len(runes) && (runes[i] == '"' || runes[i] == '/') {
+		return readDelimited(runes, i, tok)
+	}
+
+	start := i
+	for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()[]{}", runes[i]) {
+		if runes[i] == ':' && tok.Field == "" {
+			tok.Field = string(runes[start:i])
+			i++
+			if i < len(runes) && (runes[i] == '"' || runes[i] == '/') {
+				return readDelimited(runes, i, tok)
+			}
+			start = i
+			continue
+		}
+		i++
+	}
+
+	tok.Value = string(runes[start:i])
+	if tok.Field == "" && !tok.Negated && operators[tok.Value] {
+		tok.Kind = Operator
+	}
+
+	return tok, i
+}
+
+func readDelimited(runes []rune, i int, tok Token) (Token, int) {
+	delim := runes[i]
+	if delim == '"' {
+		tok.Kind = Phrase
+	} else {
+		tok.Kind = Regexp
+	}
+
+	i++
+	start := i
+	for i < len(runes) && runes[i] != delim {
+		if runes[i] == '\\' {
+			i++
+		}
+		i++
+	}
+
+	tok.Value = string(runes[start:min(i, len(runes))])
+
+	return tok, min(i+1, len(runes))
+}
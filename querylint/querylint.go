@@ -0,0 +1,86 @@
+// Package querylint finds Loggly query patterns which are slow to run
+// or likely to hit the rate limits, and suggests better alternatives.
+package querylint
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Issue A problem found in a query.
+type Issue struct {
+	Rule       string
+	Message    string
+	Suggestion string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s (%s). %s", i.Message, i.Rule, i.Suggestion)
+}
+
+// Thresholds of the time window dependent rules.
+const (
+	MatchAllWindow = 24 * time.Hour
+	RegexpWindow   = time.Hour
+)
+
+// Lint Check the query searched over the given time window. A zero
+// window disables the window dependent rules.
+func Lint(query string, window time.Duration) []Issue {
+	tokens := Tokenize(query)
+
+	var issues []Issue
+
+	if window > MatchAllWindow && matchesAll(tokens) {
+		issues = append(issues, Issue{
+			Rule:       "match-all",
+			Message:    fmt.Sprintf("The query matches every event of a %s window", window),
+			Suggestion: "Add a field filter like json.level:error or narrow the range with -from.",
+		})
+	}
+
+	for _, t := range tokens {
+		switch {
+		case t.Kind == Term && isLeadingWildcard(t.Value):
+			issues = append(issues, Issue{
+				Rule:       "leading-wildcard",
+				Message:    fmt.Sprintf("%q starts with a wildcard, which cannot use the index", termString(t)),
+				Suggestion: "Search for the end of the word without the wildcard or filter on a field first.",
+			})
+		case t.Kind == Regexp && window > RegexpWindow:
+			issues = append(issues, Issue{
+				Rule:       "regexp-window",
+				Message:    fmt.Sprintf("Regular expression %q runs over a %s window", "/"+t.Value+"/", window),
+				Suggestion: fmt.Sprintf("Narrow the range to at most %s or combine the expression with an indexed term.", RegexpWindow),
+			})
+		}
+	}
+
+	return issues
+}
+
+func matchesAll(tokens []Token) bool {
+	for _, t := range tokens {
+		if t.Kind == Operator || t.Kind == Paren {
+			continue
+		}
+		if t.Kind == Term && t.Field == "" && !t.Negated && t.Value == "*" {
+			continue
+		}
+		return false
+	}
+
+	return true
+}
+
+func isLeadingWildcard(value string) bool {
+	return len(value) > 1 && strings.ContainsAny(value[:1], "*?")
+}
+
+func termString(t Token) string {
+	if t.Field != "" {
+		return t.Field + ":" + t.Value
+	}
+	return t.Value
+}
@@ -0,0 +1,63 @@
+package querylint
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize(`json.type:"upload failed" AND -foo /Black(Berry)?/ (bar OR baz)`)
+
+	want := []Token{
+		{Kind: Phrase, Field: "json.type", Value: "upload failed"},
+		{Kind: Operator, Value: "AND"},
+		{Kind: Term, Value: "foo", Negated: true},
+		{Kind: Regexp, Value: "Black(Berry)?"},
+		{Kind: Paren, Value: "("},
+		{Kind: Term, Value: "bar"},
+		{Kind: Operator, Value: "OR"},
+		{Kind: Term, Value: "baz"},
+		{Kind: Paren, Value: ")"},
+	}
+
+	if len(tokens) != len(want) {
+		t.Fatalf("expected %d tokens, got %d: %+v", len(want), len(tokens), tokens)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Errorf("token %d: expected %+v, got %+v", i, want[i], tokens[i])
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		query  string
+		window time.Duration
+		rules  []string
+	}{
+		{"json.level:error", 7 * 24 * time.Hour, nil},
+		{"*", 7 * 24 * time.Hour, []string{"match-all"}},
+		{"", 7 * 24 * time.Hour, []string{"match-all"}},
+		{"*", time.Hour, nil},
+		{"*timeout", time.Hour, []string{"leading-wildcard"}},
+		{"json.host:*-prod", time.Hour, []string{"leading-wildcard"}},
+		{"json.host:api-*", time.Hour, nil},
+		{"/time.*out/", 24 * time.Hour, []string{"regexp-window"}},
+		{"/time.*out/", 30 * time.Minute, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			issues := Lint(tt.query, tt.window)
+			if len(issues) != len(tt.rules) {
+				t.Fatalf("expected rules %v, got %+v", tt.rules, issues)
+			}
+			for i, rule := range tt.rules {
+				if issues[i].Rule != rule {
+					t.Errorf("expected rule %s, got %s", rule, issues[i].Rule)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/ingest"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// redacted Replaces the values of the fields redacted by replay.
+const redacted = "[REDACTED]"
+
+// replayEvent A message to replay and the time it was logged.
+type replayEvent struct {
+	at      time.Time
+	message []byte
+	// parsed The message if it is a JSON object.
+	parsed map[string]any
+}
+
+// parseSpeed Parse the -speed value, like 10x or 0.5. Zero or max
+// replays as fast as possible.
+func parseSpeed(s string) (float64, error) {
+	if s == "max" {
+		return 0, nil
+	}
+
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || speed < 0 {
+		return 0, fmt.Errorf("invalid speed %q, use a multiplier like 10x, or max", s)
+	}
+	return speed, nil
+}
+
+// eventTime The time of a whole event or of a message, from the epoch
+// milliseconds or the RFC 3339 timestamp field.
+func eventTime(event map[string]any) (time.Time, bool) {
+	if t, ok := search.EventTimestamp(event); ok {
+		return t, true
+	}
+
+	if s, ok := event["timestamp"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// readReplayEvents Read NDJSON events, whole Loggly events as printed by
+// export or -all, or messages, sorted by time.
+func readReplayEvents(r io.Reader) ([]replayEvent, error) {
+	var events []replayEvent
+
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	for n := 1; ; n++ {
+		var event map[string]any
+		if err := d.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("event %d: %w", n, err)
+		}
+
+		at, ok := eventTime(event)
+		if !ok {
+			return nil, fmt.Errorf("event %d: missing timestamp", n)
+		}
+
+		e := replayEvent{at: at}
+		if logmsg, ok := event["logmsg"].(string); ok {
+			e.message = []byte(logmsg)
+
+			msgDecoder := json.NewDecoder(strings.NewReader(logmsg))
+			msgDecoder.UseNumber()
+			var parsed map[string]any
+			if msgDecoder.Decode(&parsed) == nil {
+				e.parsed = parsed
+			}
+		} else {
+			e.parsed = event
+		}
+
+		events = append(events, e)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+	return events, nil
+}
+
+// redactField Replace the value of the dotted path in the message.
+func redactField(m map[string]any, path string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := m[key].(map[string]any)
+		if !ok {
+			return
+		}
+		m = child
+	}
+
+	if _, ok := m[keys[len(keys)-1]]; ok {
+		m[keys[len(keys)-1]] = redacted
+	}
+}
+
+// replayMessage The message to send, with the fields redacted and the
+// timestamp rewritten to the time of the replay.
+func replayMessage(e replayEvent, redact []string, rewriteTimestamps bool, now time.Time) ([]byte, error) {
+	if e.parsed == nil {
+		return e.message, nil
+	}
+	if e.message != nil && len(redact) == 0 && !rewriteTimestamps {
+		return e.message, nil
+	}
+
+	for _, path := range redact {
+		redactField(e.parsed, path)
+	}
+	if rewriteTimestamps {
+		e.parsed["timestamp"] = now.UTC().Format(time.RFC3339Nano)
+	}
+
+	return json.Marshal(e.parsed)
+}
+
+// runReplay Send the events of a file to the ingest endpoint, keeping
+// their relative timing sped up by -speed.
+func runReplay(args []string) {
+	var config Config
+	flags := newFlagSet("loggly replay", &config)
+	ingestOpts := addIngestFlags(flags)
+	tags := flags.String("tag", "replay", "")
+	speedFlag := flags.String("speed", "1x", "")
+	rewriteTimestamps := flags.Bool("rewrite-timestamps", false, "")
+	redact := flags.String("redact", "", "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+
+	if flags.NArg() != 1 {
+		check(usageError(fmt.Errorf("usage: loggly replay [options] <file>")))
+	}
+	speed, err := parseSpeed(*speedFlag)
+	check(usageError(err))
+
+	client, err := ingestOpts.newIngestClient(config, fileConfig)
+	check(err)
+	client.SetTags(splitList(*tags))
+
+	var in io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		check(usageError(err))
+		defer f.Close()
+		in = f
+	}
+
+	events, err := readReplayEvents(in)
+	check(err)
+	if len(events) == 0 {
+		return
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	start, first := time.Now(), events[0].at
+	due := func(e replayEvent) time.Time {
+		if speed == 0 {
+			return start
+		}
+		return start.Add(time.Duration(float64(e.at.Sub(first)) / speed))
+	}
+
+	sent := 0
+	report := func() {
+		fmt.Fprintln(os.Stderr, locale.Sprintf("Replayed %d of %d events", sent, len(events)))
+	}
+
+	for i := 0; i < len(events); {
+		if wait := time.Until(due(events[i])); wait > 0 {
+			select {
+			case <-ctx.Done():
+				report()
+				check(ctx.Err())
+			case <-time.After(wait):
+			}
+		}
+
+		// send every event due by now at once
+		now := time.Now()
+		var batch [][]byte
+		for ; i < len(events) && !due(events[i]).After(now); i++ {
+			message, err := replayMessage(events[i], splitList(*redact), *rewriteTimestamps, now)
+			check(err)
+
+			if bytes.ContainsAny(message, "\r\n") {
+				// the bulk endpoint takes single line events only
+				if err := client.Send(ctx, message); err != nil {
+					report()
+					check(err)
+				}
+				sent++
+				continue
+			}
+			batch = append(batch, message)
+		}
+
+		n, err := sendBatch(ctx, client, batch)
+		sent += n
+		if err != nil {
+			report()
+			check(err)
+		}
+	}
+
+	report()
+}
+
+func sendBatch(ctx context.Context, client *ingest.Client, batch [][]byte) (int, error) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	return client.SendBulk(ctx, batch)
+}
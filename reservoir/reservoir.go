@@ -0,0 +1,72 @@
+// Package reservoir implements reservoir sampling: picking a fixed
+// number of items uniformly from a stream of unknown length.
+package reservoir
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+)
+
+type Reservoir[T any] struct {
+	size  int
+	seen  int64
+	items []T
+	// position of each item in the stream, to return them in order
+	positions []int64
+	rnd       *rand.Rand
+}
+
+func New[T any](size int, rnd *rand.Rand) *Reservoir[T] {
+	if rnd == nil {
+		rnd = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+
+	return &Reservoir[T]{
+		size:      size,
+		items:     make([]T, 0, size),
+		positions: make([]int64, 0, size),
+		rnd:       rnd,
+	}
+}
+
+// Add Offer the next item of the stream to the sample.
+func (r *Reservoir[T]) Add(item T) {
+	pos := r.seen
+	r.seen++
+
+	if len(r.items) < r.size {
+		r.items = append(r.items, item)
+		r.positions = append(r.positions, pos)
+		return
+	}
+
+	if j := r.rnd.Int64N(r.seen); j < int64(r.size) {
+		r.items[j] = item
+		r.positions[j] = pos
+	}
+}
+
+// Seen Number of items offered so far.
+func (r *Reservoir[T]) Seen() int64 {
+	return r.seen
+}
+
+// Items The sampled items in the order they appeared in the stream.
+func (r *Reservoir[T]) Items() []T {
+	idx := make([]int, len(r.items))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	slices.SortFunc(idx, func(a, b int) int {
+		return cmp.Compare(r.positions[a], r.positions[b])
+	})
+
+	ret := make([]T, len(idx))
+	for i, k := range idx {
+		ret[i] = r.items[k]
+	}
+
+	return ret
+}
@@ -0,0 +1,61 @@
+package reservoir
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestReservoirKeepsAllWhenStreamIsShort(t *testing.T) {
+	r := New[int](5, nil)
+	for i := range 3 {
+		r.Add(i)
+	}
+
+	got := r.Items()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(got))
+	}
+	for i, v := range got {
+		if v != i {
+			t.Errorf("expected %d at index %d, got %d", i, i, v)
+		}
+	}
+}
+
+func TestReservoirItemsInStreamOrder(t *testing.T) {
+	r := New[int](10, rand.New(rand.NewPCG(1, 2)))
+	for i := range 1000 {
+		r.Add(i)
+	}
+
+	got := r.Items()
+	if len(got) != 10 {
+		t.Fatalf("expected 10 items, got %d", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Errorf("items are not in stream order: %v", got)
+		}
+	}
+	if r.Seen() != 1000 {
+		t.Errorf("expected 1000 seen, got %d", r.Seen())
+	}
+}
+
+func TestReservoirUniform(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(3, 4))
+	counts := make([]int, 10)
+	for range 10000 {
+		r := New[int](1, rnd)
+		for i := range 10 {
+			r.Add(i)
+		}
+		counts[r.Items()[0]]++
+	}
+
+	for i, c := range counts {
+		if c < 800 || c > 1200 {
+			t.Errorf("item %d picked %d times out of 10000, expected around 1000", i, c)
+		}
+	}
+}
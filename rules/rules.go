@@ -0,0 +1,173 @@
+// Package rules defines the files of the golden queries checked by
+// loggly assert: every rule is a query and the expectations its results
+// must meet, like
+//
+//	rules:
+//	  - name: no new fatals
+//	    query: json.level:FATAL
+//	    from: -10m
+//	    expect:
+//	      count: == 0
+//	  - name: request ids are logged
+//	    query: json.service:api
+//	    expect:
+//	      count: "> 0"
+//	      fields: [json.requestId]
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Expect The expectations of a rule.
+type Expect struct {
+	// Count A comparison of the number of matching events, like "== 0"
+	// or "< 50". A plain number means ==.
+	Count Count `yaml:"count,omitempty" json:"count,omitzero"`
+	// Fields The fields every fetched event must have.
+	Fields []string `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// Rule A query and its expectations. From and Until override the time
+// range of the command line.
+type Rule struct {
+	Name   string `yaml:"name"`
+	Query  string `yaml:"query"`
+	From   string `yaml:"from,omitempty"`
+	Until  string `yaml:"until,omitempty"`
+	Expect Expect `yaml:"expect"`
+}
+
+// File The rules of a file.
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Read Decode and validate the rules.
+func Read(r io.Reader) (File, error) {
+	var f File
+
+	d := yaml.NewDecoder(r)
+	d.KnownFields(true)
+	if err := d.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return File{}, fmt.Errorf("rules: invalid file: %w", err)
+	}
+
+	if len(f.Rules) == 0 {
+		return File{}, fmt.Errorf("rules: no rules")
+	}
+
+	for i := range f.Rules {
+		rule := &f.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if strings.TrimSpace(rule.Query) == "" {
+			return File{}, fmt.Errorf("rules: %s: missing query", rule.Name)
+		}
+		if rule.Expect.Count.IsZero() && len(rule.Expect.Fields) == 0 {
+			return File{}, fmt.Errorf("rules: %s: nothing is expected, set expect.count or expect.fields", rule.Name)
+		}
+	}
+
+	return f, nil
+}
+
+// Load Read the rules file.
+func Load(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Count An expected number of events, compared with op.
+type Count struct {
+	op string
+	n  int64
+}
+
+var countOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// ParseCount Parse a comparison like "< 50", a plain number means ==.
+func ParseCount(s string) (Count, error) {
+	s = strings.TrimSpace(s)
+
+	op := "=="
+	for _, o := range countOps {
+		if rest, ok := strings.CutPrefix(s, o); ok {
+			op, s = o, strings.TrimSpace(rest)
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return Count{}, fmt.Errorf("invalid count %q, use a comparison like == 0 or < 50", s)
+	}
+
+	return Count{op: op, n: n}, nil
+}
+
+// Match Whether n meets the expectation. An unset count matches
+// everything.
+func (c Count) Match(n int64) bool {
+	switch c.op {
+	case "==":
+		return n == c.n
+	case "!=":
+		return n != c.n
+	case "<":
+		return n < c.n
+	case "<=":
+		return n <= c.n
+	case ">":
+		return n > c.n
+	case ">=":
+		return n >= c.n
+	default:
+		return true
+	}
+}
+
+// IsZero Whether the count is unset.
+func (c Count) IsZero() bool {
+	return c.op == ""
+}
+
+func (c Count) String() string {
+	if c.op == "" {
+		return ""
+	}
+	return c.op + " " + strconv.FormatInt(c.n, 10)
+}
+
+// UnmarshalYAML Decode the comparison from a string or a number.
+func (c *Count) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: count must be a comparison like == 0", node.Line)
+	}
+
+	parsed, err := ParseCount(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+
+	*c = parsed
+	return nil
+}
+
+// MarshalText Encode the comparison as it was written.
+func (c Count) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
@@ -0,0 +1,122 @@
+package rules
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	f, err := Read(strings.NewReader(`
+rules:
+  - name: no new fatals
+    query: json.level:FATAL
+    from: -10m
+    expect:
+      count: == 0
+  - query: json.service:api
+    expect:
+      count: 3
+      fields: [json.requestId]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(f.Rules))
+	}
+
+	first := f.Rules[0]
+	if first.Name != "no new fatals" || first.From != "-10m" || first.Expect.Count.String() != "== 0" {
+		t.Errorf("unexpected first rule %+v", first)
+	}
+
+	second := f.Rules[1]
+	if second.Name != "rule 2" {
+		t.Errorf("expected the default name rule 2, got %q", second.Name)
+	}
+	if second.Expect.Count.String() != "== 3" {
+		t.Errorf("expected a plain number to mean ==, got %q", second.Expect.Count)
+	}
+	if len(second.Expect.Fields) != 1 || second.Expect.Fields[0] != "json.requestId" {
+		t.Errorf("unexpected fields %v", second.Expect.Fields)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":         ``,
+		"missing query": "rules:\n  - expect:\n      count: 0\n",
+		"no expect":     "rules:\n  - query: '*'\n",
+		"bad count":     "rules:\n  - query: '*'\n    expect:\n      count: about 3\n",
+		"unknown key":   "rules:\n  - query: '*'\n    expect:\n      cuont: 0\n",
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Read(strings.NewReader(input)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCountMatch(t *testing.T) {
+	tests := []struct {
+		expect string
+		n      int64
+		match  bool
+	}{
+		{"== 0", 0, true},
+		{"== 0", 1, false},
+		{"0", 0, true},
+		{"!= 0", 2, true},
+		{"< 50", 49, true},
+		{"< 50", 50, false},
+		{"<=50", 50, true},
+		{"> 1", 1, false},
+		{">= 1", 1, true},
+	}
+
+	for _, test := range tests {
+		c, err := ParseCount(test.expect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Match(test.n); got != test.match {
+			t.Errorf("%q with %d: expected %v, got %v", test.expect, test.n, test.match, got)
+		}
+	}
+
+	if !(Count{}).Match(42) {
+		t.Error("expected an unset count to match everything")
+	}
+}
+
+func TestCountJSON(t *testing.T) {
+	c, err := ParseCount("<10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(Expect{Count: c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["count"] != "< 10" {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	data, err = json.Marshal(Expect{Fields: []string{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"fields":["x"]}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+}
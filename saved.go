@@ -0,0 +1,227 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/Ajnasz/go-loggly-cli/savedsearch"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// savedSearchesFile The local saved searches, in the config directory.
+const savedSearchesFile = "saved-searches.json"
+
+// savedSearchesTimeout How long listing the saved searches of Loggly may
+// take before falling back to the local ones.
+const savedSearchesTimeout = 10 * time.Second
+
+func loadSavedSearches() (*savedsearch.Store, string) {
+	path, err := platform.ConfigFile(savedSearchesFile)
+	check(err)
+	store, err := savedsearch.Load(path)
+	check(err)
+	return store, path
+}
+
+// runSaved Handle the saved subcommands.
+func runSaved(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			runSavedList(args[1:])
+			return
+		case "run":
+			runSavedRun(args[1:])
+			return
+		case "save":
+			runSavedSave(args[1:])
+			return
+		case "delete":
+			runSavedDelete(args[1:])
+			return
+		}
+	}
+
+	check(usageError(fmt.Errorf("usage: loggly saved list [options] | loggly saved run <name> [options] | loggly saved save [options] <name> [query...] | loggly saved delete <name>")))
+}
+
+// remoteSavedSearches The saved searches of the Loggly account. When
+// they can not be listed it warns and returns none, the local ones are
+// the fallback.
+func remoteSavedSearches(config Config) []search.SavedSearch {
+	if config.Input != "" || len(splitList(config.Account)) != 1 || config.Token == "" {
+		return nil
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, savedSearchesTimeout)
+	defer cancelTimeout()
+
+	c, err := config.newClient()
+	if err == nil {
+		var searches []search.SavedSearch
+		if searches, err = c.SavedSearches(ctx); err == nil {
+			return searches
+		}
+	}
+	config.warnf("The saved searches of Loggly could not be listed, only the local ones are used: %s", err)
+	return nil
+}
+
+// runSavedList Print the saved searches of Loggly and the local ones.
+func runSavedList(args []string) {
+	var config Config
+	flags := newFlagSet("loggly saved list", &config)
+	flags.Parse(args)
+
+	prepareScanConfig(flags, &config)
+	store, _ := loadSavedSearches()
+
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSOURCE\tFROM\tUNTIL\tQUERY")
+	for _, s := range store.Searches {
+		fmt.Fprintf(tw, "%s\tlocal\t%s\t%s\t%s\n", s.Name, orDash(s.From), orDash(s.Until), s.Query)
+	}
+	for _, s := range remoteSavedSearches(config) {
+		fmt.Fprintf(tw, "%s\tloggly\t%s\t%s\t%s\n", s.Name, orDash(s.From), orDash(s.Until), s.Query)
+	}
+	check(tw.Flush())
+}
+
+// findSavedSearch The saved search of the name, the local one first,
+// then the one of Loggly, resolving the credentials of the options, and
+// whether it is the one of Loggly.
+func findSavedSearch(name string, flags *flag.FlagSet, config *Config) (savedsearch.Search, bool) {
+	store, _ := loadSavedSearches()
+	if s, ok := store.Get(name); ok {
+		return s, false
+	}
+
+	prepareScanConfig(flags, config)
+	for _, s := range remoteSavedSearches(*config) {
+		if s.Name == name {
+			return savedsearch.Search{Name: s.Name, Query: s.Query, From: s.From, Until: s.Until}, true
+		}
+	}
+
+	check(usageError(fmt.Errorf("no saved search %q, see loggly saved list", name)))
+	return savedsearch.Search{}, false
+}
+
+// runSavedRun Search with the query and the time range of the saved
+// search. The options are the ones of loggly search, -from and -until
+// override the saved time range.
+func runSavedRun(args []string) {
+	if len(args) == 0 {
+		check(usageError(fmt.Errorf("usage: loggly saved run <name> [options]")))
+	}
+	name, options := args[0], args[1:]
+
+	var config Config
+	flags, _ := newRunFlagSet(&config)
+	flags.Parse(options)
+	if flags.NArg() > 0 || config.Query != "" || config.QueryFile != "" {
+		check(usageError(fmt.Errorf("the query of the saved search %s can not be changed, save a new one", name)))
+	}
+
+	s, remote := findSavedSearch(name, flags, &config)
+
+	var runArgs []string
+	if s.From != "" {
+		runArgs = append(runArgs, "-from", s.From)
+	}
+	if s.Until != "" {
+		runArgs = append(runArgs, "-until", s.Until)
+	}
+	runArgs = append(runArgs, options...)
+	runArgs = append(runArgs, "--", s.Query)
+
+	var opts runOptions
+	if remote {
+		// the token is resolved already, the standard input is read
+		// only once
+		token := config.Token
+		opts.override = func(c *Config) {
+			c.Token, c.TokenFile = token, ""
+		}
+	}
+	run(runArgs, opts)
+}
+
+// runSavedSave Store the query under the name locally, with -from and
+// -until when they are given.
+func runSavedSave(args []string) {
+	var config Config
+	flags := newFlagSet("loggly saved save", &config)
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+
+	if flags.NArg() == 0 {
+		check(usageError(fmt.Errorf("usage: loggly saved save [options] <name> [query...]")))
+	}
+	name := flags.Arg(0)
+	check(usageError(savedsearch.ValidateName(name)))
+
+	query, queryErr := resolveQuery(config, flags.Args()[1:])
+	check(queryErr)
+	if query == "" {
+		check(usageError(fmt.Errorf("the query of the saved search is missing")))
+	}
+
+	s := savedsearch.Search{Name: name, Query: query, SavedAt: time.Now().UTC()}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "from":
+			s.From = config.From
+		case "to", "until":
+			s.Until = config.To
+		}
+	})
+	now := time.Now()
+	if s.From != "" {
+		check(usageError(validateTimeRange(s.From, cmp.Or(s.Until, "now"), now)))
+	} else if s.Until != "" {
+		_, err := normalizeTime("until", s.Until, now)
+		check(usageError(err))
+	}
+
+	store, path := loadSavedSearches()
+	store.Put(s)
+	check(store.Save(path))
+
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Saved the search %s", name))
+}
+
+// runSavedDelete Remove the local saved search. The saved searches of
+// Loggly are managed in its web UI.
+func runSavedDelete(args []string) {
+	if len(args) != 1 {
+		check(usageError(fmt.Errorf("usage: loggly saved delete <name>")))
+	}
+	name := args[0]
+
+	store, path := loadSavedSearches()
+	if !store.Delete(name) {
+		check(usageError(fmt.Errorf("no local saved search %q, the saved searches of Loggly are deleted in its web UI", name)))
+	}
+	check(store.Save(path))
+
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Deleted the saved search %s", name))
+}
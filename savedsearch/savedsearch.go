@@ -0,0 +1,119 @@
+// Package savedsearch stores canned queries under a name, the local
+// fallback of the saved searches of Loggly: a JSON file of the searches
+// with their time ranges.
+package savedsearch
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/platform"
+)
+
+// Version The version of the store file. Saving a newer store would
+// drop the settings of the searches this loggly does not know, so it is
+// refused instead of rewritten.
+const Version = 1
+
+// Search A saved query. The empty From and Until leave the time range
+// to the options of the run.
+type Search struct {
+	Name    string    `json:"name"`
+	Query   string    `json:"query"`
+	From    string    `json:"from,omitempty"`
+	Until   string    `json:"until,omitempty"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Store The saved searches, sorted by name.
+type Store struct {
+	Version  int      `json:"version"`
+	Searches []Search `json:"searches"`
+}
+
+// ValidateName Check that the name can be given on the command line
+// without quoting.
+func ValidateName(name string) error {
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool { return r <= ' ' }) {
+		return fmt.Errorf("invalid saved search name %q, use a name without spaces", name)
+	}
+	return nil
+}
+
+// Get The search of the name.
+func (s *Store) Get(name string) (Search, bool) {
+	i := slices.IndexFunc(s.Searches, func(search Search) bool { return search.Name == name })
+	if i < 0 {
+		return Search{}, false
+	}
+	return s.Searches[i], true
+}
+
+// Put Add the search, replacing the one of the same name.
+func (s *Store) Put(search Search) {
+	s.Delete(search.Name)
+	s.Searches = append(s.Searches, search)
+	slices.SortFunc(s.Searches, func(a, b Search) int { return cmp.Compare(a.Name, b.Name) })
+}
+
+// Delete Remove the search of the name, tells whether it existed.
+func (s *Store) Delete(name string) bool {
+	n := len(s.Searches)
+	s.Searches = slices.DeleteFunc(s.Searches, func(search Search) bool { return search.Name == name })
+	return len(s.Searches) < n
+}
+
+// Read Decode a store.
+func Read(r io.Reader) (*Store, error) {
+	var s Store
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("savedsearch: invalid file: %w", err)
+	}
+
+	if s.Version < 1 {
+		return nil, fmt.Errorf("savedsearch: missing version")
+	}
+	if s.Version > Version {
+		return nil, fmt.Errorf("savedsearch: version %d is newer than the supported %d, update loggly", s.Version, Version)
+	}
+
+	return &s, nil
+}
+
+// Load Read the store file, an empty store when it does not exist yet.
+func Load(path string) (*Store, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Store{Version: Version}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Save Write the store file through a temporary file, so an interrupted
+// save keeps the previous searches.
+func (s *Store) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	s.Version = Version
+	return platform.WriteFile(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	})
+}
@@ -0,0 +1,83 @@
+package savedsearch
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 10, 17, 10, 15, 0, 0, time.UTC)
+
+func TestPutGetDelete(t *testing.T) {
+	s := &Store{}
+	s.Put(Search{Name: "payment-errors", Query: "json.service:payment AND json.level:error", From: "-1h"})
+	s.Put(Search{Name: "5xx", Query: "json.http.status:>=500"})
+	s.Put(Search{Name: "payment-errors", Query: "json.service:payment", From: "-2h"})
+
+	var names []string
+	for _, search := range s.Searches {
+		names = append(names, search.Name)
+	}
+	if want := []string{"5xx", "payment-errors"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+
+	got, ok := s.Get("payment-errors")
+	if !ok || got.Query != "json.service:payment" || got.From != "-2h" {
+		t.Errorf("expected the replaced search, got %+v", got)
+	}
+
+	if !s.Delete("5xx") || s.Delete("5xx") {
+		t.Error("expected the search to be deleted once")
+	}
+	if _, ok := s.Get("5xx"); ok {
+		t.Error("expected the deleted search to be gone")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saved", "searches.json")
+
+	empty, err := Load(path)
+	if err != nil || len(empty.Searches) != 0 {
+		t.Fatalf("expected an empty store of a missing file, got %+v, %v", empty, err)
+	}
+
+	s := &Store{}
+	s.Put(Search{Name: "errors", Query: "json.level:error", Until: "now", SavedAt: now})
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Searches, s.Searches) {
+		t.Errorf("expected %+v, got %+v", s.Searches, loaded.Searches)
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+	if _, err := Read(strings.NewReader(`{"version": 2, "searches": []}`)); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected a newer version error, got %v", err)
+	}
+	if _, err := Read(strings.NewReader(`{"searches": []}`)); err == nil {
+		t.Error("expected a missing version error")
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"errors", "payment-errors", "5xx"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("%q: unexpected error %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "payment errors", "tab\tname"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
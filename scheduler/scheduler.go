@@ -0,0 +1,142 @@
+// Package scheduler runs queries periodically for the polling modes,
+// like watch and tail. It keeps at most one run in flight per key,
+// skips the ticks missed by slow runs instead of piling them up, jitters
+// the first run, and backs off every job on repeated API failures.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Job A single run of a scheduled query.
+type Job func(ctx context.Context) error
+
+type Options struct {
+	// Interval Time between the starts of two runs.
+	Interval time.Duration
+	// Jitter Maximum random delay of the first run, to spread the
+	// requests of jobs started together.
+	Jitter time.Duration
+	// MaxBackoff Upper limit of the delay added after failures.
+	MaxBackoff time.Duration
+	// OnError Called with the errors of the runs.
+	OnError func(key string, err error)
+	Rand    *rand.Rand
+}
+
+type Scheduler struct {
+	opts Options
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+	failures int
+}
+
+func New(opts Options) *Scheduler {
+	if opts.Rand == nil {
+		opts.Rand = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Minute
+	}
+
+	return &Scheduler{
+		opts:     opts,
+		inFlight: make(map[string]bool),
+	}
+}
+
+// TryRun Run the job unless a run of the same key is in flight. Returns
+// whether the job ran.
+func (s *Scheduler) TryRun(ctx context.Context, key string, job Job) (bool, error) {
+	s.mu.Lock()
+	if s.inFlight[key] {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.inFlight[key] = true
+	s.mu.Unlock()
+
+	err := job(ctx)
+
+	s.mu.Lock()
+	delete(s.inFlight, key)
+	// a run canceled by the caller is not an API failure
+	if err != nil && ctx.Err() == nil {
+		s.failures++
+	} else if err == nil {
+		s.failures = 0
+	}
+	s.mu.Unlock()
+
+	return true, err
+}
+
+// Backoff The delay added to every job after consecutive failures,
+// doubling with each failure up to MaxBackoff.
+func (s *Scheduler) Backoff() time.Duration {
+	s.mu.Lock()
+	failures := s.failures
+	s.mu.Unlock()
+
+	if failures == 0 {
+		return 0
+	}
+
+	backoff := max(s.opts.Interval, time.Second)
+	for i := 1; i < failures && backoff < s.opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, s.opts.MaxBackoff)
+}
+
+// retryDelay How long the failed run was asked to wait before the next
+// one, like the Retry-After header of the API, 0 when it was not.
+func retryDelay(err error) time.Duration {
+	var r interface{ RetryAfter() time.Duration }
+	if errors.As(err, &r) {
+		return r.RetryAfter()
+	}
+	return 0
+}
+
+// Every Run the job every interval until the context is canceled.
+func (s *Scheduler) Every(ctx context.Context, key string, job Job) {
+	var delay time.Duration
+	if s.opts.Jitter > 0 {
+		s.mu.Lock()
+		delay = time.Duration(s.opts.Rand.Int64N(int64(s.opts.Jitter)))
+		s.mu.Unlock()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		ran, err := s.TryRun(ctx, key, job)
+		if ran && err != nil && ctx.Err() == nil && s.opts.OnError != nil {
+			s.opts.OnError(key, err)
+		}
+
+		// A run slower than the interval starts the next one right
+		// away, the missed ticks are dropped. The API may ask for a
+		// longer wait than the backoff.
+		next := max(s.opts.Interval-time.Since(start), 0) + s.Backoff()
+		if ran && err != nil {
+			next = max(next, min(retryDelay(err), s.opts.MaxBackoff))
+		}
+		timer.Reset(next)
+	}
+}
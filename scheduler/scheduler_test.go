@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTryRunOneInFlight(t *testing.T) {
+	s := New(Options{Interval: time.Second})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.TryRun(context.Background(), "q", func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+
+	<-started
+	ran, _ := s.TryRun(context.Background(), "q", func(context.Context) error { return nil })
+	if ran {
+		t.Error("expected the second run of the same key to be skipped")
+	}
+
+	ran, _ = s.TryRun(context.Background(), "other", func(context.Context) error { return nil })
+	if !ran {
+		t.Error("expected a different key to run")
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestBackoff(t *testing.T) {
+	s := New(Options{Interval: time.Second, MaxBackoff: 5 * time.Second})
+	fail := func(context.Context) error { return errors.New("boom") }
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		s.TryRun(context.Background(), "q", fail)
+		if got := s.Backoff(); got != w {
+			t.Errorf("after %d failures expected %s backoff, got %s", i+1, w, got)
+		}
+	}
+
+	s.TryRun(context.Background(), "q", func(context.Context) error { return nil })
+	if got := s.Backoff(); got != 0 {
+		t.Errorf("expected the backoff to reset after a success, got %s", got)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	var runs atomic.Int32
+	var errs atomic.Int32
+	s := New(Options{
+		Interval: 5 * time.Millisecond,
+		Jitter:   time.Millisecond,
+		OnError:  func(string, error) { errs.Add(1) },
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+
+	s.Every(ctx, "q", func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	if n := runs.Load(); n < 2 {
+		t.Errorf("expected repeated runs, got %d", n)
+	}
+	if n := errs.Load(); n != 0 {
+		t.Errorf("expected no errors, got %d", n)
+	}
+}
+
+type retryError time.Duration
+
+func (e retryError) Error() string             { return "unavailable" }
+func (e retryError) RetryAfter() time.Duration { return time.Duration(e) }
+
+func TestEveryRetryAfter(t *testing.T) {
+	var runs atomic.Int32
+	s := New(Options{Interval: 5 * time.Millisecond, MaxBackoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+
+	s.Every(ctx, "q", func(context.Context) error {
+		runs.Add(1)
+		return retryError(time.Minute)
+	})
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("expected the next run to wait for the retry after, got %d runs", n)
+	}
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"net/http"
+)
+
+// Authenticator Adds the credentials to the requests sent to the API,
+// for example for a gateway fronting Loggly with its own authentication.
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+// AuthenticatorFunc A function used as an Authenticator.
+type AuthenticatorFunc func(r *http.Request) error
+
+// Authenticate Call the function.
+func (f AuthenticatorFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+// BearerToken Authenticates with an API token of Loggly, the default.
+type BearerToken string
+
+// Authenticate Set the token as the bearer token.
+func (t BearerToken) Authenticate(r *http.Request) error {
+	r.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// BasicAuth Authenticates with a user name and a password.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate Set the basic authentication header.
+func (a BasicAuth) Authenticate(r *http.Request) error {
+	r.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// Headers Authenticates with fixed headers, like the pre-signed headers
+// of a gateway.
+type Headers http.Header
+
+// Authenticate Set the headers.
+func (h Headers) Authenticate(r *http.Request) error {
+	for name, values := range h {
+		r.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	return nil
+}
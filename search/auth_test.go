@@ -0,0 +1,56 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAuthenticators(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   Authenticator
+		header string
+		want   string
+	}{
+		{"default", nil, "Authorization", "Bearer token"},
+		{"bearer", BearerToken("other"), "Authorization", "Bearer other"},
+		{"basic", BasicAuth{Username: "alice", Password: "secret"}, "Authorization", "Basic YWxpY2U6c2VjcmV0"},
+		{"headers", Headers{"x-gateway-signature": {"signed"}}, "X-Gateway-Signature", "signed"},
+		{"func", AuthenticatorFunc(func(r *http.Request) error {
+			r.Header.Set("X-Api-Key", "key")
+			return nil
+		}), "X-Api-Key", "key"},
+	}
+
+	for _, test := range tests {
+		c := New("acme", "token")
+		if test.auth != nil {
+			c.SetAuthenticator(test.auth)
+		}
+
+		r, err := c.NewRequest(context.Background(), "/search")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := r.Header.Get(test.header); got != test.want {
+			t.Errorf("%s: expected %s %q, got %q", test.name, test.header, test.want, got)
+		}
+		if test.header != "Authorization" && r.Header.Get("Authorization") != "" {
+			t.Errorf("%s: expected no bearer token, got %q", test.name, r.Header.Get("Authorization"))
+		}
+	}
+}
+
+func TestAuthenticatorError(t *testing.T) {
+	failed := errors.New("no signature")
+	c := New("acme", "token").SetAuthenticator(AuthenticatorFunc(func(*http.Request) error {
+		return failed
+	}))
+
+	if _, err := c.NewRequest(context.Background(), "/search"); !errors.Is(err, failed) {
+		t.Errorf("expected the error of the authenticator, got %v", err)
+	}
+}
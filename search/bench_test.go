@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/mockserver"
+)
+
+// benchEventCount Number of the events served to the fetch benchmarks.
+const benchEventCount = 5000
+
+// benchEvents n typical events, newest first, a millisecond apart.
+func benchEvents(n int) []mockserver.Event {
+	now := time.Now()
+	events := make([]mockserver.Event, n)
+	for i := range events {
+		msg := map[string]any{
+			"level":    "info",
+			"message":  "request completed",
+			"duration": i,
+			"http":     map[string]any{"method": "GET", "path": "/v1/orders", "status": 200},
+			"user":     map[string]any{"id": fmt.Sprintf("u%03d", i%100)},
+		}
+		events[i] = mockserver.Event{
+			ID:        fmt.Sprintf("ev-%06d", i),
+			Timestamp: now.Add(-time.Duration(i) * time.Millisecond).UnixMilli(),
+			LogMsg:    fmt.Sprintf(`{"level":"info","message":"request completed","duration":%d}`, i),
+			Tags:      []string{"web", "prod"},
+			LogTypes:  []string{"json"},
+			Event:     map[string]any{"json": msg},
+		}
+	}
+	return events
+}
+
+func startBenchServer(b *testing.B, eventsDelay func(page int) time.Duration) *mockserver.Server {
+	b.Helper()
+
+	srv := mockserver.NewWithEvents(benchEvents(benchEventCount))
+	srv.EventsDelay = eventsDelay
+	if err := srv.Start(); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { srv.Close() })
+
+	return srv
+}
+
+// benchFetch Fetch every event of the server, reporting the events
+// fetched per second.
+func benchFetch(b *testing.B, srv *mockserver.Server, pageSize int, concurrency int, raw bool) {
+	b.Helper()
+
+	c := New("demo", "demo").SetEndpoint(srv.URL).SetConcurrency(concurrency)
+	pages := int64((benchEventCount + pageSize - 1) / pageSize)
+	q := NewQuery("*").From("-1h").Size(pageSize).MaxPage(pages).Raw(raw)
+
+	b.ReportAllocs()
+	events := 0
+	for b.Loop() {
+		resChan, errChan := c.Fetch(context.Background(), *q)
+		for _, r := range collect(b, resChan, errChan) {
+			events += r.Len()
+		}
+	}
+
+	if events < b.N*benchEventCount {
+		b.Fatalf("expected %d events per run, got %d in %d runs", benchEventCount, events, b.N)
+	}
+	b.ReportMetric(float64(events)/b.Elapsed().Seconds(), "events/s")
+}
+
+// BenchmarkFetchPageSize End-to-end fetch throughput by page size.
+func BenchmarkFetchPageSize(b *testing.B) {
+	srv := startBenchServer(b, nil)
+	for _, size := range []int{100, 500, 1000} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			benchFetch(b, srv, size, 3, false)
+		})
+	}
+}
+
+// BenchmarkFetchConcurrency End-to-end fetch throughput by the number
+// of the pages fetched at once, with a latency like a remote API.
+func BenchmarkFetchConcurrency(b *testing.B) {
+	srv := startBenchServer(b, func(int) time.Duration { return 5 * time.Millisecond })
+	for _, concurrency := range []int{1, 3, 8} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			benchFetch(b, srv, 250, concurrency, false)
+		})
+	}
+}
+
+// BenchmarkFetchOutOfOrder End-to-end fetch throughput when the later
+// pages complete first, and are held back until the earlier ones
+// arrive.
+func BenchmarkFetchOutOfOrder(b *testing.B) {
+	const pageSize = 250
+	pages := benchEventCount / pageSize
+	srv := startBenchServer(b, func(page int) time.Duration {
+		return time.Duration(pages-page) * time.Millisecond
+	})
+
+	b.Run("decoded", func(b *testing.B) {
+		benchFetch(b, srv, pageSize, 8, false)
+	})
+	b.Run("raw", func(b *testing.B) {
+		benchFetch(b, srv, pageSize, 8, true)
+	})
+}
@@ -0,0 +1,159 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+)
+
+// Event A Loggly event as returned by the events API, a JSON object
+// decoded to a map[string]any.
+type Event = any
+
+// DecodeError The message of an event could not be decoded.
+type DecodeError struct {
+	// Index the position of the event in the decoded events
+	Index int
+	// ID the id Loggly gave the event, empty if it has none
+	ID        string
+	Timestamp time.Time
+	Err       error
+}
+
+func (e *DecodeError) Error() string {
+	var context []string
+	if e.ID != "" {
+		context = append(context, "id "+e.ID)
+	}
+	if !e.Timestamp.IsZero() {
+		context = append(context, e.Timestamp.UTC().Format(time.RFC3339Nano))
+	}
+
+	if len(context) == 0 {
+		return fmt.Sprintf("event %d: %s", e.Index, e.Err)
+	}
+	return fmt.Sprintf("event %d (%s): %s", e.Index, strings.Join(context, ", "), e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// errNoMessage The event has neither a logmsg nor a parsed JSON message.
+var errNoMessage = errors.New("no JSON message in the event")
+
+// eventMessage The JSON of the message of the event, the logmsg or else
+// the message Loggly parsed into event.json.
+func eventMessage(event Event) ([]byte, error) {
+	m, ok := event.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("the event is a %T, not an object", event)
+	}
+
+	if msg, ok := m["logmsg"].(string); ok {
+		return []byte(msg), nil
+	}
+
+	if inner, ok := m["event"].(map[string]any); ok {
+		if parsed, ok := inner["json"]; ok {
+			return json.Marshal(parsed)
+		}
+	}
+
+	return nil, errNoMessage
+}
+
+// decodeEvent Decode the message of the event at index into a T.
+func decodeEvent[T any](index int, event Event) (T, error) {
+	var v T
+	data, err := eventMessage(event)
+	if err == nil {
+		err = json.Unmarshal(data, &v)
+	}
+	if err == nil {
+		return v, nil
+	}
+
+	decodeErr := &DecodeError{Index: index, Err: err}
+	if m, ok := event.(map[string]any); ok {
+		decodeErr.ID, _ = m["id"].(string)
+	}
+	decodeErr.Timestamp, _ = EventTimestamp(event)
+
+	return v, decodeErr
+}
+
+// DecodeEvents Decode the JSON messages of the events into the caller's
+// type, like:
+//
+//	type request struct {
+//		Level    string `json:"level"`
+//		Duration int    `json:"duration"`
+//	}
+//	requests, err := search.DecodeEvents[request](res.Events)
+//
+// The returned slice has a value for every event, the zero value for the
+// ones which failed to decode. The error joins a *DecodeError per failed
+// event.
+func DecodeEvents[T any](events []Event) ([]T, error) {
+	decoded := make([]T, len(events))
+	var errs []error
+	for i, event := range events {
+		v, err := decodeEvent[T](i, event)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		decoded[i] = v
+	}
+
+	return decoded, errors.Join(errs...)
+}
+
+// Events Iterate over the events of the query, fetching the pages as the
+// iteration reaches them. A failed fetch is yielded as the last error.
+func (c *Client) Events(ctx context.Context, q Query) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		pages := c.Pages(q)
+		for {
+			res, ok, err := pages.Next(ctx)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !ok {
+				return
+			}
+
+			for _, event := range res.Events {
+				if !yield(event, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Decoded Decode the messages of the iterated events into the caller's
+// type. An event which fails to decode is yielded as a *DecodeError, the
+// iteration goes on unless the caller stops it.
+func Decoded[T any](events iter.Seq2[Event, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		index := 0
+		for event, err := range events {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			if !yield(decodeEvent[T](index, event)) {
+				return
+			}
+			index++
+		}
+	}
+}
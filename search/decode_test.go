@@ -0,0 +1,64 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type decodedRequest struct {
+	Level    string `json:"level"`
+	Duration int    `json:"duration"`
+}
+
+func TestDecodeEvents(t *testing.T) {
+	events := []Event{
+		map[string]any{"id": "a", "logmsg": `{"level":"info","duration":12}`},
+		map[string]any{"id": "b", "timestamp": json.Number("1700000000000"), "logmsg": `{"level":"warn","duration":"slow"}`},
+		map[string]any{"event": map[string]any{"json": map[string]any{"level": "error", "duration": 7}}},
+		map[string]any{"id": "d", "logmsg": "plain text"},
+	}
+
+	decoded, err := DecodeEvents[decodedRequest](events)
+	if len(decoded) != len(events) {
+		t.Fatalf("expected %d values, got %d", len(events), len(decoded))
+	}
+	if decoded[0] != (decodedRequest{"info", 12}) || decoded[2] != (decodedRequest{"error", 7}) {
+		t.Errorf("unexpected values %+v", decoded)
+	}
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected a DecodeError, got %v", err)
+	}
+	if decodeErr.Index != 1 || decodeErr.ID != "b" {
+		t.Errorf("expected the error of event 1, got %+v", decodeErr)
+	}
+	for _, want := range []string{"event 1 (id b, 2023-11-14T22:13:20Z)", "Go struct field", "event 3 (id d)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the error, got %q", want, err)
+		}
+	}
+}
+
+func TestDecodedAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+	c := New("demo", "demo").SetEndpoint(srv.URL)
+
+	var n int
+	for req, err := range Decoded[decodedRequest](c.Events(context.Background(), *NewQuery("*").Size(10).MaxPage(1))) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.Level == "" {
+			t.Errorf("event %d: expected a level", n)
+		}
+		n++
+	}
+
+	if n != 20 {
+		t.Errorf("expected 20 events, got %d", n)
+	}
+}
@@ -0,0 +1,174 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIError Error response of the Loggly API.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	// ContentType of the response body.
+	ContentType string
+	// Hint actionable advice about the likely cause, may be empty.
+	Hint string
+
+	retryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("go-loggly-search: %q, %s", e.Status, e.summary())
+	if e.Hint != "" {
+		msg += "\n" + e.Hint
+	}
+	if e.IsAuthError() {
+		msg += "\nRun `loggly auth check` to verify the account and the token."
+	}
+	return msg
+}
+
+// IsAuthError Tell whether the API rejected the credentials.
+func (e *APIError) IsAuthError() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsHTML Tell whether the API answered with a web page, like a
+// maintenance page or the error page of a gateway, instead of JSON.
+func (e *APIError) IsHTML() bool {
+	return isHTML(e.ContentType, e.Body)
+}
+
+// Transient Tell whether the failure is likely temporary: rate limiting,
+// a gateway error or a maintenance page. The request is worth retrying
+// later.
+func (e *APIError) Transient() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return e.IsHTML() && !e.IsAuthError() && e.StatusCode != http.StatusNotFound
+}
+
+// RetryAfter How long the API asked to wait before retrying, from the
+// Retry-After header, 0 when it did not tell.
+func (e *APIError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// summary The response body, or only the title and the size of the web
+// pages, their markup is of no use in a terminal.
+func (e *APIError) summary() string {
+	if !e.IsHTML() {
+		return string(e.Body)
+	}
+	if title := htmlTitle(e.Body); title != "" {
+		return fmt.Sprintf("HTML page %q (%d bytes)", title, len(e.Body))
+	}
+	return fmt.Sprintf("HTML page (%d bytes)", len(e.Body))
+}
+
+func newAPIError(res *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode:  res.StatusCode,
+		Status:      res.Status,
+		Body:        body,
+		ContentType: res.Header.Get("Content-Type"),
+		Hint:        authHint(res.StatusCode, body),
+		retryAfter:  parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+	}
+	if e.Hint == "" {
+		e.Hint = transientHint(e)
+	}
+	return e
+}
+
+// isHTML Tell whether the body is a web page, from its content type, or
+// from its start when the content type is missing or wrong.
+func isHTML(contentType string, body []byte) bool {
+	if strings.HasPrefix(strings.ToLower(contentType), "text/html") {
+		return true
+	}
+	start := strings.ToLower(string(body[:min(len(body), 512)]))
+	start = strings.TrimSpace(start)
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
+
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// htmlTitle The title of the web page, empty when it has none.
+func htmlTitle(body []byte) string {
+	m := titleRegexp.FindSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+}
+
+// parseRetryAfter The delay of a Retry-After header, given in seconds or
+// as a date. 0 when the header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now).Round(time.Second), 0)
+	}
+	return 0
+}
+
+// transientHint Advice about the temporary failures, when to retry.
+func transientHint(e *APIError) string {
+	if !e.Transient() {
+		return ""
+	}
+
+	var msg string
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		msg = "Too many requests, Loggly throttled the account."
+	case e.IsHTML():
+		msg = "Loggly answered with a web page instead of JSON, it is probably down for maintenance."
+	default:
+		msg = "Loggly is temporarily unavailable."
+	}
+
+	if e.retryAfter > 0 {
+		return msg + fmt.Sprintf(" Retry after %s.", e.retryAfter)
+	}
+	return msg + " Retry in a few minutes."
+}
+
+// authHint Guess why the credentials were rejected from the shape of
+// the response body.
+func authHint(statusCode int, body []byte) string {
+	if statusCode != http.StatusUnauthorized && statusCode != http.StatusForbidden {
+		return ""
+	}
+
+	var payload map[string]any
+	isJSON := json.Unmarshal(body, &payload) == nil
+	text := strings.ToLower(string(bytes.TrimSpace(body)))
+
+	switch {
+	case !isJSON && strings.Contains(text, "<html"):
+		return "The API answered with a web page instead of JSON, the account subdomain is probably wrong. Use the subdomain of your Loggly URL, e.g. \"acme\" for acme.loggly.com."
+	case strings.Contains(text, "subdomain") || strings.Contains(text, "account"):
+		return "The token does not belong to this account, check the -account value."
+	case statusCode == http.StatusForbidden:
+		return "The token is valid but lacks the permission to search. Create an API token with read access in Loggly (Source Setup > API Tokens)."
+	default:
+		return "The token was rejected. It may be mistyped, expired or revoked, or it may be a customer (ingest) token instead of an API token."
+	}
+}
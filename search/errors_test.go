@@ -0,0 +1,142 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthHint(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"not an auth error", 500, "oops", ""},
+		{"html page", 401, "<html><body>Login</body></html>", "subdomain is probably wrong"},
+		{"wrong account", 403, `{"message": "token does not match the subdomain"}`, "does not belong to this account"},
+		{"missing scope", 403, `{"message": "forbidden"}`, "lacks the permission"},
+		{"invalid token", 401, `{"message": "unauthorized"}`, "token was rejected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := authHint(tt.status, []byte(tt.body))
+			if tt.want == "" && got != "" {
+				t.Errorf("expected no hint, got %q", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("expected hint containing %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+const maintenancePage = `<!DOCTYPE html>
+<html><head><title>Loggly &amp; Co
+  is down for maintenance</title></head>
+<body>` + "We will be back soon." + `</body></html>`
+
+func TestMaintenancePage(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		retryAfter  string
+		wantRetry   time.Duration
+		wantHint    string
+	}{
+		{"service unavailable", http.StatusServiceUnavailable, "text/html; charset=utf-8", "120", 2 * time.Minute, "Retry after 2m0s."},
+		{"ok with a web page", http.StatusOK, "", "", 0, "Retry in a few minutes."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(maintenancePage))
+			}))
+			defer srv.Close()
+
+			_, err := New("demo", "demo").SetEndpoint(srv.URL).CreateSearch(context.Background(), "q=*")
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %v", err)
+			}
+			if !apiErr.IsHTML() || !apiErr.Transient() {
+				t.Errorf("expected a transient HTML error, got %+v", apiErr)
+			}
+			if got := apiErr.RetryAfter(); got != tt.wantRetry {
+				t.Errorf("expected %s retry after, got %s", tt.wantRetry, got)
+			}
+
+			msg := err.Error()
+			if strings.Contains(msg, "<html") || strings.Contains(msg, "back soon") {
+				t.Errorf("expected the page to be summarized, got %q", msg)
+			}
+			if !strings.Contains(msg, `HTML page "Loggly & Co is down for maintenance"`) {
+				t.Errorf("expected the title of the page, got %q", msg)
+			}
+			if !strings.Contains(msg, tt.wantHint) {
+				t.Errorf("expected hint %q, got %q", tt.wantHint, msg)
+			}
+		})
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   bool
+	}{
+		{http.StatusTooManyRequests, `{"message": "slow down"}`, true},
+		{http.StatusBadGateway, "", true},
+		{http.StatusGatewayTimeout, "", true},
+		{http.StatusInternalServerError, `{"message": "oops"}`, false},
+		{http.StatusInternalServerError, "<html>oops</html>", true},
+		{http.StatusBadRequest, `{"message": "invalid query"}`, false},
+		{http.StatusUnauthorized, "<html>Login</html>", false},
+		{http.StatusNotFound, "<html>Not found</html>", false},
+	}
+
+	for _, tt := range tests {
+		e := &APIError{StatusCode: tt.status, Body: []byte(tt.body)}
+		if got := e.Transient(); got != tt.want {
+			t.Errorf("status %d, body %q: expected transient %t, got %t", tt.status, tt.body, tt.want, got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{"Wed, 01 May 2024 12:01:30 GMT", 90 * time.Second},
+		{"Wed, 01 May 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("%q: expected %s, got %s", tt.value, tt.want, got)
+		}
+	}
+}
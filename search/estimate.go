@@ -0,0 +1,66 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Estimate Expected cost of fetching every page of a query.
+type Estimate struct {
+	// Total number of events matching the query.
+	Total int64
+	// Pages number of event pages a full fetch would download.
+	Pages int64
+	// Requests number of HTTP requests a full fetch would make,
+	// including the one creating the search.
+	Requests int64
+	// Bytes rough size of the downloaded events, extrapolated from
+	// a single sample event.
+	Bytes int64
+}
+
+// Estimate Create the search and read the number of matching events
+// without downloading the event pages.
+func (c *Client) Estimate(ctx context.Context, q Query) (*Estimate, error) {
+	sample := q
+	sample.size = 1
+
+	j, err := c.CreateSearch(ctx, sample.String())
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Search(ctx, j, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventSize int64
+	if len(res.Events) > 0 {
+		data, err := json.Marshal(res.Events[0])
+		if err != nil {
+			return nil, err
+		}
+		eventSize = int64(len(data))
+	}
+
+	return newEstimate(res.Total, eventSize, q), nil
+}
+
+func newEstimate(total int64, eventSize int64, q Query) *Estimate {
+	size := int64(max(q.size, 1))
+	pages := (total + size - 1) / size
+	pages = max(min(pages, q.lastPage()+1), 1)
+
+	events := min(total, pages*size)
+	if q.maxEvents > 0 {
+		events = min(events, q.maxEvents)
+	}
+
+	return &Estimate{
+		Total:    total,
+		Pages:    pages,
+		Requests: pages + 1,
+		Bytes:    events * eventSize,
+	}
+}
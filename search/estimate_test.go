@@ -0,0 +1,37 @@
+package search
+
+import "testing"
+
+func TestNewEstimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int64
+		size     int
+		maxPages int64
+		want     Estimate
+	}{
+		{"empty", 0, 100, 3, Estimate{Total: 0, Pages: 1, Requests: 2, Bytes: 0}},
+		{"partial page", 50, 100, 3, Estimate{Total: 50, Pages: 1, Requests: 2, Bytes: 500}},
+		{"exact pages", 200, 100, 3, Estimate{Total: 200, Pages: 2, Requests: 3, Bytes: 2000}},
+		{"capped by maxPages", 10000, 100, 3, Estimate{Total: 10000, Pages: 4, Requests: 5, Bytes: 4000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuery("*").Size(tt.size).MaxPage(tt.maxPages)
+			got := newEstimate(tt.total, 10, *q)
+			if *got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestNewEstimateMaxEvents(t *testing.T) {
+	q := NewQuery("*").Size(100).MaxPage(10).MaxEvents(150)
+	got := newEstimate(10000, 10, *q)
+	want := Estimate{Total: 10000, Pages: 2, Requests: 3, Bytes: 1500}
+	if *got != want {
+		t.Errorf("expected %+v, got %+v", want, *got)
+	}
+}
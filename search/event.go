@@ -0,0 +1,30 @@
+package search
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventTimestamp Read the timestamp (epoch milliseconds) of an event
+// returned by the events API.
+func EventTimestamp(event any) (time.Time, bool) {
+	m, ok := event.(map[string]any)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	switch ts := m["timestamp"].(type) {
+	case json.Number:
+		ms, err := ts.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(ms), true
+	case float64:
+		return time.UnixMilli(int64(ts)), true
+	case int64:
+		return time.UnixMilli(ts), true
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"context"
+
+	"github.com/Ajnasz/go-loggly-cli/kmerge"
+	"golang.org/x/sync/errgroup"
+)
+
+// Stream Result channels of a Fetch call.
+type Stream struct {
+	Responses chan Response
+	Errors    chan error
+}
+
+// NewStream Wrap the channels returned by Fetch.
+func NewStream(responses chan Response, errors chan error) Stream {
+	return Stream{Responses: responses, Errors: errors}
+}
+
+// MergeByTime Merge the events of several fetches into one stream
+// ordered by the event timestamps. Every stream must be ordered the
+// same way, newest first unless ascending is true, like a Query with the
+// matching order. Each merged event is sent in its own Response.
+// Both returned channels are closed when all streams are drained or
+// any of them fails.
+func MergeByTime(ctx context.Context, ascending bool, streams ...Stream) (chan Response, chan error) {
+	resChan := make(chan Response)
+	errChan := make(chan error)
+
+	less := func(a, b any) bool {
+		ta, _ := EventTimestamp(a)
+		tb, _ := EventTimestamp(b)
+		if ascending {
+			return ta.Before(tb)
+		}
+		return ta.After(tb)
+	}
+
+	go func() {
+		defer close(errChan)
+
+		errg, ctx := errgroup.WithContext(ctx)
+
+		inputs := make([]<-chan any, len(streams))
+		for i, s := range streams {
+			events := make(chan any)
+			inputs[i] = events
+			errg.Go(func() error {
+				return flattenStream(ctx, s, events)
+			})
+		}
+
+		merged := make(chan any)
+		errg.Go(func() error {
+			return kmerge.Merge(ctx, less, merged, inputs...)
+		})
+
+		errg.Go(func() error {
+			defer close(resChan)
+			for event := range merged {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case resChan <- Response{Events: []any{event}}:
+				}
+			}
+			return nil
+		})
+
+		if err := errg.Wait(); err != nil {
+			errChan <- err
+		}
+	}()
+
+	return resChan, errChan
+}
+
+// flattenStream Send the events of the stream one by one.
+func flattenStream(ctx context.Context, s Stream, events chan<- any) error {
+	defer close(events)
+
+	responses, errs := s.Responses, s.Errors
+	for responses != nil || errs != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res, ok := <-responses:
+			if !ok {
+				responses = nil
+				continue
+			}
+			for _, event := range res.Events {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case events <- event:
+				}
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
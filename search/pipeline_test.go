@@ -0,0 +1,251 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+)
+
+var errInjected = errors.New("injected page failure")
+
+// scriptedSource Serves total events in pages of size, holding every
+// page until the test releases it, so the test decides the order in
+// which the in-flight pages complete. The failPage fails when released.
+type scriptedSource struct {
+	total    int
+	size     int
+	failPage int
+
+	arrived chan int
+
+	mu        sync.Mutex
+	release   map[int]chan struct{}
+	requested map[int]bool
+}
+
+func newScriptedSource(total, size, failPage int) *scriptedSource {
+	return &scriptedSource{
+		total:     total,
+		size:      size,
+		failPage:  failPage,
+		arrived:   make(chan int),
+		release:   make(map[int]chan struct{}),
+		requested: make(map[int]bool),
+	}
+}
+
+func (s *scriptedSource) CreateSearch(ctx context.Context, params string) (*simplejson.Json, error) {
+	return simplejson.New(), nil
+}
+
+func (s *scriptedSource) Search(ctx context.Context, j *simplejson.Json, page int) (*Response, error) {
+	release := make(chan struct{})
+	s.mu.Lock()
+	s.release[page] = release
+	s.requested[page] = true
+	s.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case s.arrived <- page:
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-release:
+	}
+
+	if page == s.failPage {
+		return nil, errInjected
+	}
+
+	res := &Response{Total: int64(s.total), Page: int64(page), Events: []any{}}
+	for i := page * s.size; i < min((page+1)*s.size, s.total); i++ {
+		res.Events = append(res.Events, i)
+	}
+	return res, nil
+}
+
+func (s *scriptedSource) SearchRaw(ctx context.Context, j *simplejson.Json, page int) (*Response, error) {
+	res, err := s.Search(ctx, j, page)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Raw = []json.RawMessage{}
+	for _, e := range res.Events {
+		res.Raw = append(res.Raw, json.RawMessage(strconv.Itoa(e.(int))))
+	}
+	res.Events = nil
+	return res, nil
+}
+
+func (s *scriptedSource) releasePage(page int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.release[page])
+}
+
+func (s *scriptedSource) wasRequested(page int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requested[page]
+}
+
+type pipelineResult struct {
+	events []int
+	err    error
+}
+
+// runScripted Fetch through the scripted source, completing the
+// in-flight pages in the order picked by the choices.
+func runScripted(t *testing.T, src *scriptedSource, q Query, concurrency int, choices []byte) pipelineResult {
+	t.Helper()
+
+	c := New("test", "test").SetConcurrency(concurrency)
+	c.source = src
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resChan, errChan := c.Fetch(ctx, q)
+
+	done := make(chan pipelineResult)
+	go func() {
+		var result pipelineResult
+		for resChan != nil || errChan != nil {
+			select {
+			case res, ok := <-resChan:
+				if !ok {
+					resChan = nil
+					continue
+				}
+				for _, e := range res.Events {
+					result.events = append(result.events, e.(int))
+				}
+				for _, e := range res.Raw {
+					n, _ := strconv.Atoi(string(e))
+					result.events = append(result.events, n)
+				}
+			case err, ok := <-errChan:
+				if !ok {
+					errChan = nil
+					continue
+				}
+				result.err = err
+			}
+		}
+		done <- result
+	}()
+
+	deadline := time.After(5 * time.Second)
+	var pending []int
+	for step := 0; ; step++ {
+		select {
+		case page := <-src.arrived:
+			pending = append(pending, page)
+			continue
+		case result := <-done:
+			return result
+		case <-deadline:
+			t.Fatalf("fetch pipeline hung, pages waiting: %v", pending)
+		case <-time.After(time.Millisecond):
+		}
+
+		if len(pending) == 0 {
+			continue
+		}
+
+		i := 0
+		if len(choices) > 0 {
+			i = int(choices[step%len(choices)]) % len(pending)
+		}
+		src.releasePage(pending[i])
+		pending = append(pending[:i], pending[i+1:]...)
+	}
+}
+
+func checkPipeline(t *testing.T, total, size, maxPages, concurrency, failPage int, choices []byte, raw bool) {
+	src := newScriptedSource(total, size, failPage)
+	q := NewQuery("*").Size(size).MaxPage(int64(maxPages)).Raw(raw)
+
+	result := runScripted(t, src, *q, concurrency, choices)
+
+	// the events must arrive in order, without gaps or duplicates
+	for i, e := range result.events {
+		if e != i {
+			t.Fatalf("event %d: expected %d, got %d (events %v)", i, i, e, result.events)
+		}
+	}
+
+	if failPage >= 0 && src.wasRequested(failPage) {
+		if !errors.Is(result.err, errInjected) {
+			t.Fatalf("expected the injected failure, got %v", result.err)
+		}
+		return
+	}
+
+	if result.err != nil {
+		t.Fatalf("unexpected error: %v", result.err)
+	}
+
+	want := min(total, (maxPages+1)*size)
+	if len(result.events) != want {
+		t.Fatalf("expected %d events, got %d", want, len(result.events))
+	}
+}
+
+func TestFetchPipelineOrderings(t *testing.T) {
+	tests := []struct {
+		name                                        string
+		total, size, maxPages, concurrency, failure int
+		choices                                     []byte
+	}{
+		{"in order", 50, 10, 5, 3, -1, []byte{0}},
+		{"reversed", 50, 10, 5, 3, -1, []byte{2, 1, 0}},
+		{"partial last page", 45, 10, 5, 4, -1, []byte{3, 0, 1}},
+		{"more pages than events", 5, 10, 5, 8, -1, []byte{7, 3}},
+		{"limited by max pages", 100, 10, 2, 2, -1, []byte{1}},
+		{"first page fails", 50, 10, 5, 3, 0, []byte{1, 0}},
+		{"middle page fails", 50, 10, 5, 3, 2, []byte{2, 1, 0}},
+		{"failure while later pages wait", 80, 10, 7, 8, 1, []byte{5, 4, 3, 2, 1, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkPipeline(t, test.total, test.size, test.maxPages, test.concurrency, test.failure, test.choices, false)
+		})
+		t.Run(test.name+" raw", func(t *testing.T) {
+			checkPipeline(t, test.total, test.size, test.maxPages, test.concurrency, test.failure, test.choices, true)
+		})
+	}
+}
+
+// FuzzFetchPipeline Complete the pages in arbitrary orders, with an
+// optional failing page, and check the pipeline neither hangs nor loses
+// or reorders pages.
+func FuzzFetchPipeline(f *testing.F) {
+	f.Add(uint8(50), uint8(10), uint8(5), uint8(3), int8(-1), []byte{2, 1, 0})
+	f.Add(uint8(45), uint8(10), uint8(5), uint8(8), int8(3), []byte{7, 0, 3})
+	f.Add(uint8(3), uint8(1), uint8(9), uint8(2), int8(-1), []byte{1})
+
+	f.Fuzz(func(t *testing.T, total, size, maxPages, concurrency uint8, failPage int8, choices []byte) {
+		checkPipeline(t,
+			int(total),
+			int(size%10)+1,
+			int(maxPages%8)+1,
+			int(concurrency%8)+1,
+			int(failPage%10),
+			choices,
+			false,
+		)
+	})
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"context"
+	"net/http"
+)
+
+// RSIDPlaceholder Stands for the search id in the planned /events
+// requests, as the real id is only known after the search is created.
+const RSIDPlaceholder = "RSID"
+
+// PlanRequests Build, without sending them, the requests a Fetch of the
+// query would make: the /search request followed by the /events
+// request of every page. Fewer pages are requested when the results
+// run out.
+func (c *Client) PlanRequests(ctx context.Context, q Query) ([]*http.Request, error) {
+	var requests []*http.Request
+
+	r, err := c.NewRequest(ctx, "/search?"+q.String())
+	if err != nil {
+		return nil, err
+	}
+	requests = append(requests, r)
+
+	for page := int64(0); page <= q.lastPage(); page++ {
+		r, err := c.NewRequest(ctx, "/events?"+eventsParams(RSIDPlaceholder, int(page)))
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+
+	return requests, nil
+}
@@ -7,12 +7,14 @@ import (
 
 // Query builder struct
 type Query struct {
-	query    string
-	from     string
-	until    string
-	order    string
-	size     int
-	maxPages int64
+	query     string
+	from      string
+	until     string
+	order     string
+	size      int
+	maxPages  int64
+	maxEvents int64
+	raw       bool
 }
 
 // Create a new query
@@ -55,6 +57,45 @@ func (q *Query) MaxPage(maxPages int64) *Query {
 	return q
 }
 
+// MaxEvents Limit the number of events returned across all pages.
+// Zero means no limit.
+func (q *Query) MaxEvents(n int64) *Query {
+	q.maxEvents = n
+	return q
+}
+
+// Raw Keep the events undecoded in Response.Raw, for callers passing
+// them through as they are.
+func (q *Query) Raw(raw bool) *Query {
+	q.raw = raw
+	return q
+}
+
+// lastPage Index of the last page to fetch, honoring both maxPages
+// and maxEvents.
+func (q *Query) lastPage() int64 {
+	last := q.maxPages
+	if q.maxEvents > 0 && q.size > 0 {
+		last = min(last, (q.maxEvents-1)/int64(q.size))
+	}
+	return last
+}
+
+// pageEventLimit Number of events of the given page that fit into
+// maxEvents, or -1 if the page is not limited.
+func (q *Query) pageEventLimit(page int) int {
+	if q.maxEvents <= 0 {
+		return -1
+	}
+	return int(max(q.maxEvents-int64(page)*int64(q.size), 0))
+}
+
+// Order Set the order of the events, "desc" for newest first or "asc".
+func (q *Query) Order(order string) *Query {
+	q.order = order
+	return q
+}
+
 // Until Set until time.
 func (q *Query) Until(str string) *Query {
 	q.until = str
@@ -0,0 +1,45 @@
+package search
+
+import "testing"
+
+func TestQueryLastPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		maxPages  int64
+		maxEvents int64
+		want      int64
+	}{
+		{"no event limit", 100, 3, 0, 3},
+		{"limit within first page", 100, 3, 50, 0},
+		{"limit on page boundary", 100, 3, 200, 1},
+		{"limit over maxPages", 100, 3, 10000, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuery("*").Size(tt.size).MaxPage(tt.maxPages).MaxEvents(tt.maxEvents)
+			if got := q.lastPage(); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueryPageEventLimit(t *testing.T) {
+	q := NewQuery("*").Size(100).MaxEvents(150)
+
+	if got := q.pageEventLimit(0); got != 150 {
+		t.Errorf("expected 150 for page 0, got %d", got)
+	}
+	if got := q.pageEventLimit(1); got != 50 {
+		t.Errorf("expected 50 for page 1, got %d", got)
+	}
+	if got := q.pageEventLimit(2); got != 0 {
+		t.Errorf("expected 0 for page 2, got %d", got)
+	}
+
+	if got := NewQuery("*").pageEventLimit(5); got != -1 {
+		t.Errorf("expected -1 without limit, got %d", got)
+	}
+}
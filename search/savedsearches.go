@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SavedSearch A saved search of the Loggly web UI.
+type SavedSearch struct {
+	ID    int64
+	Name  string
+	Query string
+	From  string
+	Until string
+}
+
+// savedSearchPayload The shape of a saved search in the API response,
+// the query and the time range are in its context.
+type savedSearchPayload struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Context struct {
+		Terms string `json:"terms"`
+		From  string `json:"from"`
+		Until string `json:"until"`
+	} `json:"context"`
+}
+
+// SavedSearches List the saved searches of the account.
+func (c *Client) SavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	body, err := c.GetBody(ctx, "/savedsearches")
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []savedSearchPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("go-loggly-search: invalid saved searches: %w", err)
+	}
+
+	searches := make([]SavedSearch, len(payload))
+	for i, p := range payload {
+		searches[i] = SavedSearch{
+			ID:    p.ID,
+			Name:  p.Name,
+			Query: p.Context.Terms,
+			From:  p.Context.From,
+			Until: p.Context.Until,
+		}
+	}
+
+	return searches, nil
+}
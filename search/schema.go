@@ -0,0 +1,217 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bitly/go-simplejson"
+)
+
+// Fields of the documented API responses. Anything else is reported as
+// schema drift, so a changed API shows up as a warning instead of
+// silently empty results.
+var (
+	searchResponseKeys = []string{"rsid"}
+	rsidKeys           = []string{"id", "status", "date_from", "date_to", "elapsed_time"}
+	eventsResponseKeys = []string{"total_events", "page", "events"}
+)
+
+// Renamed or alternative fields accepted when the documented one is
+// missing.
+var (
+	totalAliases  = []string{"total", "totalEvents", "total_count"}
+	eventsAliases = []string{"results", "items", "data"}
+)
+
+// SetWarningHandler Call handler with the warnings of the client, like
+// unexpected fields in the API responses. Each distinct warning is
+// reported once. The handler may be called concurrently.
+func (c *Client) SetWarningHandler(handler func(string)) *Client {
+	c.warningMu.Lock()
+	defer c.warningMu.Unlock()
+	c.warningHandler = handler
+	return c
+}
+
+func (c *Client) warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+
+	c.warningMu.Lock()
+	if c.warningHandler == nil || c.warned[msg] {
+		c.warningMu.Unlock()
+		return
+	}
+	if c.warned == nil {
+		c.warned = make(map[string]bool)
+	}
+	c.warned[msg] = true
+	handler := c.warningHandler
+	c.warningMu.Unlock()
+
+	handler(msg)
+}
+
+func (c *Client) warnUnknownKeys(where string, j *simplejson.Json, known []string) {
+	m, err := j.Map()
+	if err != nil {
+		return
+	}
+
+	var unknown []string
+	for k := range m {
+		if !slices.Contains(known, k) {
+			unknown = append(unknown, k)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		c.warnf("API schema drift: unexpected fields in %s: %s", where, strings.Join(unknown, ", "))
+	}
+}
+
+// searchID Read the id of the created search. Besides the documented
+// {"rsid": {"id": "..."}} shape it accepts an rsid string or number and
+// a top level id.
+func (c *Client) searchID(j *simplejson.Json) (string, error) {
+	c.warnUnknownKeys("the /search response", j, append(searchResponseKeys, "id"))
+
+	rsid, hasRSID := j.CheckGet("rsid")
+	if hasRSID {
+		if _, err := rsid.Map(); err == nil {
+			c.warnUnknownKeys("the /search rsid", rsid, rsidKeys)
+			if id, ok := scalarString(rsid.Get("id")); ok {
+				return id, nil
+			}
+		} else if id, ok := scalarString(rsid); ok {
+			c.warnf("API schema drift: rsid of the /search response is a plain value instead of an object")
+			return id, nil
+		}
+	}
+
+	if id, ok := scalarString(j.Get("id")); ok {
+		c.warnf("API schema drift: search id found at the top level of the /search response instead of rsid.id")
+		return id, nil
+	}
+
+	return "", fmt.Errorf("go-loggly-search: API schema drift: no search id in the /search response")
+}
+
+// parseRawEvents Build the Response of an /events call without
+// decoding the events, tolerating the same renamed fields as
+// parseEvents.
+func (c *Client) parseRawEvents(body []byte) (*Response, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	var events json.RawMessage
+	eventsKey := ""
+	for _, key := range append([]string{"events"}, eventsAliases...) {
+		if v, ok := fields[key]; ok {
+			events, eventsKey = v, key
+			delete(fields, key)
+			break
+		}
+	}
+	if eventsKey == "" {
+		return nil, fmt.Errorf("go-loggly-search: API schema drift: no events array in the /events response")
+	}
+	if eventsKey != "events" {
+		c.warnf("API schema drift: events of the API response is called %s", eventsKey)
+	}
+
+	// The rest is small, decode it like parseEvents does.
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	j, err := simplejson.NewJson(rest)
+	if err != nil {
+		return nil, err
+	}
+	c.warnUnknownKeys("the /events response", j, slices.Concat(eventsResponseKeys, totalAliases, eventsAliases))
+
+	total, ok := c.lookupAlias(j, "total_events", totalAliases)
+	if !ok {
+		c.warnf("API schema drift: no total_events in the /events response, totals will be 0")
+	}
+
+	var eventList []json.RawMessage
+	if err := json.Unmarshal(events, &eventList); err != nil {
+		return nil, fmt.Errorf("go-loggly-search: API schema drift: %s of the /events response is not an array", eventsKey)
+	}
+	if eventList == nil {
+		eventList = []json.RawMessage{}
+	}
+
+	return &Response{
+		Total: total.MustInt64(),
+		Page:  j.Get("page").MustInt64(),
+		Raw:   eventList,
+	}, nil
+}
+
+// parseEvents Build the Response of an /events call, tolerating
+// renamed total and events fields.
+func (c *Client) parseEvents(j *simplejson.Json) (*Response, error) {
+	c.warnUnknownKeys("the /events response", j, slices.Concat(eventsResponseKeys, totalAliases, eventsAliases))
+
+	total, ok := c.lookupAlias(j, "total_events", totalAliases)
+	if !ok {
+		c.warnf("API schema drift: no total_events in the /events response, totals will be 0")
+	}
+
+	events, ok := c.lookupAlias(j, "events", eventsAliases)
+	if !ok {
+		return nil, fmt.Errorf("go-loggly-search: API schema drift: no events array in the /events response")
+	}
+
+	eventList, err := events.Array()
+	if err != nil {
+		return nil, fmt.Errorf("go-loggly-search: API schema drift: events of the /events response is not an array")
+	}
+
+	for _, e := range eventList {
+		if _, ok := e.(map[string]any); !ok {
+			c.warnf("API schema drift: event of the /events response is not an object")
+			break
+		}
+	}
+
+	return &Response{
+		Total:  total.MustInt64(),
+		Page:   j.Get("page").MustInt64(),
+		Events: eventList,
+	}, nil
+}
+
+func (c *Client) lookupAlias(j *simplejson.Json, key string, aliases []string) (*simplejson.Json, bool) {
+	if v, ok := j.CheckGet(key); ok {
+		return v, true
+	}
+
+	for _, alias := range aliases {
+		if v, ok := j.CheckGet(alias); ok {
+			c.warnf("API schema drift: %s of the API response is called %s", key, alias)
+			return v, true
+		}
+	}
+
+	return j.Get(key), false
+}
+
+func scalarString(j *simplejson.Json) (string, bool) {
+	switch v := j.Interface().(type) {
+	case string:
+		return v, v != ""
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,160 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bitly/go-simplejson"
+)
+
+func mustJSON(t *testing.T, s string) *simplejson.Json {
+	t.Helper()
+	j, err := simplejson.NewJson([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func TestSearchIDShapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		drifted bool
+	}{
+		{"documented", `{"rsid": {"id": "123", "status": "SCHEDULED"}}`, "123", false},
+		{"plain rsid", `{"rsid": "123"}`, "123", true},
+		{"numeric rsid", `{"rsid": {"id": 123}}`, "123", false},
+		{"top level id", `{"id": "123"}`, "123", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			c := New("a", "t").SetWarningHandler(func(w string) { warnings = append(warnings, w) })
+
+			got, err := c.searchID(mustJSON(t, tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if drifted := len(warnings) > 0; drifted != tt.drifted {
+				t.Errorf("expected drift %v, got warnings %v", tt.drifted, warnings)
+			}
+		})
+	}
+}
+
+func TestSearchIDMissing(t *testing.T) {
+	c := New("a", "t")
+	if _, err := c.searchID(mustJSON(t, `{"search": {}}`)); err == nil {
+		t.Error("expected error for missing search id")
+	}
+}
+
+func TestParseEventsRenamedFields(t *testing.T) {
+	var warnings []string
+	c := New("a", "t").SetWarningHandler(func(w string) { warnings = append(warnings, w) })
+
+	res, err := c.parseEvents(mustJSON(t, `{"total": 2, "page": 0, "results": [{"logmsg": "a"}, {"logmsg": "b"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Total != 2 || len(res.Events) != 2 {
+		t.Errorf("unexpected response %+v", res)
+	}
+
+	if !strings.Contains(strings.Join(warnings, "\n"), "schema drift") {
+		t.Errorf("expected schema drift warnings, got %v", warnings)
+	}
+}
+
+func TestParseRawEventsRenamedFields(t *testing.T) {
+	var warnings []string
+	c := New("a", "t").SetWarningHandler(func(w string) { warnings = append(warnings, w) })
+
+	res, err := c.parseRawEvents([]byte(`{"total": 2, "page": 1, "results": [{"logmsg": "a"}, {"logmsg": "b"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Total != 2 || res.Page != 1 || len(res.Raw) != 2 || string(res.Raw[1]) != `{"logmsg": "b"}` {
+		t.Errorf("unexpected response %+v", res)
+	}
+
+	if !strings.Contains(strings.Join(warnings, "\n"), "called results") {
+		t.Errorf("expected schema drift warnings, got %v", warnings)
+	}
+}
+
+func TestParseRawEventsMissing(t *testing.T) {
+	c := New("a", "t")
+
+	if _, err := c.parseRawEvents([]byte(`{"total_events": 2, "page": 0}`)); err == nil {
+		t.Error("expected an error without events")
+	}
+	if _, err := c.parseRawEvents([]byte(`{"total_events": 2, "page": 0, "events": {}}`)); err == nil {
+		t.Error("expected an error when events is not an array")
+	}
+}
+
+// eventsPage An /events response body with n typical events.
+func eventsPage(n int) []byte {
+	var events []string
+	for i := range n {
+		events = append(events, fmt.Sprintf(`{"id":"ev-%06d","timestamp":%d,"logmsg":"{\"level\":\"info\",\"message\":\"request completed\",\"duration\":%d}","tags":["web","prod"],"logtypes":["json"],"event":{"json":{"level":"info","message":"request completed","duration":%d,"http":{"method":"GET","path":"/v1/orders","status":200},"user":{"id":"u%03d"}}}}`,
+			i, 1700000000000+int64(i), i, i, i%100))
+	}
+
+	return []byte(fmt.Sprintf(`{"total_events":%d,"page":0,"events":[%s]}`, n, strings.Join(events, ",")))
+}
+
+// BenchmarkPassthrough Compare printing whole events by decoding and
+// re-encoding them with copying the raw bytes.
+func BenchmarkPassthrough(b *testing.B) {
+	body := eventsPage(1000)
+	c := New("a", "t")
+
+	b.Run("decoded", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			j, err := simplejson.NewJson(body)
+			if err != nil {
+				b.Fatal(err)
+			}
+			res, err := c.parseEvents(j)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, e := range res.Events {
+				if _, err := json.Marshal(e); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("raw", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf bytes.Buffer
+		for b.Loop() {
+			res, err := c.parseRawEvents(body)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, e := range res.Raw {
+				buf.Reset()
+				if err := json.Compact(&buf, e); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
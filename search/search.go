@@ -2,12 +2,20 @@ package search
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/Ajnasz/go-loggly-cli/orderedbuffer"
 	"github.com/Ajnasz/go-loggly-cli/semaphore"
@@ -25,6 +33,43 @@ type Client struct {
 	endpoint string
 	// Number of concurrent requests when fetching multiple pages.
 	concurrency atomic.Int64
+	// Timeout of a single HTTP request, zero means no timeout.
+	pageTimeout time.Duration
+	// Transport used by every request.
+	transport *http.Transport
+	// Called with every request right before it is sent.
+	requestHook func(*http.Request)
+	// Waited for before every request.
+	limiter Limiter
+	// Adds the credentials to the requests, the Token as a bearer
+	// token when nil.
+	auth Authenticator
+	// Told about the responses and the pages.
+	observer Observer
+
+	logger *slog.Logger
+
+	warningMu      sync.Mutex
+	warningHandler func(string)
+	warned         map[string]bool
+
+	// Replaces the API in the tests of the fetch pipeline.
+	source pageSource
+}
+
+// pageSource Creates a search and fetches its pages.
+type pageSource interface {
+	CreateSearch(ctx context.Context, params string) (*simplejson.Json, error)
+	Search(ctx context.Context, j *simplejson.Json, page int) (*Response, error)
+	SearchRaw(ctx context.Context, j *simplejson.Json, page int) (*Response, error)
+}
+
+// pages The source of the fetched pages, the Loggly API by default.
+func (c *Client) pages() pageSource {
+	if c.source != nil {
+		return c.source
+	}
+	return c
 }
 
 // Response Search response with total events, page number
@@ -33,14 +78,25 @@ type Response struct {
 	Total  int64
 	Page   int64
 	Events []any
+	// Raw The undecoded events of a raw query, Events is empty then.
+	Raw []json.RawMessage
+}
+
+// Len Number of events in the response.
+func (r Response) Len() int {
+	if r.Raw != nil {
+		return len(r.Raw)
+	}
+	return len(r.Events)
 }
 
 // New Create a new loggly search client with credentials.
 func New(account string, token string) *Client {
 	c := &Client{
-		Account:  account,
-		Token:    token,
-		endpoint: "loggly.com/apiv2",
+		Account:   account,
+		Token:     token,
+		endpoint:  "loggly.com/apiv2",
+		transport: http.DefaultTransport.(*http.Transport).Clone(),
 	}
 
 	return c
@@ -54,26 +110,209 @@ func (c *Client) SetConcurrency(n int) *Client {
 	return c
 }
 
+// SetPageTimeout Set the timeout of a single HTTP request including
+// reading its body. Zero disables the timeout.
+func (c *Client) SetPageTimeout(d time.Duration) *Client {
+	c.pageTimeout = d
+	return c
+}
+
+// SetProxy Send the requests through the given proxy. By default the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+func (c *Client) SetProxy(proxy *url.URL) *Client {
+	if proxy == nil {
+		c.transport.Proxy = http.ProxyFromEnvironment
+	} else {
+		c.transport.Proxy = http.ProxyURL(proxy)
+	}
+	return c
+}
+
+// SetEndpoint Override the loggly.com/apiv2 API base. A host and path
+// is prefixed with the account subdomain, while a full URL with a scheme,
+// like http://127.0.0.1:8080/apiv2, is used as it is.
+func (c *Client) SetEndpoint(endpoint string) *Client {
+	c.endpoint = endpoint
+	return c
+}
+
+// SetTLSConfig Use custom TLS settings, for example to trust the CA of
+// a TLS intercepting proxy.
+func (c *Client) SetTLSConfig(config *tls.Config) *Client {
+	c.transport.TLSClientConfig = config
+	return c
+}
+
+// SetDialContext Open the connections with dial instead of through a
+// proxy, for example to reach the API through a unix socket.
+func (c *Client) SetDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) *Client {
+	c.transport.DialContext = dial
+	c.transport.Proxy = nil
+	return c
+}
+
+// Transport The transport of the requests, with the proxy and TLS
+// settings of the client.
+func (c *Client) Transport() http.RoundTripper {
+	return c.transport
+}
+
+// SetRequestHook Call hook with every request before it is sent, for
+// example to log it. The hook may be called concurrently.
+func (c *Client) SetRequestHook(hook func(*http.Request)) *Client {
+	c.requestHook = hook
+	return c
+}
+
+// Limiter Delays the requests, for example to share a rate limit.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// SetLimiter Wait for the limiter before sending a request.
+func (c *Client) SetLimiter(l Limiter) *Client {
+	c.limiter = l
+	return c
+}
+
+// Observer Is told about the responses and the fetched pages, for
+// example to print statistics. It may be called concurrently.
+type Observer interface {
+	// Response The body of a response of bytes size was read, elapsed
+	// after sending the request.
+	Response(bytes int64, elapsed time.Duration)
+	// Page A page of the search was fetched in elapsed.
+	Page(res Response, elapsed time.Duration)
+}
+
+// SetObserver Tell the observer about the responses and the pages.
+func (c *Client) SetObserver(o Observer) *Client {
+	c.observer = o
+	return c
+}
+
+// SetAuthenticator Authenticate the requests with auth instead of the
+// Token, for example to reach Loggly through a gateway of its own.
+func (c *Client) SetAuthenticator(auth Authenticator) *Client {
+	c.auth = auth
+	return c
+}
+
+// SetLogger Log the requests, their timing and the paging decisions
+// to the logger. Requests are logged at info level, the details at debug
+// level. By default nothing is logged.
+func (c *Client) SetLogger(logger *slog.Logger) *Client {
+	c.logger = logger
+	return c
+}
+
+func (c *Client) log() *slog.Logger {
+	if c.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return c.logger
+}
+
 // URL Return the base api url.
 func (c *Client) URL() string {
+	if strings.Contains(c.endpoint, "://") {
+		return strings.TrimSuffix(c.endpoint, "/")
+	}
 	return fmt.Sprintf("https://%s.%s", c.Account, c.endpoint)
 }
 
 // Get the given path.
 func (c *Client) Get(ctx context.Context, path string) (*http.Response, error) {
+	r, err := c.NewRequest(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.requestHook != nil {
+		c.requestHook(r)
+	}
+
+	log := c.log()
+	log.Debug("sending request", "method", r.Method, "url", r.URL.String())
+
+	start := time.Now()
+	client := &http.Client{Transport: c.transport}
+	res, err := client.Do(r)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		log.Info("request failed", "url", r.URL.String(), "duration", elapsed, "error", err)
+		return nil, err
+	}
+
+	attrs := []any{"url", r.URL.String(), "status", res.StatusCode, "duration", elapsed}
+	for _, name := range rateLimitHeaders {
+		if v := res.Header.Get(name); v != "" {
+			attrs = append(attrs, name, v)
+		}
+	}
+	log.Info("request done", attrs...)
+
+	return res, nil
+}
+
+// rateLimitHeaders Response headers logged to help tuning concurrency.
+var rateLimitHeaders = []string{
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	"Retry-After",
+}
+
+// NewRequest Build the authenticated GET request of the given path.
+func (c *Client) NewRequest(ctx context.Context, path string) (*http.Request, error) {
 	r, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL()+path, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	r.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	var auth Authenticator = BearerToken(c.Token)
+	if c.auth != nil {
+		auth = c.auth
+	}
+	if err := auth.Authenticate(r); err != nil {
+		return nil, fmt.Errorf("go-loggly-search: authenticating the request: %w", err)
+	}
+
 	r.Header.Set("User-Agent", "go-loggly-cli/1 author/Ajnasz")
-	client := &http.Client{}
-	return client.Do(r)
+	return r, nil
 }
 
 // GetJSON from the given path.
-func (c *Client) GetJSON(ctx context.Context, path string) (j *simplejson.Json, err error) {
+func (c *Client) GetJSON(ctx context.Context, path string) (*simplejson.Json, error) {
+	body, err := c.GetBody(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return simplejson.NewJson(body)
+}
+
+// GetBody Read the response body of the given path.
+func (c *Client) GetBody(ctx context.Context, path string) (body []byte, err error) {
+	if c.pageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.pageTimeout)
+		defer cancel()
+		defer func() {
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("go-loggly-search: request timed out after %s: %w", c.pageTimeout, err)
+			}
+		}()
+	}
+
+	start := time.Now()
 	res, err := c.Get(ctx, path)
 
 	if err != nil {
@@ -82,21 +321,20 @@ func (c *Client) GetJSON(ctx context.Context, path string) (j *simplejson.Json,
 
 	defer res.Body.Close()
 
-	if res.StatusCode >= 400 {
-		body, err := io.ReadAll(res.Body)
+	body, err = io.ReadAll(res.Body)
+	if c.observer != nil {
+		c.observer.Response(int64(len(body)), time.Since(start))
+	}
+
+	// a maintenance page may be served with 200 OK
+	if res.StatusCode >= 400 || (err == nil && isHTML(res.Header.Get("Content-Type"), body)) {
 		if err != nil {
 			body = []byte(err.Error())
 		}
-		return nil, fmt.Errorf("go-loggly-search: %q, %s", res.Status, body)
+		return nil, newAPIError(res, body)
 	}
 
-	body, err := io.ReadAll(res.Body)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return simplejson.NewJson(body)
+	return body, err
 }
 
 // CreateSearch Create a new search instance, loggly requires that a search
@@ -111,16 +349,22 @@ func (c *Client) GetEvents(ctx context.Context, params string) (*simplejson.Json
 	return c.GetJSON(ctx, "/events?"+params)
 }
 
+func eventsParams(rsid string, page int) string {
+	qs := url.Values{}
+	qs.Set("rsid", rsid)
+	qs.Set("page", strconv.Itoa(page))
+	return qs.Encode()
+}
+
 // Search response with total events, page number
 // and the events array.
 func (c *Client) Search(ctx context.Context, j *simplejson.Json, page int) (*Response, error) {
-	id := j.GetPath("rsid", "id").MustString()
-
-	qs := url.Values{}
-	qs.Set("rsid", id)
-	qs.Set("page", strconv.Itoa(page))
+	id, err := c.searchID(j)
+	if err != nil {
+		return nil, err
+	}
 
-	j, err := c.GetEvents(ctx, qs.Encode())
+	j, err = c.GetEvents(ctx, eventsParams(id, page))
 
 	if err != nil {
 		return nil, err
@@ -128,21 +372,64 @@ func (c *Client) Search(ctx context.Context, j *simplejson.Json, page int) (*Res
 
 	// Search response with total events, page number
 	// and the events array.
-	return &Response{
-		Total:  j.Get("total_events").MustInt64(),
-		Page:   j.Get("page").MustInt64(),
-		Events: j.Get("events").MustArray(),
-	}, nil
+	return c.parseEvents(j)
+}
+
+// SearchRaw Like Search, but keeps the events undecoded in
+// Response.Raw.
+func (c *Client) SearchRaw(ctx context.Context, j *simplejson.Json, page int) (*Response, error) {
+	id, err := c.searchID(j)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.GetBody(ctx, "/events?"+eventsParams(id, page))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.parseRawEvents(body)
+}
 
+// fetchPage Fetch a page of the search, without the events beyond
+// maxEvents.
+func (c *Client) fetchPage(ctx context.Context, j *simplejson.Json, q Query, page int) (*Response, error) {
+	fetch := c.pages().Search
+	if q.raw {
+		fetch = c.pages().SearchRaw
+	}
+
+	start := time.Now()
+	res, err := fetch(ctx, j, page)
+	if err != nil {
+		return nil, err
+	}
+
+	if res != nil {
+		if limit := q.pageEventLimit(page); limit >= 0 && limit < res.Len() {
+			if res.Raw != nil {
+				res.Raw = res.Raw[:limit]
+			} else {
+				res.Events = res.Events[:limit]
+			}
+		}
+	}
+
+	if res != nil && c.observer != nil {
+		c.observer.Page(*res, time.Since(start))
+	}
+
+	return res, nil
 }
 
 func (c *Client) fetchAndStorePage(
 	ctx context.Context,
 	j *simplejson.Json,
 	responsesStore *orderedbuffer.OrderedBuffer[Response],
+	q Query,
 	page int,
 ) (*Response, error) {
-	res, err := c.Search(ctx, j, page)
+	res, err := c.fetchPage(ctx, j, q, page)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +446,7 @@ func shouldStopFetching(err error, res *Response, pageSize int) bool {
 		return true
 	}
 
-	if res == nil || len(res.Events) < pageSize {
+	if res == nil || res.Len() < pageSize {
 		return true
 	}
 
@@ -168,13 +455,14 @@ func shouldStopFetching(err error, res *Response, pageSize int) bool {
 
 func (c *Client) fetchAllPages(ctx context.Context, q Query, resChan chan Response) error {
 	defer close(resChan)
-	j, err := c.CreateSearch(ctx, q.String())
+	j, err := c.pages().CreateSearch(ctx, q.String())
 
 	if err != nil {
 		return err
 	}
 
-	concurrent := min(q.maxPages, c.concurrency.Load())
+	lastPage := q.lastPage()
+	concurrent := max(min(lastPage, c.concurrency.Load()), 1)
 	sem := semaphore.New(concurrent)
 
 	var page atomic.Int64
@@ -187,22 +475,30 @@ func (c *Client) fetchAllPages(ctx context.Context, q Query, resChan chan Respon
 	errg, ctx := errgroup.WithContext(ctx)
 
 	for {
+		// A failed page cancels the context. Wait for the running pages
+		// anyway, they must not send on the closed channel, and report
+		// the error of the failed page instead of the cancellation.
 		if err := sem.Acquire(ctx); err != nil {
+			if werr := errg.Wait(); werr != nil {
+				return werr
+			}
 			return err
 		}
 		p := int(page.Add(1))
+		c.log().Debug("fetching page", "page", p)
 		errg.Go(func() error {
 			defer sem.Release()
 
-			res, err := c.fetchAndStorePage(ctx, j, responsesStore, p)
+			res, err := c.fetchAndStorePage(ctx, j, responsesStore, q, p)
 
 			if shouldStopFetching(err, res, q.size) {
+				c.log().Debug("last page reached", "page", p)
 				hasMore.Store(false)
 			}
 			return err
 		})
 
-		shouldBreak := page.Load() >= q.maxPages || !hasMore.Load()
+		shouldBreak := page.Load() >= lastPage || !hasMore.Load()
 
 		if shouldBreak {
 			break
@@ -212,6 +508,68 @@ func (c *Client) fetchAllPages(ctx context.Context, q Query, resChan chan Respon
 	return errg.Wait()
 }
 
+// FetchPage Fetch a single page of the query, the same page Fetch
+// returns with that number, for example to check a page again.
+func (c *Client) FetchPage(ctx context.Context, q Query, page int) (Response, error) {
+	j, err := c.pages().CreateSearch(ctx, q.String())
+	if err != nil {
+		return Response{}, err
+	}
+
+	res, err := c.fetchPage(ctx, j, q, page)
+	if err != nil || res == nil {
+		return Response{}, err
+	}
+
+	return *res, nil
+}
+
+// Pages A cursor over the pages of a query. Unlike Fetch, which fetches
+// ahead, a page is fetched only when asked for, so the pages nobody
+// looks at are never downloaded.
+type Pages struct {
+	c    *Client
+	q    Query
+	j    *simplejson.Json
+	page int
+	done bool
+}
+
+// Pages Create a cursor over the pages of the query, the search is
+// created with the first page.
+func (c *Client) Pages(q Query) *Pages {
+	return &Pages{c: c, q: q}
+}
+
+// Next Fetch the next page, false after the last one.
+func (p *Pages) Next(ctx context.Context) (Response, bool, error) {
+	if p.done {
+		return Response{}, false, nil
+	}
+
+	if p.j == nil {
+		j, err := p.c.pages().CreateSearch(ctx, p.q.String())
+		if err != nil {
+			p.done = true
+			return Response{}, false, err
+		}
+		p.j = j
+	}
+
+	p.c.log().Debug("fetching page", "page", p.page)
+	res, err := p.c.fetchPage(ctx, p.j, p.q, p.page)
+	if shouldStopFetching(err, res, p.q.size) || int64(p.page) >= p.q.lastPage() {
+		p.done = true
+	}
+	p.page++
+
+	if err != nil || res == nil {
+		return Response{}, false, err
+	}
+
+	return *res, true, nil
+}
+
 // Fetch Search response with total events, page number
 // and the events array.
 // Fetch will fetch all pages up to maxPages in the Query
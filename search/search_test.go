@@ -0,0 +1,307 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/mockserver"
+)
+
+func startMockServer(t *testing.T) *mockserver.Server {
+	t.Helper()
+
+	srv, err := mockserver.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	return srv
+}
+
+func collect(t testing.TB, resChan chan Response, errChan chan error) []Response {
+	t.Helper()
+
+	var responses []Response
+	for {
+		select {
+		case r, ok := <-resChan:
+			if !ok {
+				resChan = nil
+				continue
+			}
+			responses = append(responses, r)
+		case err, ok := <-errChan:
+			if !ok {
+				return responses
+			}
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFetchAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+
+	c := New("demo", "demo").SetEndpoint(srv.URL).SetConcurrency(3)
+	q := NewQuery("*").Size(10).MaxPage(4)
+
+	resChan, errChan := c.Fetch(context.Background(), *q)
+	responses := collect(t, resChan, errChan)
+
+	if len(responses) != 5 {
+		t.Fatalf("expected 5 pages, got %d", len(responses))
+	}
+
+	for i, r := range responses {
+		if r.Page != int64(i) {
+			t.Errorf("expected page %d at index %d, got %d", i, i, r.Page)
+		}
+		if len(r.Events) != 10 {
+			t.Errorf("expected 10 events on page %d, got %d", i, len(r.Events))
+		}
+	}
+}
+
+func TestFetchMaxEventsAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+
+	c := New("demo", "demo").SetEndpoint(srv.URL).SetConcurrency(3)
+	q := NewQuery("*").Size(10).MaxPage(10).MaxEvents(25)
+
+	resChan, errChan := c.Fetch(context.Background(), *q)
+
+	var events int
+	for _, r := range collect(t, resChan, errChan) {
+		events += len(r.Events)
+	}
+
+	if events != 25 {
+		t.Errorf("expected 25 events, got %d", events)
+	}
+}
+
+func TestFetchRawAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+	ctx := context.Background()
+
+	c := New("demo", "demo").SetEndpoint(srv.URL).SetConcurrency(3)
+	resChan, errChan := c.Fetch(ctx, *NewQuery("*").Size(10).MaxPage(2).MaxEvents(25))
+	decoded := collect(t, resChan, errChan)
+	resChan, errChan = c.Fetch(ctx, *NewQuery("*").Size(10).MaxPage(2).MaxEvents(25).Raw(true))
+	raw := collect(t, resChan, errChan)
+
+	if len(raw) != len(decoded) {
+		t.Fatalf("expected %d pages, got %d", len(decoded), len(raw))
+	}
+
+	for i := range raw {
+		if len(raw[i].Events) != 0 || raw[i].Len() != decoded[i].Len() {
+			t.Fatalf("page %d: expected %d raw events, got %+v", i, decoded[i].Len(), raw[i])
+		}
+
+		for j, data := range raw[i].Raw {
+			var event map[string]any
+			if err := json.Unmarshal(data, &event); err != nil {
+				t.Fatal(err)
+			}
+			if event["id"] != decoded[i].Events[j].(map[string]any)["id"] {
+				t.Errorf("page %d event %d: expected %v, got %v", i, j, decoded[i].Events[j], event)
+			}
+		}
+	}
+}
+
+func TestFetchPageAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+	ctx := context.Background()
+
+	c := New("demo", "demo").SetEndpoint(srv.URL)
+	q := NewQuery("*").Size(10).MaxPage(3).MaxEvents(25).Raw(true)
+	resChan, errChan := c.Fetch(ctx, *q)
+	pages := collect(t, resChan, errChan)
+
+	for i, want := range pages {
+		got, err := c.FetchPage(ctx, *q, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Len() != want.Len() {
+			t.Fatalf("page %d: expected %d events, got %d", i, want.Len(), got.Len())
+		}
+		for j := range want.Raw {
+			if string(got.Raw[j]) != string(want.Raw[j]) {
+				t.Errorf("page %d event %d: expected %s, got %s", i, j, want.Raw[j], got.Raw[j])
+			}
+		}
+	}
+}
+
+func TestPagesAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+	ctx := context.Background()
+
+	c := New("demo", "demo").SetEndpoint(srv.URL)
+	q := NewQuery("*").Size(10).MaxPage(2).MaxEvents(25)
+	resChan, errChan := c.Fetch(ctx, *q)
+	want := collect(t, resChan, errChan)
+
+	pages := c.Pages(*q)
+	for i := range want {
+		got, ok, err := pages.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("expected page %d", i)
+		}
+		if got.Page != want[i].Page || got.Len() != want[i].Len() {
+			t.Errorf("page %d: expected %d events, got page %d with %d", i, want[i].Len(), got.Page, got.Len())
+		}
+	}
+
+	if _, ok, err := pages.Next(ctx); ok || err != nil {
+		t.Errorf("expected no more pages, got %t, %v", ok, err)
+	}
+}
+
+func TestMergeByTimeAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+	ctx := context.Background()
+
+	c := New("demo", "demo").SetEndpoint(srv.URL).SetConcurrency(2)
+	errors := NewStream(c.Fetch(ctx, *NewQuery("json.level:error").Size(10).MaxPage(2)))
+	warnings := NewStream(c.Fetch(ctx, *NewQuery("json.level:warn").Size(10).MaxPage(2)))
+
+	resChan, errChan := MergeByTime(ctx, false, errors, warnings)
+	responses := collect(t, resChan, errChan)
+
+	if len(responses) == 0 {
+		t.Fatal("expected merged events")
+	}
+
+	var prev time.Time
+	for i, r := range responses {
+		ts, ok := EventTimestamp(r.Events[0])
+		if !ok {
+			t.Fatalf("event %d has no timestamp", i)
+		}
+		if i > 0 && ts.After(prev) {
+			t.Errorf("event %d (%s) is newer than the previous one (%s)", i, ts, prev)
+		}
+		prev = ts
+	}
+}
+
+type countingLimiter struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	return nil
+}
+
+func TestLimiterAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+
+	limiter := &countingLimiter{}
+	c := New("demo", "demo").SetEndpoint(srv.URL).SetLimiter(limiter)
+
+	resChan, errChan := c.Fetch(context.Background(), *NewQuery("*").Size(10).MaxPage(2))
+	collect(t, resChan, errChan)
+
+	// the search and its three pages
+	if limiter.calls != 4 {
+		t.Errorf("expected the limiter to be waited for 4 times, got %d", limiter.calls)
+	}
+}
+
+type countingObserver struct {
+	mu        sync.Mutex
+	responses int
+	bytes     int64
+	pages     int
+	events    int
+}
+
+func (o *countingObserver) Response(bytes int64, elapsed time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.responses++
+	o.bytes += bytes
+}
+
+func (o *countingObserver) Page(res Response, elapsed time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pages++
+	o.events += res.Len()
+}
+
+func TestObserverAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+
+	observer := &countingObserver{}
+	c := New("demo", "demo").SetEndpoint(srv.URL).SetObserver(observer)
+
+	resChan, errChan := c.Fetch(context.Background(), *NewQuery("*").Size(10).MaxPage(2).MaxEvents(25))
+	collect(t, resChan, errChan)
+
+	// the search and its three pages
+	if observer.responses != 4 || observer.bytes == 0 {
+		t.Errorf("expected 4 responses with a body, got %d of %d bytes", observer.responses, observer.bytes)
+	}
+	if observer.pages != 3 || observer.events != 25 {
+		t.Errorf("expected 25 events in 3 pages, got %d in %d", observer.events, observer.pages)
+	}
+}
+
+func TestSavedSearchesAgainstMockServer(t *testing.T) {
+	srv := startMockServer(t)
+
+	got, err := New("demo", "demo").SetEndpoint(srv.URL).SavedSearches(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 saved searches, got %+v", got)
+	}
+	want := SavedSearch{ID: 1, Name: "errors", Query: "json.level:error", From: "-1h", Until: "now"}
+	if got[0] != want {
+		t.Errorf("expected %+v, got %+v", want, got[0])
+	}
+}
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"", "https://acme.loggly.com/apiv2"},
+		{"eu.loggly.com/apiv2", "https://acme.eu.loggly.com/apiv2"},
+		{"http://127.0.0.1:8080/apiv2/", "http://127.0.0.1:8080/apiv2"},
+	}
+
+	for _, test := range tests {
+		c := New("acme", "token")
+		if test.endpoint != "" {
+			c.SetEndpoint(test.endpoint)
+		}
+
+		if got := c.URL(); got != test.want {
+			t.Errorf("endpoint %q: expected %q, got %q", test.endpoint, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Ajnasz/go-loggly-cli/configfile"
+	"github.com/Ajnasz/go-loggly-cli/ingest"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// ingestFlags The options of the commands sending events.
+type ingestFlags struct {
+	flags    *flag.FlagSet
+	token    *string
+	endpoint *string
+	profile  *string
+}
+
+func addIngestFlags(flags *flag.FlagSet) ingestFlags {
+	return ingestFlags{
+		flags:    flags,
+		token:    flags.String("ingest-token", os.Getenv("LOGGLY_INGEST_TOKEN"), ""),
+		endpoint: flags.String("ingest-endpoint", ingest.DefaultEndpoint, ""),
+		profile:  flags.String("profile", "", ""),
+	}
+}
+
+// newIngestClient Client of the ingest endpoint with the proxy and TLS
+// options of the search client. With -profile the ingest_token and
+// ingest_endpoint of the [profile.<name>] section of the config file
+// are used, unless the flags are given.
+func (f ingestFlags) newIngestClient(config Config, file configfile.File) (*ingest.Client, error) {
+	token, endpoint := *f.token, *f.endpoint
+	if *f.profile != "" {
+		given := make(map[string]bool)
+		f.flags.Visit(func(fl *flag.Flag) { given[fl.Name] = true })
+
+		prefix := "profile." + *f.profile + "."
+		if file[prefix+"ingest_token"] == "" && file[prefix+"ingest_endpoint"] == "" {
+			return nil, usageError(fmt.Errorf("profile %s is not in the config file", *f.profile))
+		}
+		if v := file[prefix+"ingest_token"]; v != "" && !given["ingest-token"] {
+			token = v
+		}
+		if v := file[prefix+"ingest_endpoint"]; v != "" && !given["ingest-endpoint"] {
+			endpoint = v
+		}
+	}
+
+	if token == "" {
+		return nil, usageError(fmt.Errorf("the customer token of the ingest endpoint is required, set -ingest-token or $LOGGLY_INGEST_TOKEN"))
+	}
+
+	client := ingest.New(token).SetEndpoint(endpoint)
+
+	proxy, err := parseProxy(config.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		client.SetProxy(proxy)
+	}
+
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		client.SetTLSConfig(tlsConfig)
+	}
+
+	return client, nil
+}
+
+// readEvents Read the non-empty lines of r as events.
+func readEvents(r io.Reader) ([][]byte, error) {
+	var events [][]byte
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, ingest.MaxEventSize+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) > 0 {
+			events = append(events, bytes.Clone(line))
+		}
+	}
+
+	return events, scanner.Err()
+}
+
+// runSend Post the lines of the files, or of the standard input, as
+// events to the bulk ingest endpoint.
+func runSend(args []string) {
+	var config Config
+	flags := newFlagSet("loggly send", &config)
+	ingestOpts := addIngestFlags(flags)
+	tags := flags.String("tag", "", "")
+	flags.Parse(args)
+
+	check(setErrorFormat(config.ErrorFormat))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+
+	client, err := ingestOpts.newIngestClient(config, fileConfig)
+	check(err)
+	client.SetTags(splitList(*tags))
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var events [][]byte
+	for _, name := range files {
+		var in io.Reader = os.Stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			check(usageError(err))
+			defer f.Close()
+			in = f
+		}
+
+		read, err := readEvents(in)
+		if err != nil {
+			check(fmt.Errorf("reading %s: %w", name, err))
+		}
+		events = append(events, read...)
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
+
+	sent, err := client.SendBulk(ctx, events)
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Sent %d of %d events", sent, len(events)))
+	check(err)
+}
@@ -0,0 +1,94 @@
+// Package session defines the file handing an investigation over to a
+// teammate: the query, the time range, the pinned fields, the notes and
+// the selected events. The file is JSON with a version, so later
+// versions can extend it.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Version The version of the session files. A teammate on a newer
+// loggly may hand over context this one does not show, like a new kind
+// of notes, so newer sessions are refused instead of opened without it.
+const Version = 1
+
+// Ext The conventional extension of the session files.
+const Ext = ".loggly"
+
+// Event A selected event.
+type Event struct {
+	ID   string         `json:"id,omitempty"`
+	Data map[string]any `json:"data"`
+}
+
+// Session The shared context of an investigation.
+type Session struct {
+	Version int    `json:"version"`
+	Query   string `json:"query"`
+	From    string `json:"from,omitempty"`
+	Until   string `json:"until,omitempty"`
+	// PinnedFields Dotted paths of the fields pinned in the field list.
+	PinnedFields []string `json:"pinned_fields,omitempty"`
+	// Annotations Notes of the events by event id.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Events      []Event           `json:"events,omitempty"`
+}
+
+// Read Decode a session.
+func Read(r io.Reader) (Session, error) {
+	var s Session
+
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	if err := d.Decode(&s); err != nil {
+		return Session{}, fmt.Errorf("session: invalid file: %w", err)
+	}
+
+	if s.Version < 1 {
+		return Session{}, fmt.Errorf("session: missing version")
+	}
+	if s.Version > Version {
+		return Session{}, fmt.Errorf("session: version %d is newer than the supported %d, update loggly", s.Version, Version)
+	}
+
+	return s, nil
+}
+
+// Load Read the session file.
+func Load(path string) (Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Session{}, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Write Encode the session with the current version.
+func (s Session) Write(w io.Writer) error {
+	s.Version = Version
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Save Write the session file.
+func (s Session) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
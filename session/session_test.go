@@ -0,0 +1,71 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	s := Session{
+		Query:        "json.level:error",
+		From:         "-2h",
+		Until:        "now",
+		PinnedFields: []string{"json.hostname"},
+		Annotations:  map[string]string{"e1": "root cause"},
+		Events: []Event{
+			{ID: "e1", Data: map[string]any{"message": "boom", "duration": json.Number("1200")}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "incident"+Ext)
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Version = Version
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("expected %+v, got %+v", s, got)
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`{"query":"*"}`, "missing version"},
+		{`{"version":2,"query":"*"}`, "newer"},
+		{`{"version":1`, "invalid file"},
+	}
+
+	for _, test := range tests {
+		_, err := Read(strings.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.input, test.err, err)
+		}
+	}
+}
+
+func TestWriteSetsVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (Session{Query: "*"}).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Version != Version {
+		t.Errorf("expected version %d, got %d", Version, s.Version)
+	}
+}
@@ -0,0 +1,212 @@
+// Package snapshot stores the counts of the events of a query grouped by
+// the values of some fields, and compares a later run with them, for
+// example to verify that a fix made a class of errors go away.
+package snapshot
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Version The version of the snapshot files. A newer snapshot may
+// group or filter the events in ways this loggly does not repeat, and a
+// diff against it would report changes that did not happen, so it is
+// refused.
+const Version = 1
+
+// Group The number of the events with the same values of the fields.
+type Group struct {
+	Values []string `json:"values"`
+	Count  int      `json:"count"`
+}
+
+// key Identifies the group by its values.
+func (g Group) key() string {
+	return strings.Join(g.Values, "\x00")
+}
+
+// Snapshot The grouped counts of a query.
+type Snapshot struct {
+	Version int    `json:"version"`
+	Query   string `json:"query"`
+	From    string `json:"from,omitempty"`
+	Until   string `json:"until,omitempty"`
+	// By The fields grouping the events.
+	By []string `json:"by"`
+	// All The fields are paths of the whole events, not of the
+	// messages.
+	All bool `json:"all,omitempty"`
+	// Grep, GrepV, Where and JQ The filters of the counted events, the
+	// diff counts with the same ones.
+	Grep    string    `json:"grep,omitempty"`
+	GrepV   string    `json:"grep_v,omitempty"`
+	Where   string    `json:"where,omitempty"`
+	JQ      string    `json:"jq,omitempty"`
+	SavedAt time.Time `json:"saved_at"`
+	Groups  []Group   `json:"groups"`
+}
+
+// Counter Groups the events by the values of the fields.
+type Counter struct {
+	groups map[string]*Group
+}
+
+// NewCounter Create an empty counter.
+func NewCounter() *Counter {
+	return &Counter{groups: make(map[string]*Group)}
+}
+
+// Add Count an event with the values.
+func (c *Counter) Add(values []string) {
+	g := Group{Values: values}
+	if existing, ok := c.groups[g.key()]; ok {
+		existing.Count++
+		return
+	}
+	g.Values = slices.Clone(values)
+	g.Count = 1
+	c.groups[g.key()] = &g
+}
+
+// Groups The groups from the largest to the smallest, the equal ones by
+// their values.
+func (c *Counter) Groups() []Group {
+	groups := make([]Group, 0, len(c.groups))
+	for _, g := range c.groups {
+		groups = append(groups, *g)
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), slices.Compare(a.Values, b.Values))
+	})
+	return groups
+}
+
+// Total The number of the events of the groups.
+func (s Snapshot) Total() int {
+	total := 0
+	for _, g := range s.Groups {
+		total += g.Count
+	}
+	return total
+}
+
+// Kinds of the changes of the groups.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change A group which appeared, disappeared or has another count.
+type Change struct {
+	Kind   string
+	Values []string
+	Before int
+	After  int
+}
+
+// Diff The groups changed from before to after: the removed ones first,
+// then the added and the changed ones, each by the size of the change.
+// Returns the number of the unchanged groups too.
+func Diff(before, after []Group) ([]Change, int) {
+	counts := make(map[string]Group, len(before))
+	for _, g := range before {
+		counts[g.key()] = g
+	}
+
+	var changes []Change
+	unchanged := 0
+	for _, g := range after {
+		previous, ok := counts[g.key()]
+		delete(counts, g.key())
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Added, Values: g.Values, After: g.Count})
+		case previous.Count != g.Count:
+			changes = append(changes, Change{Kind: Changed, Values: g.Values, Before: previous.Count, After: g.Count})
+		default:
+			unchanged++
+		}
+	}
+	for _, g := range before {
+		if _, ok := counts[g.key()]; ok {
+			changes = append(changes, Change{Kind: Removed, Values: g.Values, Before: g.Count})
+		}
+	}
+
+	order := map[string]int{Removed: 0, Added: 1, Changed: 2}
+	slices.SortFunc(changes, func(a, b Change) int {
+		return cmp.Or(
+			cmp.Compare(order[a.Kind], order[b.Kind]),
+			cmp.Compare(abs(b.After-b.Before), abs(a.After-a.Before)),
+			slices.Compare(a.Values, b.Values),
+		)
+	})
+
+	return changes, unchanged
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Read Decode a snapshot.
+func Read(r io.Reader) (Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot: invalid file: %w", err)
+	}
+
+	if s.Version < 1 {
+		return Snapshot{}, fmt.Errorf("snapshot: missing version")
+	}
+	if s.Version > Version {
+		return Snapshot{}, fmt.Errorf("snapshot: version %d is newer than the supported %d, update loggly", s.Version, Version)
+	}
+
+	return s, nil
+}
+
+// Load Read the snapshot file.
+func Load(path string) (Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Write Encode the snapshot with the current version.
+func (s Snapshot) Write(w io.Writer) error {
+	s.Version = Version
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Save Write the snapshot file.
+func (s Snapshot) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,108 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+	values := []string{"error", "billing"}
+	c.Add(values)
+	// the counter keeps its own copy
+	values[1] = "frontend"
+	c.Add(values)
+	c.Add([]string{"error", "billing"})
+	c.Add([]string{"warn", "-"})
+
+	want := []Group{
+		{Values: []string{"error", "billing"}, Count: 2},
+		{Values: []string{"error", "frontend"}, Count: 1},
+		{Values: []string{"warn", "-"}, Count: 1},
+	}
+	if got := c.Groups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := []Group{
+		{Values: []string{"timeout"}, Count: 120},
+		{Values: []string{"not found"}, Count: 40},
+		{Values: []string{"conflict"}, Count: 3},
+		{Values: []string{"denied"}, Count: 7},
+	}
+	after := []Group{
+		{Values: []string{"not found"}, Count: 12},
+		{Values: []string{"conflict"}, Count: 3},
+		{Values: []string{"denied"}, Count: 9},
+		{Values: []string{"disk full"}, Count: 5},
+	}
+
+	changes, unchanged := Diff(before, after)
+
+	want := []Change{
+		{Kind: Removed, Values: []string{"timeout"}, Before: 120},
+		{Kind: Added, Values: []string{"disk full"}, After: 5},
+		{Kind: Changed, Values: []string{"not found"}, Before: 40, After: 12},
+		{Kind: Changed, Values: []string{"denied"}, Before: 7, After: 9},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("expected %v, got %v", want, changes)
+	}
+	if unchanged != 1 {
+		t.Errorf("expected 1 unchanged group, got %d", unchanged)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	s := Snapshot{
+		Query:   "json.level:error",
+		From:    "-1h",
+		Until:   "now",
+		By:      []string{"json.message"},
+		GrepV:   "healthcheck",
+		Where:   `json.status >= 500`,
+		SavedAt: time.Date(2025, 10, 17, 10, 15, 0, 0, time.UTC),
+		Groups:  []Group{{Values: []string{"timeout"}, Count: 120}},
+	}
+
+	path := filepath.Join(t.TempDir(), "before-fix.json")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Version = Version
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("expected %+v, got %+v", s, got)
+	}
+	if got.Total() != 120 {
+		t.Errorf("expected a total of 120, got %d", got.Total())
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`{"query":"*"}`, "missing version"},
+		{`{"version":2,"query":"*"}`, "newer"},
+		{`{"version":1`, "invalid file"},
+	}
+
+	for _, test := range tests {
+		_, err := Read(strings.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.input, test.err, err)
+		}
+	}
+}
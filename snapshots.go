@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/platform"
+	"github.com/Ajnasz/go-loggly-cli/snapshot"
+)
+
+// snapshotExt The extension of the snapshot files.
+const snapshotExt = ".json"
+
+func validateSnapshotName(name string) error {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
+
+// snapshotsDir Directory of the snapshots, in the state directory.
+func snapshotsDir() (string, error) {
+	return platform.StateFile("snapshots")
+}
+
+func snapshotPath(name string) (string, error) {
+	dir, err := snapshotsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+snapshotExt), nil
+}
+
+// runSnapshot Handle the snapshot subcommands.
+func runSnapshot(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "save":
+			runSnapshotSave(args[1:])
+			return
+		case "diff":
+			runSnapshotDiff(args[1:])
+			return
+		case "list":
+			runSnapshotList()
+			return
+		}
+	}
+
+	check(usageError(fmt.Errorf("usage: loggly snapshot save -by <fields> [options] <name> [query...] | loggly snapshot diff [options] <name> | loggly snapshot list")))
+}
+
+// countGroups Count the events of the query by the values of the fields.
+// Warns when the page limits cut the counting short.
+func countGroups(config Config, command, query string, fields []string) []snapshot.Group {
+	counter := snapshot.NewCounter()
+	values := make([]string, len(fields))
+	scanEvents(config, command, query, func(event any) {
+		for i, field := range fields {
+			values[i] = pivotValue(event, field)
+		}
+		counter.Add(values)
+	})
+
+	return counter.Groups()
+}
+
+// runSnapshotSave Count the events of the query by the -by fields and
+// store the counts under the name.
+func runSnapshotSave(args []string) {
+	var config Config
+	flags := newFlagSet("loggly snapshot save", &config)
+	by := flags.String("by", "", "")
+	flags.Parse(args)
+
+	prepareScanConfig(flags, &config)
+
+	if flags.NArg() == 0 {
+		check(usageError(fmt.Errorf("usage: loggly snapshot save -by <fields> [options] <name> [query...]")))
+	}
+	name := flags.Arg(0)
+	check(usageError(validateSnapshotName(name)))
+	fields := splitList(*by)
+	if len(fields) == 0 {
+		check(usageError(fmt.Errorf("by is required, the comma separated fields grouping the events")))
+	}
+
+	if len(splitList(config.Account)) > 1 {
+		check(usageError(fmt.Errorf("a snapshot counts the events of a single account")))
+	}
+
+	query := strings.Join(flags.Args()[1:], " ")
+	if query == "" {
+		query = "*"
+	}
+
+	s := snapshot.Snapshot{
+		Query:   query,
+		From:    config.From,
+		Until:   config.To,
+		By:      fields,
+		All:     config.AllMsg,
+		Grep:    config.Grep,
+		GrepV:   config.GrepV,
+		Where:   config.Where,
+		JQ:      config.JQ,
+		SavedAt: time.Now().UTC(),
+	}
+	s.Groups = countGroups(config, "snapshot save", query, fields)
+
+	path, err := snapshotPath(name)
+	check(err)
+	check(platform.EnsureDir(path))
+	check(s.Save(path))
+
+	fmt.Fprintln(os.Stderr, locale.Sprintf("Saved snapshot %s: %d events in %d groups", name, s.Total(), len(s.Groups)))
+}
+
+// runSnapshotDiff Run the query of the snapshot again and print the
+// groups added, removed and changed since. The time range and the
+// filters of the snapshot are used unless they are given.
+func runSnapshotDiff(args []string) {
+	var config Config
+	flags := newFlagSet("loggly snapshot diff", &config)
+	flags.Parse(args)
+
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	prepareScanConfig(flags, &config)
+
+	if flags.NArg() != 1 {
+		check(usageError(fmt.Errorf("usage: loggly snapshot diff [options] <name>")))
+	}
+	name := flags.Arg(0)
+	check(usageError(validateSnapshotName(name)))
+
+	path, err := snapshotPath(name)
+	check(err)
+	before, err := snapshot.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		check(usageError(fmt.Errorf("no snapshot named %s, save it with loggly snapshot save", name)))
+	}
+	check(err)
+
+	if !given["from"] {
+		config.From = before.From
+	}
+	if !given["to"] && !given["until"] {
+		config.To = before.Until
+	}
+	config.AllMsg = before.All
+	// the events of the snapshot are counted, unless other filters are
+	// given
+	if !given["grep"] {
+		config.Grep = before.Grep
+	}
+	if !given["grep-v"] {
+		config.GrepV = before.GrepV
+	}
+	if !given["where"] {
+		config.Where = before.Where
+	}
+	if !given["jq"] {
+		config.JQ = before.JQ
+	}
+	if len(splitList(config.Account)) > 1 {
+		check(usageError(fmt.Errorf("a snapshot counts the events of a single account")))
+	}
+
+	after := countGroups(config, "snapshot diff", before.Query, before.By)
+	changes, unchanged := snapshot.Diff(before.Groups, after)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "CHANGE\tBEFORE\tAFTER\tDELTA\t%s\n", strings.Join(before.By, "\t"))
+	for _, c := range changes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%+d\t%s\n", c.Kind, locale.Int(c.Before), locale.Int(c.After), c.After-c.Before, strings.Join(c.Values, "\t"))
+	}
+	check(tw.Flush())
+
+	var added, removed, changed int
+	for _, c := range changes {
+		switch c.Kind {
+		case snapshot.Added:
+			added++
+		case snapshot.Removed:
+			removed++
+		default:
+			changed++
+		}
+	}
+	fmt.Fprintln(os.Stderr, locale.Sprintf("%d groups added, %d removed, %d changed, %d unchanged since %s", added, removed, changed, unchanged, locale.Time(before.SavedAt)))
+}
+
+// runSnapshotList Print the snapshots and what they count.
+func runSnapshotList() {
+	dir, err := snapshotsDir()
+	check(err)
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	check(err)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tGROUPS\tEVENTS\tSAVED\tBY\tQUERY")
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapshotExt)
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		s, err := snapshot.Load(filepath.Join(dir, entry.Name()))
+		check(err)
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", name, locale.Int(len(s.Groups)), locale.Int(s.Total()), locale.Time(s.SavedAt), strings.Join(s.By, ","), s.Query)
+	}
+	check(tw.Flush())
+}
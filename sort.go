@@ -0,0 +1,105 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+// eventSorter collects the events to print them ordered by the -sort
+// field once every page is fetched.
+type eventSorter struct {
+	field  string
+	desc   bool
+	events []any
+}
+
+func newEventSorter(config Config) *eventSorter {
+	if config.Sort == "" {
+		return nil
+	}
+
+	return &eventSorter{field: config.Sort, desc: config.Desc}
+}
+
+func (s *eventSorter) Add(events []any) {
+	s.events = append(s.events, events...)
+}
+
+// sortKey The value of the field, numeric when it is a number or a
+// numeric string.
+type sortKey struct {
+	missing bool
+	numeric bool
+	n       float64
+	s       string
+}
+
+func newSortKey(event any, field string) sortKey {
+	v, ok := lookupField(event, field)
+	if !ok || v == nil {
+		return sortKey{missing: true}
+	}
+	if n, ok := numericValue(v); ok {
+		return sortKey{numeric: true, n: n}
+	}
+	return sortKey{s: formatValue(v)}
+}
+
+// compareSortKeys Numbers before strings, the numbers by value, the
+// strings in lexical order.
+func compareSortKeys(a, b sortKey) int {
+	switch {
+	case a.numeric && b.numeric:
+		return cmp.Compare(a.n, b.n)
+	case a.numeric != b.numeric:
+		if a.numeric {
+			return -1
+		}
+		return 1
+	default:
+		return cmp.Compare(a.s, b.s)
+	}
+}
+
+// Sorted The events ordered by the field, ascending unless -desc is set.
+// The events without the field come last either way, the equal ones
+// keep the order they were fetched in.
+func (s *eventSorter) Sorted() []any {
+	type keyed struct {
+		key   sortKey
+		event any
+	}
+
+	items := make([]keyed, len(s.events))
+	for i, event := range s.events {
+		items[i] = keyed{newSortKey(event, s.field), event}
+	}
+
+	slices.SortStableFunc(items, func(a, b keyed) int {
+		if a.key.missing || b.key.missing {
+			return compareBool(a.key.missing, b.key.missing)
+		}
+		if s.desc {
+			return compareSortKeys(b.key, a.key)
+		}
+		return compareSortKeys(a.key, b.key)
+	})
+
+	sorted := make([]any, len(items))
+	for i, item := range items {
+		sorted[i] = item.event
+	}
+	return sorted
+}
+
+// compareBool False before true.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
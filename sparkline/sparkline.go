@@ -0,0 +1,72 @@
+// Package sparkline renders a series of counts as a single line of
+// block characters, like ▁▂▅▇█▃, scaled from zero to the largest count.
+package sparkline
+
+import "strings"
+
+var ticks = []rune("▁▂▃▄▅▆▇█")
+
+// Render Draw a character per value. Zero is the lowest block, any other
+// value is at least the second one, so a single event stands out from
+// none.
+func Render(values []int64) string {
+	var top int64
+	for _, v := range values {
+		top = max(top, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		sb.WriteRune(ticks[level(v, top)])
+	}
+
+	return sb.String()
+}
+
+func level(v, top int64) int {
+	if v <= 0 || top <= 0 {
+		return 0
+	}
+
+	steps := int64(len(ticks) - 1)
+	// round up, so every non zero value is above the lowest block
+	return int((v*steps + top - 1) / top)
+}
+
+// Series A fixed number of the most recent values.
+type Series struct {
+	size   int
+	values []int64
+}
+
+// NewSeries Create a series keeping the last size values.
+func NewSeries(size int) *Series {
+	return &Series{size: max(size, 1)}
+}
+
+// Add Append a value, dropping the oldest one when the series is full.
+func (s *Series) Add(v int64) {
+	s.values = append(s.values, v)
+	if len(s.values) > s.size {
+		s.values = s.values[len(s.values)-s.size:]
+	}
+}
+
+// Values The values from the oldest to the newest.
+func (s *Series) Values() []int64 {
+	return s.values
+}
+
+// Last The newest value, false for an empty series.
+func (s *Series) Last() (int64, bool) {
+	if len(s.values) == 0 {
+		return 0, false
+	}
+	return s.values[len(s.values)-1], true
+}
+
+// String The sparkline of the series, padded to the size of the series,
+// so the lines of several series stay aligned while they fill up.
+func (s *Series) String() string {
+	return Render(s.values) + strings.Repeat(" ", s.size-len(s.values))
+}
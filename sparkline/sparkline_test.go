@@ -0,0 +1,49 @@
+package sparkline
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		values []int64
+		want   string
+	}{
+		{nil, ""},
+		{[]int64{0, 0, 0}, "▁▁▁"},
+		{[]int64{0, 1, 2, 3, 4, 5, 6, 7}, "▁▂▃▄▅▆▇█"},
+		{[]int64{1, 1000}, "▂█"},
+		{[]int64{5, 5}, "██"},
+	}
+
+	for _, test := range tests {
+		if got := Render(test.values); got != test.want {
+			t.Errorf("%v: expected %q, got %q", test.values, test.want, got)
+		}
+	}
+}
+
+func TestSeries(t *testing.T) {
+	s := NewSeries(3)
+	if _, ok := s.Last(); ok {
+		t.Error("expected an empty series to have no last value")
+	}
+
+	s.Add(0)
+	if got := s.String(); got != "▁  " {
+		t.Errorf("expected the sparkline padded to 3, got %q", got)
+	}
+
+	for _, v := range []int64{7, 1, 7} {
+		s.Add(v)
+	}
+
+	values := s.Values()
+	if len(values) != 3 || values[0] != 7 || values[2] != 7 {
+		t.Errorf("expected the last 3 values, got %v", values)
+	}
+	if last, ok := s.Last(); !ok || last != 7 {
+		t.Errorf("expected the last value 7, got %d", last)
+	}
+	if got := s.String(); got != "█▂█" {
+		t.Errorf("unexpected sparkline %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// runStats Collects the summary printed by -stats, to tune the
+// -concurrency and the -size: the matching and the fetched events, the
+// pages, their latencies and the downloaded bytes.
+type runStats struct {
+	start time.Time
+
+	mu sync.Mutex
+	// matched The total of the pages by account, several accounts are
+	// searched separately.
+	matched   map[string]int64
+	fetched   int64
+	latencies []time.Duration
+	bytes     int64
+}
+
+func newRunStats() *runStats {
+	return &runStats{start: time.Now(), matched: make(map[string]int64)}
+}
+
+// statsObserver Records the responses and the pages of the client of an
+// account.
+type statsObserver struct {
+	stats   *runStats
+	account string
+}
+
+func (o statsObserver) Response(bytes int64, elapsed time.Duration) {
+	o.stats.mu.Lock()
+	defer o.stats.mu.Unlock()
+	o.stats.bytes += bytes
+}
+
+func (o statsObserver) Page(res search.Response, elapsed time.Duration) {
+	o.stats.mu.Lock()
+	defer o.stats.mu.Unlock()
+	o.stats.matched[o.account] = max(o.stats.matched[o.account], res.Total)
+	o.stats.fetched += int64(res.Len())
+	o.stats.latencies = append(o.stats.latencies, elapsed)
+}
+
+// observer The observer of the client of the account.
+func (s *runStats) observer(account string) search.Observer {
+	return statsObserver{stats: s, account: account}
+}
+
+// percentile The latency the p percent of the sorted latencies are
+// within, by the nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// Print Print the summary.
+func (s *runStats) Print(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched int64
+	for _, total := range s.matched {
+		matched += total
+	}
+
+	latencies := slices.Sorted(slices.Values(s.latencies))
+	latency := "-"
+	if len(latencies) > 0 {
+		latency = fmt.Sprintf("p50 %s, p90 %s, p99 %s, max %s",
+			percentile(latencies, 50).Round(time.Millisecond),
+			percentile(latencies, 90).Round(time.Millisecond),
+			percentile(latencies, 99).Round(time.Millisecond),
+			latencies[len(latencies)-1].Round(time.Millisecond))
+	}
+
+	lines := []string{
+		locale.Sprintf("Matching events: %s", locale.Int(matched)),
+		locale.Sprintf("Fetched events:  %s", locale.Int(s.fetched)),
+		locale.Sprintf("Fetched pages:   %s", locale.Int(len(latencies))),
+		locale.Sprintf("Downloaded:      %s", formatBytes(s.bytes)),
+		locale.Sprintf("Wall time:       %s", time.Since(s.start).Round(time.Millisecond)),
+		locale.Sprintf("Page latency:    %s", latency),
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/scheduler"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// tailFrom The start of the first poll of tail when -from is not given.
+const tailFrom = "-1m"
+
+// tailSeen The ids of the printed events with their timestamps. The
+// polls overlap, the ids older than the polled window are forgotten.
+type tailSeen struct {
+	ids map[string]time.Time
+}
+
+func newTailSeen() *tailSeen {
+	return &tailSeen{ids: make(map[string]time.Time)}
+}
+
+// Apply Return the events not printed before, the events without id
+// are kept.
+func (s *tailSeen) Apply(events []any) []any {
+	var ret []any
+	for _, event := range events {
+		if v, ok := lookupField(event, "id"); ok && v != nil {
+			id := formatValue(v)
+			if _, seen := s.ids[id]; seen {
+				continue
+			}
+			ts, ok := search.EventTimestamp(event)
+			if !ok {
+				ts = time.Now()
+			}
+			s.ids[id] = ts
+		}
+		ret = append(ret, event)
+	}
+
+	return ret
+}
+
+// Forget Drop the ids of the events older than the time, the next polls
+// do not return them again.
+func (s *tailSeen) Forget(before time.Time) {
+	for id, ts := range s.ids {
+		if ts.Before(before) {
+			delete(s.ids, id)
+		}
+	}
+}
+
+// byTimestamp Order the events from the oldest to the newest.
+func byTimestamp(a, b any) int {
+	ta, _ := search.EventTimestamp(a)
+	tb, _ := search.EventTimestamp(b)
+	return ta.Compare(tb)
+}
+
+// pollEvents Fetch every page of the query.
+func pollEvents(ctx context.Context, config Config, query string) ([]any, error) {
+	stream, err := fetchEvents(ctx, config, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []any
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case r := <-stream.Responses:
+			events = append(events, r.Events...)
+		case err := <-stream.Errors:
+			return events, err
+		}
+	}
+}
+
+// runTail Poll the query every interval and print the new events as
+// they become searchable, like tail -f. Each poll reaches back -lag to
+// catch the events indexed late, the events printed before are dropped
+// by their ids.
+func runTail(args []string) {
+	var config Config
+	flags := newFlagSet("loggly tail", &config)
+	interval := flags.Duration("interval", 5*time.Second, "")
+	lag := flags.Duration("lag", time.Minute, "")
+	flags.Parse(args)
+
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	check(setErrorFormat(config.ErrorFormat))
+	check(usageError(checkUntilAlias(flags)))
+	fileConfig, fileErr := loadConfigFile()
+	check(usageError(fileErr))
+	_, defaultsErr := applyDefaults(flags, fileConfig, os.Getenv)
+	check(usageError(defaultsErr))
+
+	if given["to"] || given["until"] {
+		check(usageError(fmt.Errorf("tail follows the new events, -until can not be used")))
+	}
+	if !given["from"] {
+		config.From = tailFrom
+	}
+	if *interval < time.Second {
+		check(usageError(fmt.Errorf("interval must be at least 1s")))
+	}
+	if *lag < 0 {
+		check(usageError(fmt.Errorf("lag must not be negative")))
+	}
+	if config.Input != "" || config.SampleOutput > 0 || config.ValueHistogram != "" || config.Pivot != "" || config.Sort != "" || config.PageSize > 0 {
+		check(usageError(fmt.Errorf("tail can not be used with -input, -sample-output, -value-histogram, -pivot, -sort or -page-size")))
+	}
+
+	query, queryErr := resolveQuery(config, flags.Args())
+	check(queryErr)
+	if query == "" {
+		query = "*"
+	}
+
+	ctx, cancel := contextWithInterrupt(context.Background())
+	defer cancel()
+
+	config.CredentialHelper = credentialHelper(fileConfig, config.Account)
+	auditLog, auditErr := auditLogPath(fileConfig)
+	check(usageError(auditErr))
+	config.AuditLog = auditLog
+	historyFile, historyErr := historyPath(fileConfig)
+	check(usageError(historyErr))
+	config.HistoryFile = historyFile
+	check(config.resolveToken(os.Stdin))
+	config.Format = resolveFormat(config, fileConfig)
+	config.FileFormat = resolveFileFormat(config, fileConfig)
+	check(usageError(config.normalizeTimeRange()))
+	check(usageError(config.Validate()))
+	useTimeLocation(config)
+	check(lintQuery(config, query))
+
+	out, outErr := newOutput(config, query)
+	check(outErr)
+	defer func() { check(out.Close()) }()
+
+	filters, filtersErr := newEventFilters(config)
+	check(filtersErr)
+	dedup := newDedupFilter(config)
+	seen := newTailSeen()
+	fields := newFieldRecorder(config)
+
+	limit := (config.MaxPages + 1) * int64(config.Size)
+	if config.MaxEvents > 0 {
+		limit = min(limit, config.MaxEvents)
+	}
+
+	check(startAudit(config, "tail", query))
+	printed := 0
+	from := config.From
+	poll := func(ctx context.Context) error {
+		now := time.Now()
+		pollConfig := config
+		pollConfig.From = from
+		pollConfig.To = now.UTC().Format(time.RFC3339Nano)
+
+		events, err := pollEvents(ctx, pollConfig, query)
+		if err != nil {
+			return err
+		}
+		if int64(len(events)) >= limit {
+			config.warnf("A poll reached the page limits, some events may be missing, raise -maxPages or -size or lower -interval")
+		}
+
+		// the API returns the newest events first
+		events = seen.Apply(events)
+		fields.Add(events)
+		fields.Save(config)
+		slices.SortStableFunc(events, byTimestamp)
+		events = dedup.Apply(events)
+
+		decoded, ok := decodeRes(config, search.Response{Events: events})
+		if ok {
+			decoded = filters.Apply(decoded)
+			check(out.Write(decoded))
+			printed += len(decoded)
+		}
+
+		next := now.Add(-*lag)
+		seen.Forget(next)
+		from = next.UTC().Format(time.RFC3339Nano)
+		return nil
+	}
+
+	s := scheduler.New(scheduler.Options{
+		Interval: *interval,
+		OnError: func(_ string, err error) {
+			fmt.Fprintln(os.Stderr, locale.Sprintf("Error: %s", err))
+		},
+	})
+
+	// the errors of the first poll, like a wrong token or query, are
+	// fatal, the later ones and the transient ones, like a maintenance
+	// page, are retried with a backoff
+	wait := *interval
+	if _, err := s.TryRun(ctx, "tail", poll); err != nil && ctx.Err() == nil {
+		var apiErr *search.APIError
+		if !errors.As(err, &apiErr) || !apiErr.Transient() {
+			check(err)
+		}
+		fmt.Fprintln(os.Stderr, locale.Sprintf("Error: %s", err))
+		wait = max(wait+s.Backoff(), apiErr.RetryAfter())
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(wait):
+		s.Every(ctx, "tail", poll)
+	}
+
+	check(finishAudit(int64(printed), nil))
+}
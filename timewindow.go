@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/naturaltime"
+)
+
+// timeWindow Length of the from-to range, if both ends can be resolved.
+func timeWindow(from, to string, now time.Time) (time.Duration, bool) {
+	start, ok := resolveTime(from, now)
+	if !ok {
+		return 0, false
+	}
+
+	end, ok := resolveTime(to, now)
+	if !ok {
+		return 0, false
+	}
+
+	return end.Sub(start), true
+}
+
+// timeFormatsHelp Accepted -from and -to values, for the error messages.
+const timeFormatsHelp = "use now, a relative offset like -30m, -24h, -7d or -2w, " +
+	"an ISO 8601 timestamp like 2024-01-02T15:04:05Z or 2024-01-02, " +
+	"a unix epoch in seconds or milliseconds, " +
+	"or an expression like \"yesterday 14:00\", \"last monday\" or \"2 days ago\""
+
+// absoluteLayouts ISO 8601 layouts, the ones without a zone are in the
+// local time zone.
+var absoluteLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// resolveTime Understand "now", relative offsets like -24h, ISO 8601
+// timestamps, unix epochs and natural language expressions.
+func resolveTime(value string, now time.Time) (time.Time, bool) {
+	if value == "now" {
+		return now, true
+	}
+
+	if t, ok := parseAbsoluteTime(value); ok {
+		return t, true
+	}
+
+	if offset, ok := parseOffset(value); ok {
+		return now.Add(-offset), true
+	}
+
+	return naturaltime.Parse(value, now)
+}
+
+func parseAbsoluteTime(value string) (time.Time, bool) {
+	for _, layout := range absoluteLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > 0 {
+		// 10 digits are seconds until 2286, 13 digits are milliseconds
+		if n >= 1e11 {
+			return time.UnixMilli(n), true
+		}
+		return time.Unix(n, 0), true
+	}
+
+	return time.Time{}, false
+}
+
+// parseOffset Parse a relative offset like -24h.
+func parseOffset(value string) (time.Duration, bool) {
+	if len(value) < 3 || value[0] != '-' {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	units := map[byte]time.Duration{
+		's': time.Second,
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+
+	return time.Duration(n) * unit, true
+}
+
+// normalizeTime Check a -from or -to value and convert it to a form the
+// API understands: now and relative offsets are kept, timestamps, epochs
+// and natural language expressions become RFC 3339 UTC times.
+func normalizeTime(name, value string, now time.Time) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%s must not be empty", name)
+	}
+
+	if value == "now" {
+		return value, nil
+	}
+
+	if _, ok := parseOffset(value); ok {
+		return value, nil
+	}
+
+	if t, ok := parseAbsoluteTime(value); ok {
+		return t.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano), nil
+	}
+
+	if t, ok := naturaltime.Parse(value, now); ok {
+		return t.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano), nil
+	}
+
+	return "", fmt.Errorf("invalid %s %q: %s", name, value, timeFormatsHelp)
+}
+
+// validateTimeRange Check that the from and until values are valid
+// and from is before until.
+func validateTimeRange(from, until string, now time.Time) error {
+	if _, err := normalizeTime("from", from, now); err != nil {
+		return err
+	}
+	if _, err := normalizeTime("until", until, now); err != nil {
+		return err
+	}
+
+	start, _ := resolveTime(strings.TrimSpace(from), now)
+	end, _ := resolveTime(strings.TrimSpace(until), now)
+	if !start.Before(end) {
+		return fmt.Errorf("from (%s) must be before until (%s)", from, until)
+	}
+
+	return nil
+}
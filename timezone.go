@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/search"
+)
+
+// Names of the -time-format values printing numbers instead of text.
+const (
+	timeFormatUnix   = "unix"
+	timeFormatUnixMS = "unixms"
+)
+
+// timeFormats Layouts of the named -time-format values, any other value
+// is a Go time layout.
+var timeFormats = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339ms":   "2006-01-02T15:04:05.000Z07:00",
+	"rfc3339nano": time.RFC3339Nano,
+	"datetime":    time.DateTime,
+}
+
+// defaultTimeFormat The -time-format used by -tz and -utc alone.
+const defaultTimeFormat = "rfc3339ms"
+
+// timestampLayouts The layouts of the ISO 8601 timestamps rewritten in
+// the messages. Timestamps without a time zone are ambiguous, they are
+// left as they are.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+}
+
+// timeLocation The time zone selected by -utc or -tz, the local one by
+// default.
+func (c Config) timeLocation() (*time.Location, error) {
+	switch {
+	case c.UTC:
+		return time.UTC, nil
+	case c.TimeZone != "":
+		loc, err := time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tz %q: %w", c.TimeZone, err)
+		}
+		return loc, nil
+	default:
+		return time.Local, nil
+	}
+}
+
+// useTimeLocation Print the times of the human readable outputs, like
+// the table and the histograms, in the zone of -tz or -utc as well.
+func useTimeLocation(config Config) {
+	if config.TimeZone == "" && !config.UTC {
+		return
+	}
+
+	if loc, err := config.timeLocation(); err == nil {
+		locale.Default = locale.New(locale.FromEnv(os.Getenv), loc)
+	}
+}
+
+// validateTimeFormat Check that the -time-format is a name or a layout.
+func validateTimeFormat(format string) error {
+	if format == timeFormatUnix || format == timeFormatUnixMS {
+		return nil
+	}
+	if _, ok := timeFormats[format]; ok {
+		return nil
+	}
+
+	// a layout without any element formats to itself
+	if time.Unix(0, 0).Format(format) == format {
+		names := slices.Sorted(maps.Keys(timeFormats))
+		return fmt.Errorf("invalid time-format %q, use %s, %s, %s or a Go time layout like 2006-01-02 15:04:05", format, strings.Join(names, ", "), timeFormatUnix, timeFormatUnixMS)
+	}
+
+	return nil
+}
+
+// timeRewriter rewrites the timestamp of the Loggly events and the ISO
+// 8601 timestamps of the messages in the zone and format of -tz, -utc
+// and -time-format.
+type timeRewriter struct {
+	loc    *time.Location
+	format string
+}
+
+// newTimeRewriter Returns nil without -tz, -utc and -time-format.
+func newTimeRewriter(config Config) (*timeRewriter, error) {
+	if config.TimeZone == "" && !config.UTC && config.TimeFormat == "" {
+		return nil, nil
+	}
+
+	loc, err := config.timeLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	format := config.TimeFormat
+	if format == "" {
+		format = defaultTimeFormat
+	}
+	if layout, ok := timeFormats[format]; ok {
+		format = layout
+	}
+
+	return &timeRewriter{loc: loc, format: format}, nil
+}
+
+// Apply Rewrite the timestamps of the events in place.
+func (r *timeRewriter) Apply(events []any) []any {
+	if r == nil {
+		return events
+	}
+
+	for i, event := range events {
+		m, ok := event.(map[string]any)
+		if !ok {
+			events[i] = r.rewrite(event)
+			continue
+		}
+
+		// the epoch milliseconds of the Loggly events, also added by -merge
+		t, isEpoch := search.EventTimestamp(m)
+		for k, v := range m {
+			if k == "timestamp" && isEpoch {
+				m[k] = r.formatTime(t)
+				continue
+			}
+			m[k] = r.rewrite(v)
+		}
+	}
+
+	return events
+}
+
+func (r *timeRewriter) rewrite(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = r.rewrite(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = r.rewrite(child)
+		}
+		return v
+	case string:
+		if t, ok := parseTimestamp(v); ok {
+			return r.formatTime(t)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func (r *timeRewriter) formatTime(t time.Time) any {
+	switch r.format {
+	case timeFormatUnix:
+		return t.Unix()
+	case timeFormatUnixMS:
+		return t.UnixMilli()
+	default:
+		return t.In(r.loc).Format(r.format)
+	}
+}
+
+// parseTimestamp Parse an ISO 8601 timestamp with a time zone.
+func parseTimestamp(s string) (time.Time, bool) {
+	// cheap check of the date part before trying the layouts
+	if len(s) < len("2006-01-02T15:04:05Z") || s[4] != '-' || s[7] != '-' {
+		return time.Time{}, false
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
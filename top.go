@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+// printTopValues Print the values with their counts and their share of
+// the total events.
+func printTopValues(w io.Writer, field string, values []valueCount, total int) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCOUNT\tSHARE\n", field)
+	for _, v := range values {
+		fmt.Fprintf(tw, "%s\t%s\t%.1f%%\n", v.Value, locale.Int(v.Count), 100*float64(v.Count)/float64(total))
+	}
+
+	return tw.Flush()
+}
+
+// analysisField The key of the field in the analysis, the Loggly style
+// json.* paths resolved the way lookupField resolves them.
+func analysisField(a *fieldAnalysis, field string) string {
+	if _, ok := a.fields[field]; ok {
+		return field
+	}
+
+	if rest, ok := strings.CutPrefix(field, "json."); ok {
+		for _, key := range []string{rest, "event." + field} {
+			if _, ok := a.fields[key]; ok {
+				return key
+			}
+		}
+	}
+	return field
+}
+
+// runTop Print the most frequent values of the -field over the fetched
+// events, counted by the field analysis of the TUI.
+func runTop(args []string) {
+	var config Config
+	flags := newFlagSet("loggly top", &config)
+	field := flags.String("field", "", "")
+	n := flags.Int("n", 10, "")
+	flags.Parse(args)
+
+	prepareScanConfig(flags, &config)
+
+	if *field == "" {
+		check(usageError(fmt.Errorf("usage: loggly top -field <field> [-n <count>] [options] [query...]")))
+	}
+	if *n <= 0 {
+		check(usageError(fmt.Errorf("n must be greater than 0")))
+	}
+
+	query, queryErr := resolveQuery(config, flags.Args())
+	check(queryErr)
+	if query == "" {
+		query = "*"
+	}
+	check(lintQuery(config, query))
+
+	analysis := newFieldAnalysis()
+	total := 0
+	scanEvents(config, "top", query, func(event any) {
+		total++
+		if m, ok := event.(map[string]any); ok {
+			analysis.Add(m)
+		}
+	})
+
+	key := analysisField(analysis, *field)
+	values := analysis.values[key]
+	if len(values) == 0 {
+		if analysis.fields[key] > 0 {
+			check(fmt.Errorf("%s is an object, give one of its fields", *field))
+		}
+		check(fmt.Errorf("no events with %s", *field))
+	}
+	if missing := total - analysis.fields[key]; missing > 0 {
+		config.warnf("%d events without %s", missing, *field)
+	}
+
+	check(printTopValues(os.Stdout, *field, topValues(values, *n), total))
+}
@@ -3,12 +3,22 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"sort"
 	"strings"
-
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Ajnasz/go-loggly-cli/fieldcache"
+	"github.com/Ajnasz/go-loggly-cli/help"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/notes"
+	"github.com/Ajnasz/go-loggly-cli/platform"
 	"github.com/Ajnasz/go-loggly-cli/search"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -46,6 +56,9 @@ var (
 
 	msgStyle       = lipgloss.NewStyle().Bold(true)
 	timestampStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	noteStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	markStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+	helpTopicStyle = lipgloss.NewStyle().Bold(true)
 )
 
 type queryKeyMap struct {
@@ -56,7 +69,7 @@ func newQueryKeyMap() queryKeyMap {
 	return queryKeyMap{
 		executeQuery: key.NewBinding(
 			key.WithKeys("enter"),
-			key.WithHelp("enter", "execute query"),
+			key.WithHelp("enter", locale.Sprintf("execute query")),
 		),
 	}
 }
@@ -65,21 +78,46 @@ type resultsKeyMap struct {
 	openDetail    key.Binding
 	openRaw       key.Binding
 	openFormatted key.Binding
+	copyResult    key.Binding
+	annotate      key.Binding
+	annotatedOnly key.Binding
+	exportEvents  key.Binding
+	markEvent     key.Binding
 }
 
 func newResultsKeyMap() resultsKeyMap {
 	return resultsKeyMap{
 		openDetail: key.NewBinding(
 			key.WithKeys("enter"),
-			key.WithHelp("enter", "view detail"),
+			key.WithHelp("enter", locale.Sprintf("view detail")),
 		),
 		openRaw: key.NewBinding(
 			key.WithKeys("1"),
-			key.WithHelp("1", "raw view"),
+			key.WithHelp("1", locale.Sprintf("raw view")),
 		),
 		openFormatted: key.NewBinding(
 			key.WithKeys("2"),
-			key.WithHelp("2", "formatted view"),
+			key.WithHelp("2", locale.Sprintf("formatted view")),
+		),
+		copyResult: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", locale.Sprintf("copy")),
+		),
+		annotate: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", locale.Sprintf("note")),
+		),
+		annotatedOnly: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", locale.Sprintf("annotated only")),
+		),
+		exportEvents: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", locale.Sprintf("export with notes")),
+		),
+		markEvent: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", locale.Sprintf("select for session")),
 		),
 	}
 }
@@ -88,21 +126,26 @@ type detailKeyMap struct {
 	closeDetail key.Binding
 	nextDetail  key.Binding
 	prevDetail  key.Binding
+	copyDetail  key.Binding
 }
 
 func newDetailKeyMap() detailKeyMap {
 	return detailKeyMap{
 		closeDetail: key.NewBinding(
 			key.WithKeys("esc"),
-			key.WithHelp("esc", "close detail"),
+			key.WithHelp("esc", locale.Sprintf("close detail")),
 		),
 		nextDetail: key.NewBinding(
 			key.WithKeys("n"),
-			key.WithHelp("n", "next result"),
+			key.WithHelp("n", locale.Sprintf("next result")),
 		),
 		prevDetail: key.NewBinding(
 			key.WithKeys("p"),
-			key.WithHelp("p", "previous result"),
+			key.WithHelp("p", locale.Sprintf("previous result")),
+		),
+		copyDetail: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", locale.Sprintf("copy")),
 		),
 	}
 }
@@ -110,17 +153,32 @@ func newDetailKeyMap() detailKeyMap {
 type fieldKeyMap struct {
 	selectField key.Binding
 	backField   key.Binding
+	exportCSV   key.Binding
+	exportJSON  key.Binding
+	pinField    key.Binding
 }
 
 func newFieldKeyMap() fieldKeyMap {
 	return fieldKeyMap{
 		selectField: key.NewBinding(
 			key.WithKeys("enter"),
-			key.WithHelp("enter", "select field"),
+			key.WithHelp("enter", locale.Sprintf("select field")),
 		),
 		backField: key.NewBinding(
 			key.WithKeys("backspace"),
-			key.WithHelp("backspace", "go up"),
+			key.WithHelp("backspace", locale.Sprintf("go up")),
+		),
+		exportCSV: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", locale.Sprintf("export csv")),
+		),
+		exportJSON: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", locale.Sprintf("export json")),
+		),
+		pinField: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", locale.Sprintf("pin")),
 		),
 	}
 }
@@ -133,7 +191,7 @@ func newValueKeyMap() valueKeyMap {
 	return valueKeyMap{
 		selectValue: key.NewBinding(
 			key.WithKeys("enter"),
-			key.WithHelp("enter", "select value"),
+			key.WithHelp("enter", locale.Sprintf("select value")),
 		),
 	}
 }
@@ -173,6 +231,13 @@ func (d resultItemDelegateRaw) Render(w io.Writer, m list.Model, index int, item
 		}
 	}
 
+	if result.note != "" {
+		line2 = noteLine(result.note, maxLen)
+	}
+	if result.marked {
+		line1 = markStyle.Render(tuiMarks.marked) + line1
+	}
+
 	output := line1
 	if line2 != "" {
 		output += "\n" + line2
@@ -214,6 +279,9 @@ func (d resultItemDelegateFormatted) Render(w io.Writer, m list.Model, index int
 
 	if ts, ok := result.data["timestamp"]; ok {
 		if timestamp, ok := ts.(string); ok {
+			if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+				timestamp = locale.Time(t)
+			}
 			line1 = fmt.Sprintf("%s - %s", timestampStyle.Render(timestamp), line1)
 		}
 	}
@@ -230,6 +298,13 @@ func (d resultItemDelegateFormatted) Render(w io.Writer, m list.Model, index int
 	} else {
 		line2 = preview
 	}
+	if result.note != "" {
+		line2 = noteLine(result.note, maxLen)
+	}
+
+	if result.marked {
+		line1 = markStyle.Render(tuiMarks.marked) + line1
+	}
 
 	output := line1 + "\n" + line2
 	isSelected := index == m.Index()
@@ -241,6 +316,15 @@ func (d resultItemDelegateFormatted) Render(w io.Writer, m list.Model, index int
 	}
 }
 
+// noteLine The note of an event shortened to the width.
+func noteLine(note string, width int) string {
+	line := tuiMarks.note + strings.ReplaceAll(note, "\n", " ")
+	if runes := []rune(line); len(runes) > width && width > 0 {
+		line = string(runes[:width])
+	}
+	return noteStyle.Render(line)
+}
+
 type pane int
 
 const (
@@ -255,20 +339,28 @@ type fieldItem struct {
 	name      string
 	count     int
 	hasNested bool
+	pinned    bool
 }
 
 func (i fieldItem) FilterValue() string { return i.name }
 func (i fieldItem) Title() string {
+	title := i.name
+	if i.pinned {
+		title = tuiMarks.pinned + title
+	}
 	if i.hasNested {
-		return i.name + " »"
+		return title + tuiMarks.nested
 	}
-	return i.name
+	return title
 }
-func (i fieldItem) Description() string { return fmt.Sprintf("%d occurrences", i.count) }
+func (i fieldItem) Description() string { return locale.Sprintf("%d occurrences", i.count) }
 
 type resultItem struct {
-	index int
-	data  map[string]any
+	index  int
+	id     string
+	note   string
+	marked bool
+	data   map[string]any
 }
 
 func (i resultItem) FilterValue() string {
@@ -293,17 +385,11 @@ type valueItem struct {
 
 func (i valueItem) FilterValue() string { return i.value }
 func (i valueItem) Title() string       { return i.value }
-func (i valueItem) Description() string { return fmt.Sprintf("%d occurrences", i.count) }
+func (i valueItem) Description() string { return locale.Sprintf("%d occurrences", i.count) }
 
 type model struct {
-	ctx         context.Context
-	account     string
-	token       string
-	from        string
-	concurrency int
-	to          string
-	size        int
-	maxPages    int64
+	ctx    context.Context
+	config Config
 
 	queryInput           textinput.Model
 	fieldsList           list.Model
@@ -326,21 +412,59 @@ type model struct {
 	paneHeight   int
 
 	results       []map[string]any
+	resultIDs     []string
 	fieldPath     []string // Current nested path like ["nested", "field1"]
 	allFields     map[string]int
 	fieldValues   map[string]map[string]int
 	showingDetail bool
+	// helpView The help topics, shown over the panes by F1.
+	helpView    viewport.Model
+	showingHelp bool
+	// historyList The queries of the history, shown over the panes by
+	// Ctrl+R.
+	historyList    list.Model
+	showingHistory bool
 
 	resultsMode resultMode
 	keyMaps     keyMaps
 
+	// notes Triage notes of the events, nil if they can not be read.
+	notes         *notes.Store
+	annotatedOnly bool
+	annotating    bool
+	noteInput     textinput.Model
+
+	// fieldCache The fields seen in the events of the account,
+	// completing the query.
+	fieldCache *fieldcache.Cache
+
+	// pinned Dotted paths of the pinned fields, listed first.
+	pinned []string
+	// marked Indexes of the results selected for the session file.
+	marked map[int]bool
+
 	err     error
 	loading bool
+	// query The number of the last query run, cancelQuery cancels it, and
+	// queryState how it was stopped.
+	query       int
+	cancelQuery context.CancelFunc
+	queryState  string
 }
 
 type resultsMsg struct {
-	results []map[string]any
-	err     error
+	// query The number of the query, the results of the replaced queries
+	// are dropped.
+	query    int
+	results  []map[string]any
+	ids      []string
+	warnings []string
+	// fields The fields of the events, added to the field cache.
+	fields *fieldcache.Cache
+	err    error
+	// timedOut The query ran over the -timeout. A request running over
+	// the -page-timeout is an error of its own.
+	timedOut bool
 }
 
 type fieldSelectedMsg struct{}
@@ -375,6 +499,9 @@ func initialModel(ctx context.Context, config Config, query string) model {
 	ti.Focus()
 	ti.CharLimit = 500
 	ti.SetValue(query)
+	// tab switches the panes, the right arrow completes the fields
+	ti.ShowSuggestions = true
+	ti.KeyMap.AcceptSuggestion = key.NewBinding(key.WithKeys("right"))
 
 	fieldsList := list.New([]list.Item{}, list.NewDefaultDelegate(), 20, 20)
 	fieldsList.Title = "Fields"
@@ -385,6 +512,9 @@ func initialModel(ctx context.Context, config Config, query string) model {
 		return []key.Binding{
 			fieldKeys.selectField,
 			fieldKeys.backField,
+			fieldKeys.exportCSV,
+			fieldKeys.exportJSON,
+			fieldKeys.pinField,
 		}
 	}
 
@@ -414,6 +544,10 @@ func initialModel(ctx context.Context, config Config, query string) model {
 			resultsKeys.openRaw,
 			resultsKeys.openFormatted,
 			resultsKeys.openDetail,
+			resultsKeys.annotate,
+			resultsKeys.annotatedOnly,
+			resultsKeys.exportEvents,
+			resultsKeys.markEvent,
 		}
 	}
 
@@ -432,29 +566,65 @@ func initialModel(ctx context.Context, config Config, query string) model {
 			resultsKeys.openRaw,
 			resultsKeys.openFormatted,
 			resultsKeys.openDetail,
+			resultsKeys.annotate,
+			resultsKeys.annotatedOnly,
+			resultsKeys.exportEvents,
+			resultsKeys.markEvent,
 		}
 	}
 
 	// Detail viewport for full JSON view
 	detailView := viewport.New(0, 0)
 
+	historyList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	historyList.Title = "History"
+	historyList.SetShowStatusBar(false)
+	historyList.SetShowHelp(false)
+	historyList.DisableQuitKeybindings()
+
+	noteInput := textinput.New()
+	noteInput.Placeholder = "note, empty removes it"
+	noteInput.CharLimit = 200
+
+	if config.Accessible {
+		useAccessibleStyles()
+		fieldsList.SetDelegate(accessibleDelegate())
+		valuesList.SetDelegate(accessibleDelegate())
+		historyList.SetDelegate(accessibleDelegate())
+		for _, l := range []*list.Model{&fieldsList, &valuesList, &resultsListRaw, &resultsListFormatted, &historyList} {
+			accessibleList(l)
+		}
+		accessibleInput(&ti)
+		accessibleInput(&noteInput)
+	}
+
+	fieldCache := fieldcache.New()
+	if fieldCacheOn(config) {
+		fieldCache = loadFieldCache(config.Account)
+	}
+
+	debugView := ""
+	store, err := openNotes()
+	if err != nil {
+		debugView = locale.Sprintf("Notes are not available: %s", err)
+	}
+
 	return model{
 		ctx:                  ctx,
-		account:              config.Account,
-		token:                config.Token,
-		size:                 config.Size,
-		maxPages:             config.MaxPages,
-		from:                 config.From,
-		to:                   config.To,
-		concurrency:          config.Concurrency,
+		config:               config,
 		queryInput:           ti,
 		fieldsList:           fieldsList,
 		valuesList:           valuesList,
 		resultsListRaw:       resultsListRaw,
 		resultsListFormatted: resultsListFormatted,
 		detailView:           detailView,
+		helpView:             viewport.New(0, 0),
+		historyList:          historyList,
 		spinner:              spinner.New(),
-		debugView:            "",
+		debugView:            debugView,
+		notes:                store,
+		fieldCache:           fieldCache,
+		noteInput:            noteInput,
 		currentPane:          queryPane,
 		allFields:            make(map[string]int),
 		fieldValues:          make(map[string]map[string]int),
@@ -472,6 +642,10 @@ func initialModel(ctx context.Context, config Config, query string) model {
 }
 
 func (m model) Init() tea.Cmd {
+	// no blinking cursor and spinner in the accessible mode
+	if m.config.Accessible {
+		return nil
+	}
 	return tea.Batch(textinput.Blink, m.spinner.Tick)
 }
 
@@ -486,6 +660,77 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.annotating {
+			switch msg.String() {
+			case "enter":
+				m.saveNote()
+				return m, nil
+			case "esc":
+				m.annotating = false
+				m.noteInput.Blur()
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.noteInput, cmd = m.noteInput.Update(msg)
+			return m, cmd
+		}
+
+		if m.showingHelp {
+			switch msg.String() {
+			case "esc", "f1":
+				m.showingHelp = false
+				return m, nil
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			}
+
+			var cmd tea.Cmd
+			m.helpView, cmd = m.helpView.Update(msg)
+			return m, cmd
+		}
+
+		if m.showingHistory {
+			if m.historyList.FilterState() != list.Filtering {
+				switch msg.String() {
+				case "enter":
+					return m, m.recallHistory()
+				case "esc":
+					// the first escape clears the filter
+					if m.historyList.FilterState() == list.Unfiltered {
+						m.showingHistory = false
+						return m, nil
+					}
+				case "ctrl+r":
+					m.showingHistory = false
+					return m, nil
+				case "q":
+					return m, tea.Quit
+				}
+			}
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+
+			return m, m.updateHistory(msg)
+		}
+
+		if msg.String() == "f1" {
+			m.showingHelp = true
+			m.helpView.GotoTop()
+			return m, nil
+		}
+
+		if msg.String() == "ctrl+r" {
+			m.openHistory()
+			return m, nil
+		}
+
+		if m.loading && msg.String() == "esc" {
+			m.stopQuery()
+			return m, nil
+		}
+
 		if m.showingDetail {
 			switch {
 			case key.Matches(msg, m.keyMaps.detail.closeDetail):
@@ -511,7 +756,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.showDetailView(item)
 				}
 				return m, nil
-
+			case key.Matches(msg, m.keyMaps.detail.copyDetail):
+				if item, ok := m.resultsListRaw.SelectedItem().(resultItem); ok {
+					m.copyResult(item)
+				}
+				return m, nil
 			}
 		} else if m.currentPane == resultsPane {
 			switch {
@@ -538,9 +787,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 				return m, nil
+			case key.Matches(msg, m.keyMaps.results.copyResult):
+				if item, ok := m.resultsListRaw.SelectedItem().(resultItem); ok {
+					m.copyResult(item)
+				}
+				return m, nil
+			case key.Matches(msg, m.keyMaps.results.annotate):
+				return m, m.startNote()
+			case key.Matches(msg, m.keyMaps.results.annotatedOnly):
+				m.annotatedOnly = !m.annotatedOnly
+				m.updateResultsView()
+				return m, nil
+			case key.Matches(msg, m.keyMaps.results.exportEvents):
+				m.exportEvents()
+				return m, nil
+			case key.Matches(msg, m.keyMaps.results.markEvent):
+				m.toggleMark()
+				return m, nil
 			}
 		} else if m.currentPane == fieldsPane {
+			filtering := m.fieldsList.FilterState() == list.Filtering
 			switch {
+			case !filtering && key.Matches(msg, m.keyMaps.fields.exportCSV):
+				m.exportFields("csv")
+				return m, nil
+			case !filtering && key.Matches(msg, m.keyMaps.fields.exportJSON):
+				m.exportFields("json")
+				return m, nil
+			case !filtering && key.Matches(msg, m.keyMaps.fields.pinField):
+				m.togglePin()
+				return m, nil
 			case key.Matches(msg, m.keyMaps.fields.selectField):
 				cmd := m.selectField()
 				return m, cmd
@@ -555,22 +831,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch {
 			case key.Matches(msg, m.keyMaps.values.selectValue):
 				cmd := m.addValueToQuery()
-				if m.loading {
-					return m, nil
-				}
-
-				m.loading = true
-				return m, tea.Batch(m.executeQuery(), cmd)
+				return m, tea.Batch(m.startQuery(), cmd)
 			}
 		} else if m.currentPane == queryPane {
 			switch {
 			case key.Matches(msg, m.keyMaps.query.executeQuery):
-				if m.loading {
-					return m, nil
-				}
-
-				m.loading = true
-				return m, m.executeQuery()
+				return m, m.startQuery()
 			}
 		}
 
@@ -578,14 +844,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c", "q":
 			return m, tea.Quit
 
+		case "ctrl+s":
+			m.saveSession()
+			return m, nil
+
 		case "tab":
 			m.currentPane = (m.currentPane + 1) % 4
 			m.updateFocus()
+			if m.config.Accessible {
+				m.announcePane()
+			}
 			return m, nil
 
 		case "shift+tab":
 			m.currentPane = (m.currentPane - 1 + 4) % 4
 			m.updateFocus()
+			if m.config.Accessible {
+				m.announcePane()
+			}
 			return m, nil
 
 		}
@@ -596,13 +872,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case resultsMsg:
+		if msg.query != m.query {
+			return m, nil
+		}
 		m.loading = false
+		m.stopQuery()
+		switch {
+		case msg.timedOut:
+			m.queryState = locale.Sprintf("Timed out after %s", m.config.Timeout)
+			m.debugView = ""
+			return m, nil
+		case errors.Is(msg.err, context.Canceled):
+			m.queryState = locale.Sprintf("Cancelled")
+			m.debugView = ""
+			return m, nil
+		}
 		if msg.err != nil {
 			m.err = msg.err
-			m.debugView = fmt.Sprintf("Error: %v", msg.err)
+			m.debugView = locale.Sprintf("Error: %s", msg.err)
 			return m, nil
 		}
 		m.results = msg.results
+		m.resultIDs = msg.ids
+		m.marked = nil
 		m.analyzeResults()
 		m.updateFieldsList()
 		m.updateResultsView()
@@ -610,7 +902,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.width > 0 && m.height > 0 {
 			m.updateSizes()
 		}
-		m.debugView = fmt.Sprintf("Loaded %d results", len(msg.results))
+		if msg.fields != nil {
+			m.fieldCache.Merge(msg.fields)
+		}
+		m.debugView = locale.Sprintf("Loaded %d results", len(msg.results))
+		if len(msg.results) == 0 {
+			if hints := fieldHints(m.fieldCache, m.queryInput.Value()); len(hints) > 0 {
+				m.debugView += " • " + strings.Join(hints, " • ")
+			}
+		}
+		if len(msg.warnings) > 0 {
+			m.debugView += " • Warning: " + strings.Join(msg.warnings, " • ")
+		}
 		return m, nil
 
 	case fieldSelectedMsg:
@@ -618,16 +921,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Update active pane
+	if m.showingHistory {
+		return m, m.updateHistory(msg)
+	}
 	if m.showingDetail {
 		var cmd tea.Cmd
 		m.detailView, cmd = m.detailView.Update(msg)
 		cmds = append(cmds, cmd)
 	} else {
+		previous := -1
+		if l := m.activeList(); l != nil {
+			previous = l.Index()
+		}
+
 		switch m.currentPane {
 		case queryPane:
 			var cmd tea.Cmd
 			m.queryInput, cmd = m.queryInput.Update(msg)
 			cmds = append(cmds, cmd)
+			if _, ok := msg.(tea.KeyMsg); ok {
+				m.updateQuerySuggestions()
+			}
 		case fieldsPane:
 			var cmd tea.Cmd
 			m.fieldsList, cmd = m.fieldsList.Update(msg)
@@ -642,6 +956,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.resultsListFormatted, _ = m.resultsListFormatted.Update(msg)
 			cmds = append(cmds, cmd)
 		}
+
+		if m.config.Accessible {
+			m.announceSelection(previous)
+		}
 	}
 
 	return m, tea.Batch(cmds...)
@@ -677,8 +995,44 @@ func (m *model) updateSizes() {
 	// Detail view uses most of the screen
 	m.detailView.Width = m.width - 10
 	m.detailView.Height = m.height - 6
-
+	m.helpView.Width = m.detailView.Width
+	m.helpView.Height = m.detailView.Height
+	m.historyList.SetSize(m.detailView.Width, m.detailView.Height)
 	m.debugView = fmt.Sprintf("Sizes: total=%d, left=%d, mid=%d, right=%d, hight=%d", m.width, leftPaneWidth, midPaneWidth, rightPaneWidth, paneHeight)
+
+	// the accessible mode shows one pane at a time, in the whole width
+	if m.config.Accessible {
+		m.paneHeight = m.height - 7
+		for _, l := range []*list.Model{&m.fieldsList, &m.valuesList, &m.resultsListRaw, &m.resultsListFormatted} {
+			l.SetSize(m.width, m.paneHeight)
+		}
+		m.detailView.Width = m.width
+		m.detailView.Height = m.height - 4
+		m.helpView.Width = m.width
+		m.helpView.Height = m.height - 4
+		m.historyList.SetSize(m.width, m.height-4)
+		m.debugView = ""
+	}
+
+	m.renderHelp()
+}
+
+// renderHelp Fill the help view with the help topics, wrapped to its
+// width.
+func (m *model) renderHelp() {
+	title := func(s string) string { return helpTopicStyle.Render(s) }
+	if m.config.Accessible {
+		title = nil
+	}
+
+	var b strings.Builder
+	for i, topic := range help.Topics {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		topic.Render(&b, title)
+	}
+	m.helpView.SetContent(lipgloss.NewStyle().Width(m.helpView.Width).Render(b.String()))
 }
 
 func (m *model) updateFocus() {
@@ -691,16 +1045,39 @@ func (m *model) updateFocus() {
 }
 
 func (m model) View() string {
+	if m.config.Accessible {
+		return m.accessibleView()
+	}
+
 	if m.width == 0 {
 		return m.spinner.View()
 	}
 
+	if m.showingHelp {
+		content := detailViewStyle.Width(m.width - 4).Render(m.helpView.View())
+		return lipgloss.JoinVertical(lipgloss.Left,
+			titleStyle.Render(locale.Sprintf("Help")),
+			content,
+			"",
+			helpStyle.Render(locale.Sprintf("↑/↓: Scroll • Esc/F1: Close • q: Quit")),
+		)
+	}
+
+	if m.showingHistory {
+		content := detailViewStyle.Width(m.width - 4).Render(m.historyList.View())
+		return lipgloss.JoinVertical(lipgloss.Left,
+			content,
+			m.debugView,
+			helpStyle.Render(locale.Sprintf("↑/↓: Select • /: Filter • Enter: Run • Esc/Ctrl+R: Close • q: Quit")),
+		)
+	}
+
 	// If showing detail view, render it full screen
 	if m.showingDetail {
-		helpText := helpStyle.Render("↑/↓: Scroll • Esc: Back to list • q: Quit")
+		helpText := helpStyle.Render(locale.Sprintf("↑/↓: Scroll • n/p: Next/Previous • y: Copy • Esc: Back to list • q: Quit"))
 		content := detailViewStyle.Width(m.width - 4).Render(m.detailView.View())
 		return lipgloss.JoinVertical(lipgloss.Left,
-			titleStyle.Render("Result Detail"),
+			titleStyle.Render(locale.Sprintf("Result Detail")),
 			content,
 			"",
 			helpText,
@@ -714,7 +1091,7 @@ func (m model) View() string {
 	}
 	querySection := queryStyle.Width(m.width - 2).Render(
 		lipgloss.JoinVertical(lipgloss.Left,
-			titleStyle.Render("Query"),
+			titleStyle.Render(locale.Sprintf("Query")),
 			m.queryInput.View(),
 		),
 	)
@@ -756,15 +1133,19 @@ func (m model) View() string {
 		resultsSection,
 	)
 
-	help := helpStyle.Render("Tab/Shift+Tab: Switch panes • Enter: Execute/Select/View • Backspace: Go up • q: Quit")
+	help := helpStyle.Render(locale.Sprintf("Tab/Shift+Tab: Switch panes • Enter: Execute/Select/View • Backspace: Go up • x/X: Export fields as CSV/JSON • a: Note • A: Annotated only • e: Export events • p/m: Pin field/Select event • Ctrl+S: Save session • Ctrl+R: History • F1: Help • q: Quit"))
 
 	status := ""
-	if m.loading {
-		status = m.spinner.View() + " Loading..."
+	if m.annotating {
+		status = locale.Sprintf("Note: %s", m.noteInput.View())
+	} else if m.loading {
+		status = m.spinner.View() + " " + locale.Sprintf("Loading...") + " " + locale.Sprintf("Esc: Cancel")
+	} else if m.queryState != "" {
+		status = m.queryState
 	} else if m.err != nil {
-		status = fmt.Sprintf("Error: %s", m.err)
+		status = locale.Sprintf("Error: %s", m.err)
 	} else if len(m.results) > 0 {
-		status = fmt.Sprintf("%d results", len(m.results))
+		status = locale.Sprintf("%d results", len(m.results))
 	}
 
 	status = status + "    " + m.debugView
@@ -780,73 +1161,177 @@ func (m model) View() string {
 	)
 }
 
-func (m *model) executeQuery() tea.Cmd {
+// startQuery Run the query in a context of its own, limited by the
+// -timeout. A running query is cancelled, so a stuck request does not
+// hold up the session.
+func (m *model) startQuery() tea.Cmd {
+	m.stopQuery()
+	m.query++
+	m.loading = true
+	m.queryState = ""
+	m.err = nil
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancelQuery = cancel
+	if m.config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, m.config.Timeout)
+		// a single cancel releases both
+		m.cancelQuery = func() {
+			cancelTimeout()
+			cancel()
+		}
+	}
+
+	return m.executeQuery(ctx, m.query)
+}
+
+// stopQuery Cancel the running query.
+func (m *model) stopQuery() {
+	if m.cancelQuery != nil {
+		m.cancelQuery()
+		m.cancelQuery = nil
+	}
+}
+
+func (m *model) executeQuery(ctx context.Context, n int) tea.Cmd {
+	query := m.queryInput.Value()
 	return func() tea.Msg {
-		query := m.queryInput.Value()
 		if query == "" {
-			return resultsMsg{results: []map[string]any{}}
+			return resultsMsg{query: n, results: []map[string]any{}}
+		}
+
+		// stderr is hidden behind the TUI, the history is recorded here
+		// to show its failure in the status line
+		config := m.config
+		config.HistoryFile = ""
+		started := time.Now()
+
+		var msg resultsMsg
+		results, err := auditQuery(config, "tui", query, func() (int64, error) {
+			msg = m.fetchResults(ctx, query)
+			return int64(len(msg.results)), msg.err
+		})
+		// the requests fail in many ways when the query is cancelled
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if historyErr := recordHistory(m.config, started, "tui", query, results, err); historyErr != nil {
+			msg.warnings = append(msg.warnings, locale.Sprintf("The history could not be updated: %s", historyErr))
 		}
+		msg.query = n
+		msg.err = err
+		msg.timedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+		return msg
+	}
+}
 
-		c := search.New(m.account, m.token).SetConcurrency(m.concurrency)
-		q := search.NewQuery(query).Size(m.size).From(m.from).To(m.to).MaxPage(m.maxPages)
-		resChan, errChan := c.Fetch(m.ctx, *q)
+// fetchResults Run the query and parse the messages of the events.
+func (m *model) fetchResults(ctx context.Context, query string) resultsMsg {
+	c, err := m.config.newClient()
+	if err != nil {
+		return resultsMsg{err: err}
+	}
 
-		var results []map[string]any
+	// logs would garble the screen, unless stderr is redirected
+	if isTerminal(os.Stderr) {
+		c.SetLogger(nil)
+	}
 
-		for {
-			select {
-			case <-m.ctx.Done():
-				return resultsMsg{err: m.ctx.Err()}
-			case res, ok := <-resChan:
-				if !ok {
-					return resultsMsg{results: results}
+	// stderr is hidden behind the TUI, show the warnings in the status line
+	var warningsMu sync.Mutex
+	var warnings []string
+	c.SetWarningHandler(func(warning string) {
+		warningsMu.Lock()
+		defer warningsMu.Unlock()
+		warnings = append(warnings, warning)
+	})
+	var resChan chan search.Response
+	var errChan chan error
+	if m.config.Input != "" {
+		stream, err := archiveStream(ctx, m.config, query)
+		if err != nil {
+			return resultsMsg{err: err}
+		}
+		resChan, errChan = stream.Responses, stream.Errors
+	} else {
+		resChan, errChan = c.Fetch(ctx, *m.config.newQuery(query))
+	}
+
+	messageFields := splitList(m.config.MessageField)
+	fields := newFieldRecorder(m.config)
+	var results []map[string]any
+	var ids []string
+
+	for {
+		select {
+		case <-ctx.Done():
+			return resultsMsg{err: ctx.Err()}
+		case res, ok := <-resChan:
+			if !ok {
+				var observed *fieldcache.Cache
+				if fields != nil {
+					observed = fields.observed
 				}
-				for _, event := range res.Events {
-					eventMap := event.(map[string]any)
-					if logmsg, ok := eventMap["logmsg"].(string); ok {
-						var parsed map[string]any
-						if err := json.Unmarshal([]byte(logmsg), &parsed); err == nil {
-							results = append(results, parsed)
-						}
-					}
+				warningsMu.Lock()
+				defer warningsMu.Unlock()
+				if err := fields.save(); err != nil {
+					warnings = append(warnings, locale.Sprintf("The field cache could not be updated: %s", err))
 				}
-			case err := <-errChan:
-				if err != nil {
-					return resultsMsg{err: err}
+				return resultsMsg{results: results, ids: ids, warnings: warnings, fields: observed}
+			}
+			fields.Add(res.Events)
+			for _, event := range res.Events {
+				eventMap := event.(map[string]any)
+				if msg, ok := messageOf(eventMap, messageFields); ok {
+					parsed, err := decodeMessage(msg)
+					if err != nil {
+						parsed = map[string]any{rawField: messageText(msg)}
+					}
+					results = append(results, parsed)
+					id, _ := eventMap["id"].(string)
+					ids = append(ids, id)
 				}
 			}
+		case err := <-errChan:
+			if err != nil {
+				return resultsMsg{err: err}
+			}
 		}
 	}
 }
 
-func (m *model) analyzeResults() {
-	m.allFields = make(map[string]int)
-	m.fieldValues = make(map[string]map[string]int)
-
-	for _, result := range m.results {
-		m.analyzeObject(result, []string{})
+// updateQuerySuggestions Complete the last term of the query from the
+// field cache, when the cursor is at its end.
+func (m *model) updateQuerySuggestions() {
+	value := m.queryInput.Value()
+	if m.queryInput.Position() != len([]rune(value)) {
+		m.queryInput.SetSuggestions(nil)
+		return
 	}
-}
 
-func (m *model) analyzeObject(obj map[string]any, path []string) {
-	for key, value := range obj {
-		fullPath := append(path, key)
-		pathStr := strings.Join(fullPath, ".")
-		m.allFields[pathStr]++
+	start := strings.LastIndexFunc(value, unicode.IsSpace) + 1
+	var suggestions []string
+	for _, c := range completeTerm(value[start:], m.fieldCache) {
+		suggestions = append(suggestions, value[:start]+strings.TrimRight(c, " "))
+	}
+	m.queryInput.SetSuggestions(suggestions)
 
-		switch v := value.(type) {
-		case map[string]any:
-			m.analyzeObject(v, fullPath)
-		default:
-			valueStr := fmt.Sprintf("%v", v)
-			if m.fieldValues[pathStr] == nil {
-				m.fieldValues[pathStr] = make(map[string]int)
-			}
-			m.fieldValues[pathStr][valueStr]++
+	if m.config.Accessible {
+		if s := m.queryInput.CurrentSuggestion(); s != "" && s != value {
+			m.debugView = locale.Sprintf("Suggestion: %s, the right arrow accepts it", s)
 		}
 	}
 }
 
+func (m *model) analyzeResults() {
+	a := newFieldAnalysis()
+	for _, result := range m.results {
+		a.Add(result)
+	}
+	m.allFields, m.fieldValues = a.fields, a.values
+}
+
 func (m *model) updateFieldsList() {
 	// Get fields at current path level
 	prefix := ""
@@ -875,10 +1360,14 @@ func (m *model) updateFieldsList() {
 	var fields []fieldItem
 	for field, count := range fieldValueCounts {
 		hasNested := hasNestedFields[field]
-		fields = append(fields, fieldItem{name: field, count: count, hasNested: hasNested})
+		pinned := slices.Contains(m.pinned, prefix+field)
+		fields = append(fields, fieldItem{name: field, count: count, hasNested: hasNested, pinned: pinned})
 	}
 
 	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].pinned != fields[j].pinned {
+			return fields[i].pinned
+		}
 		return fields[i].count > fields[j].count
 	})
 
@@ -909,9 +1398,9 @@ func (m *model) selectField() tea.Cmd {
 		if hasNested {
 			m.fieldPath = testPath
 			m.updateFieldsList()
-			m.debugView = fmt.Sprintf("Selected nested field: %s", pathStr)
+			m.debugView = locale.Sprintf("Selected nested field: %s", pathStr)
 		} else {
-			m.debugView = fmt.Sprintf("Selected leaf field: %s", pathStr)
+			m.debugView = locale.Sprintf("Selected leaf field: %s", pathStr)
 		}
 
 		// Update values list for this field
@@ -945,12 +1434,21 @@ func (m *model) updateResultsView() {
 	var items []list.Item
 
 	for i, result := range m.results {
-		m.resultsListRaw.SetItems(items)
-		m.resultsListFormatted.SetItems(items)
-		items = append(items, resultItem{
+		item := resultItem{
 			index: i,
 			data:  result,
-		})
+		}
+		if i < len(m.resultIDs) {
+			item.id = m.resultIDs[i]
+		}
+		if note, ok := m.noteOf(item.id); ok {
+			item.note = note.Text
+		}
+		item.marked = m.marked[i]
+		if m.annotatedOnly && item.note == "" {
+			continue
+		}
+		items = append(items, item)
 	}
 
 	m.resultsListRaw.SetItems(items)
@@ -987,7 +1485,7 @@ func (m *model) addValueToQuery() tea.Cmd {
 		value := selectedValue.value
 
 		m.queryInput.SetValue(replaceExisitingSearch(current, fieldStr, value))
-		m.debugView = fmt.Sprintf("Added to query: %s:%s", fieldStr, value)
+		m.debugView = locale.Sprintf("Added to query: %s:%s", fieldStr, value)
 		return func() tea.Msg { return fieldSelectedMsg{} }
 	}
 
@@ -996,17 +1494,56 @@ func (m *model) addValueToQuery() tea.Cmd {
 
 func (m *model) showDetailView(item resultItem) {
 	data, _ := json.MarshalIndent(item.data, "", "  ")
-	m.detailView.SetContent(string(data))
+	content := string(data)
+	if item.note != "" {
+		content = noteStyle.Render(tuiMarks.note+item.note) + "\n\n" + content
+	}
+	m.detailView.SetContent(content)
+
+	if m.config.Accessible {
+		m.debugView = locale.Sprintf("Result %d of %d", m.resultsListRaw.Index()+1, len(m.resultsListRaw.Items()))
+	}
+}
+
+func (m *model) copyResult(item resultItem) {
+	data, _ := json.MarshalIndent(item.data, "", "  ")
+	if err := platform.CopyToClipboard(string(data), os.Stderr); err != nil {
+		m.debugView = locale.Sprintf("Copy failed: %s", err)
+		return
+	}
+	m.debugView = locale.Sprintf("Copied to clipboard")
+}
+
+func (m *model) exportFields(format string) {
+	if len(m.fieldValues) == 0 {
+		m.debugView = locale.Sprintf("Nothing to export, run a query first")
+		return
+	}
+
+	name, err := exportFields(format, summarizeFields(m.allFields, m.fieldValues, exportTopValues), time.Now())
+	if err != nil {
+		m.debugView = locale.Sprintf("Export failed: %s", err)
+		return
+	}
+	m.debugView = locale.Sprintf("Exported the field analysis to %s", name)
 }
 
 func runInteractive(ctx context.Context, config Config, query string) {
-	p := tea.NewProgram(
-		initialModel(ctx, config, query),
-		tea.WithAltScreen(),
-	)
+	runModel(initialModel(ctx, config, query))
+}
+
+// runModel Run the terminal UI with a prepared model.
+func runModel(m model) {
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	// stdin may be a pipe which provided the query, read the keys from the terminal
+	if !isTerminal(os.Stdin) {
+		opts = append(opts, tea.WithInputTTY())
+	}
+
+	p := tea.NewProgram(m, opts...)
 
 	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running interactive mode: %s\n", err)
+		fmt.Fprintln(os.Stderr, locale.Sprintf("Error: the interactive mode failed: %s", err))
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Ajnasz/go-loggly-cli/histogram"
+	"github.com/Ajnasz/go-loggly-cli/locale"
+)
+
+const histogramBarWidth = 50
+
+// valueHistogram collects the values of the -value-histogram field.
+type valueHistogram struct {
+	field   string
+	hist    *histogram.Histogram
+	skipped int
+}
+
+func newValueHistogram(config Config) *valueHistogram {
+	if config.ValueHistogram == "" {
+		return nil
+	}
+
+	hist := histogram.New(config.Buckets)
+	hist.FormatCount = locale.Int[int]
+
+	return &valueHistogram{
+		field: config.ValueHistogram,
+		hist:  hist,
+	}
+}
+
+func (h *valueHistogram) Write(events []any) error {
+	for _, event := range events {
+		v, ok := lookupField(event, h.field)
+		if !ok {
+			h.skipped++
+			continue
+		}
+
+		f, ok := numericValue(v)
+		if !ok {
+			h.skipped++
+			continue
+		}
+
+		h.hist.Add(f)
+	}
+
+	return nil
+}
+
+func (h *valueHistogram) Print(w io.Writer) error {
+	if err := h.hist.Render(w, histogramBarWidth); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s values of %s", locale.Int(h.hist.Len()), h.field)
+	if err == nil && h.skipped > 0 {
+		_, err = fmt.Fprintf(w, ", %s events without a numeric value", locale.Int(h.skipped))
+	}
+	if err == nil {
+		_, err = fmt.Fprintln(w)
+	}
+
+	return err
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Ajnasz/go-loggly-cli/locale"
+	"github.com/Ajnasz/go-loggly-cli/scheduler"
+)
+
+// clearScreen Moves the cursor home and clears the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// runWatch Run the search, or the count, every interval until
+// interrupted. The terminals are cleared before each run, and every run
+// ends with a line on the standard error telling how the number of the
+// events changed since the previous one, a delimiter of the runs when
+// the output is not a terminal. A relative time range moves with the
+// runs.
+func runWatch(ctx context.Context, config Config, query string, interval time.Duration, count bool) {
+	tty := isTerminal(os.Stdout) && (config.Output == "" || config.Tee)
+	// the events of every run replace the previous ones
+	config.NoPager = true
+
+	previous := int64(-1)
+	s := scheduler.New(scheduler.Options{Interval: interval})
+	s.Every(ctx, "watch", func(ctx context.Context) error {
+		if tty {
+			fmt.Print(clearScreen)
+			fmt.Fprintln(os.Stderr, locale.Sprintf("Every %s: %s", interval, query))
+		}
+
+		if config.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+			defer cancel()
+		}
+
+		var n int64
+		if count {
+			check(startAudit(config, "count", query))
+			n = execCount(ctx, config, query)
+		} else {
+			check(startAudit(config, "search", query))
+			n = int64(sendQuery(ctx, config, query))
+		}
+		check(finishAudit(n, nil))
+
+		now := locale.Time(time.Now())
+		if previous < 0 {
+			fmt.Fprintln(os.Stderr, locale.Sprintf("%s: %d events", now, n))
+		} else {
+			fmt.Fprintln(os.Stderr, locale.Sprintf("%s: %d events, %+d since the previous run", now, n, n-previous))
+		}
+		previous = n
+		return nil
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import "github.com/Ajnasz/go-loggly-cli/expr"
+
+// whereFilter keeps the events matching the -where expression.
+type whereFilter struct {
+	expr   *expr.Expr
+	config Config
+	warned map[string]bool
+}
+
+// newWhereFilter Compile the -where expression, returns nil when it is
+// not set.
+func newWhereFilter(config Config) (*whereFilter, error) {
+	if config.Where == "" {
+		return nil, nil
+	}
+
+	e, err := expr.Parse(config.Where)
+	if err != nil {
+		return nil, err
+	}
+
+	return &whereFilter{expr: e, config: config, warned: make(map[string]bool)}, nil
+}
+
+// Apply Return the matching events. Events failing to evaluate, like
+// comparing a string field with a number, are dropped with a warning
+// printed once per distinct error.
+func (f *whereFilter) Apply(events []any) []any {
+	if f == nil {
+		return events
+	}
+
+	var ret []any
+	for _, event := range events {
+		ok, err := f.expr.Match(func(path string) (any, bool) {
+			return lookupField(event, path)
+		})
+
+		if err != nil {
+			if !f.warned[err.Error()] {
+				f.warned[err.Error()] = true
+				f.config.warnf("%s, skipping the event", err)
+			}
+			continue
+		}
+
+		if ok {
+			ret = append(ret, event)
+		}
+	}
+
+	return ret
+}